@@ -0,0 +1,142 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/your-username/coffee-cups-system/internal/config"
+	"github.com/your-username/coffee-cups-system/internal/database"
+	"github.com/your-username/coffee-cups-system/internal/services"
+)
+
+const usage = `Usage: ledger <command> [options]
+
+Commands:
+  check              Verify that every transaction and account balances
+  balances [-at T]   Show every account's balance, now or at T (2006-01-02 or RFC 3339)
+  open               Post opening balances for data from before the ledger existed`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+
+	command, args := os.Args[1], os.Args[2:]
+	var err error
+	switch command {
+	case "check":
+		err = runCheck()
+	case "balances":
+		err = runBalances(args)
+	case "open":
+		err = runOpen()
+	default:
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		log.Fatalf("Ledger %s failed: %v", command, err)
+	}
+}
+
+// openLedger connects to the configured database and returns its ledger
+func openLedger() (*database.Database, *services.LedgerService, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	db, err := database.New(cfg.Database)
+	if err != nil {
+		return nil, nil, err
+	}
+	return db, services.NewLedgerService(db.DB), nil
+}
+
+// runCheck prints every inconsistency and fails when there is any
+func runCheck() error {
+	db, ledger, err := openLedger()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	check, err := ledger.Check()
+	if err != nil {
+		return err
+	}
+	for _, problem := range check.Problems {
+		fmt.Println(problem)
+	}
+	log.Printf("Checked %d transactions over %d accounts", check.Transactions, check.Accounts)
+	if len(check.Problems) > 0 {
+		return fmt.Errorf("found %d problems", len(check.Problems))
+	}
+	return nil
+}
+
+// runBalances prints the balance of every account at the requested time
+func runBalances(args []string) error {
+	flags := flag.NewFlagSet("balances", flag.ExitOnError)
+	at := flags.String("at", "", "show balances as they were at this date or time")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var asOf *time.Time
+	if *at != "" {
+		t, err := parseTime(*at)
+		if err != nil {
+			return err
+		}
+		asOf = &t
+	}
+
+	db, ledger, err := openLedger()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	balances, err := ledger.Balances(asOf)
+	if err != nil {
+		return err
+	}
+	for _, b := range balances {
+		fmt.Printf("%-30s %15s\n", b.Code, b.Balance)
+	}
+	return nil
+}
+
+// parseTime accepts a date, meaning the end of that day, or an RFC 3339 time
+func parseTime(value string) (time.Time, error) {
+	if day, err := time.Parse("2006-01-02", value); err == nil {
+		return day.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: use 2006-01-02 or RFC 3339", value)
+	}
+	return t, nil
+}
+
+// runOpen posts the opening balances of a ledger that has no transactions yet
+func runOpen() error {
+	db, ledger, err := openLedger()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	opening, err := ledger.Open()
+	if err != nil {
+		return err
+	}
+	log.Printf("Posted opening balances to %d accounts", len(opening.Postings))
+	return nil
+}
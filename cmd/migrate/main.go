@@ -1,25 +1,146 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log"
+	"os"
 
 	"github.com/your-username/coffee-cups-system/internal/config"
 	"github.com/your-username/coffee-cups-system/internal/database"
 )
 
+const usage = `Usage: migrate <command> [options]
+
+Commands:
+  up                 Apply all pending migrations
+  down [-steps N]    Roll back the last N migrations (default 1)
+  status             Show applied and pending migrations
+  create <name>      Create a new numbered up/down migration pair`
+
 func main() {
-	// Load configuration
+	if len(os.Args) < 2 {
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+
+	command, args := os.Args[1], os.Args[2:]
+	var err error
+	switch command {
+	case "up":
+		err = runUp()
+	case "down":
+		err = runDown(args)
+	case "status":
+		err = runStatus()
+	case "create":
+		err = runCreate(args)
+	default:
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		log.Fatalf("Migration %s failed: %v", command, err)
+	}
+}
+
+// openMigrator connects to the configured database and returns its migrator
+func openMigrator() (*database.Database, *database.Migrator, error) {
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		return nil, nil, fmt.Errorf("failed to load configuration: %w", err)
 	}
 
-	// Initialize database
 	db, err := database.New(cfg.Database)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		return nil, nil, err
+	}
+
+	migrator, err := db.Migrator()
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	return db, migrator, nil
+}
+
+// runUp applies all pending migrations
+func runUp() error {
+	db, migrator, err := openMigrator()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	applied, err := migrator.Up()
+	for _, m := range applied {
+		log.Printf("Applied %04d_%s", m.Version, m.Name)
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("Database is up to date (%d migrations applied)", len(applied))
+	return nil
+}
+
+// runDown rolls back the requested number of migrations
+func runDown(args []string) error {
+	flags := flag.NewFlagSet("down", flag.ExitOnError)
+	steps := flags.Int("steps", 1, "number of migrations to roll back")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	db, migrator, err := openMigrator()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	rolledBack, err := migrator.Down(*steps)
+	for _, m := range rolledBack {
+		log.Printf("Rolled back %04d_%s", m.Version, m.Name)
+	}
+	return err
+}
+
+// runStatus prints every migration with its applied timestamp
+func runStatus() error {
+	db, migrator, err := openMigrator()
+	if err != nil {
+		return err
 	}
 	defer db.Close()
 
-	log.Println("Database migration completed successfully")
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	for _, s := range statuses {
+		applied := "pending"
+		if s.AppliedAt != nil {
+			applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%04d  %-40s %s\n", s.Version, s.Name, applied)
+	}
+	return nil
+}
+
+// runCreate writes a new migration pair for every dialect
+func runCreate(args []string) error {
+	flags := flag.NewFlagSet("create", flag.ExitOnError)
+	dir := flags.String("dir", database.MigrationsDir, "migrations source directory")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: migrate create [-dir path] <name>")
+	}
+
+	files, err := database.CreateMigration(*dir, flags.Arg(0))
+	for _, file := range files {
+		log.Printf("Created %s", file)
+	}
+	return err
 }
@@ -29,6 +29,18 @@ func main() {
 		logger.Fatal("Failed to connect to database", "error", err)
 	}
 
+	// Refuse to serve an outdated schema; migrations are applied by
+	// cmd/migrate unless auto_migrate is enabled
+	pending, err := db.EnsureSchema(cfg.Database.AutoMigrate)
+	if err != nil {
+		logger.Fatal("Failed to prepare database schema", "error", err)
+	}
+	if pending > 0 && !cfg.Database.AllowPendingMigrations {
+		logger.Fatal("Database schema is out of date, run `migrate up`", "pending", pending)
+	} else if pending > 0 {
+		logger.Warn("Database schema is out of date, run `migrate up`", "pending", pending)
+	}
+
 	// Initialize services
 	services := services.NewServices(db.DB, logger)
 
@@ -2,14 +2,17 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/your-username/coffee-cups-system/internal/config"
 	"github.com/your-username/coffee-cups-system/internal/database"
 	"github.com/your-username/coffee-cups-system/internal/logger"
+	"github.com/your-username/coffee-cups-system/internal/scheduler"
 	"github.com/your-username/coffee-cups-system/internal/server"
 	"github.com/your-username/coffee-cups-system/internal/services"
 	"github.com/your-username/coffee-cups-system/internal/telegram"
@@ -31,6 +34,18 @@ func main() {
 		logger.Fatal("Failed to connect to database", "error", err)
 	}
 
+	// Refuse to serve an outdated schema; migrations are applied by
+	// cmd/migrate unless auto_migrate is enabled
+	pending, err := db.EnsureSchema(cfg.Database.AutoMigrate)
+	if err != nil {
+		logger.Fatal("Failed to prepare database schema", "error", err)
+	}
+	if pending > 0 && !cfg.Database.AllowPendingMigrations {
+		logger.Fatal("Database schema is out of date, run `migrate up`", "pending", pending)
+	} else if pending > 0 {
+		logger.Warn("Database schema is out of date, run `migrate up`", "pending", pending)
+	}
+
 	// Initialize services
 	services := services.NewServices(db.DB, logger)
 
@@ -46,8 +61,12 @@ func main() {
 		logger.Info("No valid Telegram bot token provided, running without bot")
 	}
 
-	// Initialize HTTP server
-	httpServer := server.New(cfg.Server, services, logger)
+	// Initialize HTTP server, receiving bot updates on it in webhook mode
+	var mounts []server.Mount
+	if bot != nil && cfg.Telegram.Mode == telegram.ModeWebhook {
+		mounts = append(mounts, server.Mount{Path: cfg.Telegram.WebhookPath, Handler: bot.WebhookHandler()})
+	}
+	httpServer := server.New(cfg.Server, services, logger, mounts...)
 
 	// Start services
 	ctx, cancel := context.WithCancel(context.Background())
@@ -62,6 +81,15 @@ func main() {
 		}()
 	}
 
+	// Start payment reminders, which are delivered by the bot
+	if bot != nil && cfg.Reminders.Enabled {
+		if reminders, err := newReminderScheduler(cfg.Reminders, services, bot, logger); err != nil {
+			logger.Warn("Failed to start payment reminders, continuing without them", "error", err)
+		} else {
+			go reminders.Run(ctx)
+		}
+	}
+
 	// Start HTTP server
 	go func() {
 		if err := httpServer.Start(); err != nil {
@@ -82,3 +110,25 @@ func main() {
 	cancel()
 	httpServer.Stop()
 }
+
+// newReminderScheduler schedules the payment reminder job
+func newReminderScheduler(cfg config.RemindersConfig, services *services.Services, bot *telegram.Bot, logger *logger.Logger) (*scheduler.Scheduler, error) {
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reminders timezone: %w", err)
+	}
+
+	reminders, err := scheduler.NewPaymentReminders(cfg, services, bot)
+	if err != nil {
+		return nil, err
+	}
+
+	s := scheduler.New(location, logger)
+	if err := s.Add("payment reminders", cfg.Schedule, reminders.Run); err != nil {
+		return nil, err
+	}
+	if err := s.Add("queued payment reminders", "* * * * *", reminders.RunQueued); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
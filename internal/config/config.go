@@ -3,16 +3,18 @@ package config
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 // Config holds all configuration for the application
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Telegram TelegramConfig `mapstructure:"telegram"`
-	LogLevel string         `mapstructure:"log_level"`
+	Server    ServerConfig    `mapstructure:"server"`
+	Database  DatabaseConfig  `mapstructure:"database"`
+	Telegram  TelegramConfig  `mapstructure:"telegram"`
+	Reminders RemindersConfig `mapstructure:"reminders"`
+	LogLevel  string          `mapstructure:"log_level"`
 }
 
 // ServerConfig holds HTTP server configuration
@@ -21,20 +23,58 @@ type ServerConfig struct {
 	Port int    `mapstructure:"port"`
 }
 
-// DatabaseConfig holds database configuration
+// DatabaseConfig holds database configuration.
+// Driver selects "postgres" (default) or "sqlite"; for SQLite, Path is the
+// database file or ":memory:" and the connection settings are ignored.
+// AutoMigrate applies pending migrations on startup, which is required for
+// an in-memory database that cmd/migrate cannot reach. Otherwise the server
+// refuses to start while migrations are pending, unless
+// AllowPendingMigrations is set.
 type DatabaseConfig struct {
-	Host     string `mapstructure:"host"`
-	Port     int    `mapstructure:"port"`
-	User     string `mapstructure:"user"`
-	Password string `mapstructure:"password"`
-	DBName   string `mapstructure:"dbname"`
-	SSLMode  string `mapstructure:"sslmode"`
+	Driver                 string `mapstructure:"driver"`
+	Path                   string `mapstructure:"path"`
+	AutoMigrate            bool   `mapstructure:"auto_migrate"`
+	AllowPendingMigrations bool   `mapstructure:"allow_pending_migrations"`
+	Host                   string `mapstructure:"host"`
+	Port                   int    `mapstructure:"port"`
+	User                   string `mapstructure:"user"`
+	Password               string `mapstructure:"password"`
+	DBName                 string `mapstructure:"dbname"`
+	SSLMode                string `mapstructure:"sslmode"`
 }
 
-// TelegramConfig holds Telegram bot configuration
+// TelegramConfig holds Telegram bot configuration.
+// AdminIDs lists the Telegram user IDs that are given the admin role;
+// everyone else is a member.
+// UndoWindow is how long after logging a coffee /undo may still void it.
+// Mode is "polling" (default) or "webhook"; in webhook mode Telegram posts
+// updates to WebhookURL, which the ingress routes to WebhookPath on the
+// HTTP server, and every request must carry WebhookSecret.
+// StockAlertsInGroup also posts low-stock and empty box warnings, which
+// always go to the box owner, in the team's group chat.
 type TelegramConfig struct {
-	Token string `mapstructure:"token"`
-	Debug bool   `mapstructure:"debug"`
+	Token         string        `mapstructure:"token"`
+	Debug         bool          `mapstructure:"debug"`
+	AdminIDs      []int64       `mapstructure:"admin_ids"`
+	UndoWindow    time.Duration `mapstructure:"undo_window"`
+	Mode          string        `mapstructure:"mode"`
+	WebhookURL    string        `mapstructure:"webhook_url"`
+	WebhookPath   string        `mapstructure:"webhook_path"`
+	WebhookSecret string        `mapstructure:"webhook_secret"`
+
+	StockAlertsInGroup bool `mapstructure:"stock_alerts_in_group"`
+}
+
+// RemindersConfig holds the payment reminder schedule.
+// Schedule is a five-field cron expression evaluated in Timezone. Users are
+// reminded once they owe at least MinAmount minor units in a currency, and
+// QuietHours (e.g. "22:00-08:00") applies to users who did not set their own.
+type RemindersConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	Schedule   string `mapstructure:"schedule"`
+	Timezone   string `mapstructure:"timezone"`
+	MinAmount  int64  `mapstructure:"min_amount"`
+	QuietHours string `mapstructure:"quiet_hours"`
 }
 
 // Load loads configuration from environment variables and config files
@@ -48,9 +88,20 @@ func Load() (*Config, error) {
 	// Set default values
 	viper.SetDefault("server.host", "0.0.0.0")
 	viper.SetDefault("server.port", 8080)
+	viper.SetDefault("database.driver", "postgres")
+	viper.SetDefault("database.path", "coffee_cups.db")
+	viper.SetDefault("database.auto_migrate", false)
+	viper.SetDefault("database.allow_pending_migrations", false)
 	viper.SetDefault("database.host", "localhost")
 	viper.SetDefault("database.port", 5432)
 	viper.SetDefault("database.sslmode", "disable")
+	viper.SetDefault("telegram.undo_window", "10m")
+	viper.SetDefault("telegram.mode", "polling")
+	viper.SetDefault("telegram.webhook_path", "/telegram/webhook")
+	viper.SetDefault("reminders.enabled", false)
+	viper.SetDefault("reminders.schedule", "0 9 * * 1-5")
+	viper.SetDefault("reminders.timezone", "UTC")
+	viper.SetDefault("reminders.min_amount", 0)
 	viper.SetDefault("log_level", "info")
 
 	// Enable reading from environment variables
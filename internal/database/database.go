@@ -3,41 +3,99 @@ package database
 import (
 	"fmt"
 
+	"github.com/glebarez/sqlite"
 	"github.com/your-username/coffee-cups-system/internal/config"
-	"github.com/your-username/coffee-cups-system/internal/models"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
+// Supported database drivers, also used to select the migrations dialect
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
 // Database wraps the GORM database connection
 type Database struct {
 	*gorm.DB
+	dialect string
 }
 
 // New creates a new database connection
 func New(cfg config.DatabaseConfig) (*Database, error) {
-	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName, cfg.SSLMode)
+	driver := cfg.Driver
+	if driver == "" {
+		driver = DriverPostgres
+	}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+	dialector, err := openDialector(driver, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	// Auto-migrate the schema
-	if err := db.AutoMigrate(
-		&models.User{},
-		&models.Box{},
-		&models.CoffeeLog{},
-		&models.Payment{},
-	); err != nil {
-		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	if driver == DriverSQLite {
+		// SQLite has a single writer and no row locks, so serialize all
+		// access through one connection. This also keeps a :memory:
+		// database alive and shared for the lifetime of the process.
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure database: %w", err)
+		}
+		sqlDB.SetMaxOpenConns(1)
+	}
+
+	return &Database{DB: db, dialect: driver}, nil
+}
+
+// openDialector returns the GORM dialector for the configured driver
+func openDialector(driver string, cfg config.DatabaseConfig) (gorm.Dialector, error) {
+	switch driver {
+	case DriverPostgres:
+		dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+			cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName, cfg.SSLMode)
+		return postgres.Open(dsn), nil
+	case DriverSQLite:
+		path := cfg.Path
+		if path == "" {
+			path = ":memory:"
+		}
+		dsn := path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_txlock=immediate"
+		return sqlite.Open(dsn), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", driver)
+	}
+}
+
+// Migrator returns a Migrator for this database's dialect.
+// The schema is never changed implicitly; run cmd/migrate to apply migrations.
+func (d *Database) Migrator() (*Migrator, error) {
+	return NewMigrator(d.DB, d.dialect)
+}
+
+// EnsureSchema applies pending migrations when autoMigrate is set and
+// returns the number of migrations that are still pending
+func (d *Database) EnsureSchema(autoMigrate bool) (int, error) {
+	migrator, err := d.Migrator()
+	if err != nil {
+		return 0, err
+	}
+
+	if autoMigrate {
+		if _, err := migrator.Up(); err != nil {
+			return 0, err
+		}
 	}
 
-	return &Database{DB: db}, nil
+	pending, err := migrator.Pending()
+	return len(pending), err
 }
 
 // Close closes the database connection
@@ -0,0 +1,142 @@
+package database
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// migrationFiles holds the numbered SQL migrations for every dialect
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// MigrationsDir is the source directory of the embedded migrations
+const MigrationsDir = "internal/database/migrations"
+
+// loadMigrations reads and pairs the embedded up/down files of a dialect
+func loadMigrations(dialect string) ([]Migration, error) {
+	dir := path.Join("migrations", dialect)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for dialect %q: %w", dialect, err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		version, name, direction, err := parseMigrationFilename(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		content, err := fs.ReadFile(migrationFiles, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s must have both up and down files", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// parseMigrationFilename splits "0001_initial.up.sql" into its parts
+func parseMigrationFilename(filename string) (int64, string, string, error) {
+	base := strings.TrimSuffix(filename, ".sql")
+	direction := path.Ext(base)
+	base = strings.TrimSuffix(base, direction)
+	direction = strings.TrimPrefix(direction, ".")
+
+	versionStr, name, ok := strings.Cut(base, "_")
+	if !ok || (direction != "up" && direction != "down") {
+		return 0, "", "", fmt.Errorf("invalid migration filename %q", filename)
+	}
+
+	version, err := strconv.ParseInt(versionStr, 10, 64)
+	if err != nil {
+		return 0, "", "", fmt.Errorf("invalid migration version in %q", filename)
+	}
+	return version, name, direction, nil
+}
+
+// CreateMigration writes an empty up/down pair with the next version number
+// into every dialect directory under dir and returns the created paths
+func CreateMigration(dir, name string) ([]string, error) {
+	name = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "_")
+	if name == "" {
+		return nil, fmt.Errorf("migration name is required")
+	}
+
+	dialects, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	next, err := nextMigrationVersion(dir, dialects)
+	if err != nil {
+		return nil, err
+	}
+
+	var created []string
+	for _, dialect := range dialects {
+		if !dialect.IsDir() {
+			continue
+		}
+		for _, direction := range []string{"up", "down"} {
+			file := filepath.Join(dir, dialect.Name(), fmt.Sprintf("%04d_%s.%s.sql", next, name, direction))
+			if err := os.WriteFile(file, []byte("-- "+name+" ("+direction+")\n"), 0o644); err != nil {
+				return created, fmt.Errorf("failed to write %s: %w", file, err)
+			}
+			created = append(created, file)
+		}
+	}
+	return created, nil
+}
+
+// nextMigrationVersion returns one past the highest version in any dialect
+func nextMigrationVersion(dir string, dialects []os.DirEntry) (int64, error) {
+	var highest int64
+	for _, dialect := range dialects {
+		if !dialect.IsDir() {
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(dir, dialect.Name()))
+		if err != nil {
+			return 0, err
+		}
+		for _, file := range files {
+			version, _, _, err := parseMigrationFilename(file.Name())
+			if err != nil {
+				return 0, err
+			}
+			if version > highest {
+				highest = version
+			}
+		}
+	}
+	return highest + 1, nil
+}
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS payments;
+DROP TABLE IF EXISTS coffee_logs;
+DROP TABLE IF EXISTS boxes;
+DROP TABLE IF EXISTS users;
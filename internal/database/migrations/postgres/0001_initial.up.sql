@@ -0,0 +1,56 @@
+CREATE TABLE IF NOT EXISTS users (
+    id BIGSERIAL PRIMARY KEY,
+    telegram_id BIGINT NOT NULL,
+    username TEXT,
+    first_name TEXT,
+    last_name TEXT,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_telegram_id ON users (telegram_id);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS boxes (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    total_cups BIGINT NOT NULL,
+    price_minor_units BIGINT NOT NULL,
+    price_currency VARCHAR(3) NOT NULL,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_by BIGINT NOT NULL REFERENCES users (id),
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_boxes_deleted_at ON boxes (deleted_at);
+
+CREATE TABLE IF NOT EXISTS coffee_logs (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users (id),
+    box_id BIGINT NOT NULL REFERENCES boxes (id),
+    logged_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_coffee_logs_user_id ON coffee_logs (user_id);
+CREATE INDEX IF NOT EXISTS idx_coffee_logs_box_id ON coffee_logs (box_id);
+CREATE INDEX IF NOT EXISTS idx_coffee_logs_deleted_at ON coffee_logs (deleted_at);
+
+CREATE TABLE IF NOT EXISTS payments (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users (id),
+    box_id BIGINT NOT NULL REFERENCES boxes (id),
+    amount_minor_units BIGINT NOT NULL,
+    amount_currency VARCHAR(3) NOT NULL,
+    is_paid BOOLEAN DEFAULT FALSE,
+    paid_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_payments_user_id ON payments (user_id);
+CREATE INDEX IF NOT EXISTS idx_payments_box_id ON payments (box_id);
+CREATE INDEX IF NOT EXISTS idx_payments_deleted_at ON payments (deleted_at);
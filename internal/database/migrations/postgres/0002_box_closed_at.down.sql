@@ -0,0 +1 @@
+ALTER TABLE boxes DROP COLUMN closed_at;
@@ -0,0 +1,2 @@
+ALTER TABLE payments DROP COLUMN settlement_id;
+DROP TABLE settlements;
@@ -0,0 +1,13 @@
+CREATE TABLE settlements (
+    id BIGSERIAL PRIMARY KEY,
+    token TEXT NOT NULL,
+    accepted_by BIGINT NOT NULL REFERENCES users (id),
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX idx_settlements_token ON settlements (token);
+CREATE INDEX idx_settlements_deleted_at ON settlements (deleted_at);
+
+ALTER TABLE payments ADD COLUMN settlement_id BIGINT REFERENCES settlements (id);
+CREATE INDEX idx_payments_settlement_id ON payments (settlement_id);
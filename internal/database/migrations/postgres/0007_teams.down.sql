@@ -0,0 +1,2 @@
+ALTER TABLE boxes DROP COLUMN team_id;
+DROP TABLE teams;
@@ -0,0 +1,13 @@
+CREATE TABLE teams (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    chat_id BIGINT,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX idx_teams_chat_id ON teams (chat_id);
+CREATE INDEX idx_teams_deleted_at ON teams (deleted_at);
+
+ALTER TABLE boxes ADD COLUMN team_id BIGINT REFERENCES teams (id);
+CREATE INDEX idx_boxes_team_id ON boxes (team_id);
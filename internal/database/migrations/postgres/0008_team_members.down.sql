@@ -0,0 +1,2 @@
+ALTER TABLE settlements DROP COLUMN team_id;
+DROP TABLE team_members;
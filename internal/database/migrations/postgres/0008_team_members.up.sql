@@ -0,0 +1,39 @@
+CREATE TABLE team_members (
+    id BIGSERIAL PRIMARY KEY,
+    team_id BIGINT NOT NULL REFERENCES teams (id),
+    user_id BIGINT NOT NULL REFERENCES users (id),
+    created_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX idx_team_members_team_user ON team_members (team_id, user_id);
+CREATE INDEX idx_team_members_user_id ON team_members (user_id);
+
+ALTER TABLE settlements ADD COLUMN team_id BIGINT REFERENCES teams (id);
+CREATE INDEX idx_settlements_team_id ON settlements (team_id);
+
+-- Everything that predates teams moves into a "Default" team
+INSERT INTO teams (name, created_at, updated_at)
+SELECT 'Default', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+WHERE EXISTS (SELECT 1 FROM boxes WHERE team_id IS NULL);
+
+UPDATE boxes SET team_id = (SELECT MAX(id) FROM teams WHERE name = 'Default' AND chat_id IS NULL)
+WHERE team_id IS NULL;
+UPDATE settlements SET team_id = (SELECT MAX(id) FROM teams WHERE name = 'Default' AND chat_id IS NULL)
+WHERE team_id IS NULL;
+
+INSERT INTO team_members (team_id, user_id, created_at)
+SELECT teams.id, users.id, CURRENT_TIMESTAMP
+FROM teams CROSS JOIN users
+WHERE teams.id = (SELECT MAX(id) FROM teams WHERE name = 'Default' AND chat_id IS NULL);
+
+-- Box owners and consumers belong to the teams of their boxes
+INSERT INTO team_members (team_id, user_id, created_at)
+SELECT DISTINCT participants.team_id, participants.user_id, CURRENT_TIMESTAMP
+FROM (
+    SELECT team_id, created_by AS user_id FROM boxes
+    UNION
+    SELECT boxes.team_id, coffee_logs.user_id FROM coffee_logs JOIN boxes ON boxes.id = coffee_logs.box_id
+) AS participants
+WHERE NOT EXISTS (
+    SELECT 1 FROM team_members
+    WHERE team_members.team_id = participants.team_id AND team_members.user_id = participants.user_id
+);
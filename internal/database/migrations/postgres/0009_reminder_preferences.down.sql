@@ -0,0 +1,3 @@
+ALTER TABLE users DROP COLUMN quiet_hours_end;
+ALTER TABLE users DROP COLUMN quiet_hours_start;
+ALTER TABLE users DROP COLUMN reminders_enabled;
@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN reminders_enabled BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE users ADD COLUMN quiet_hours_start INTEGER;
+ALTER TABLE users ADD COLUMN quiet_hours_end INTEGER;
@@ -0,0 +1,2 @@
+DROP TABLE wallet_entries;
+ALTER TABLE teams DROP COLUMN billing_mode;
@@ -0,0 +1,16 @@
+ALTER TABLE teams ADD COLUMN billing_mode TEXT NOT NULL DEFAULT 'postpaid';
+
+CREATE TABLE wallet_entries (
+    id BIGSERIAL PRIMARY KEY,
+    team_id BIGINT NOT NULL REFERENCES teams (id),
+    user_id BIGINT NOT NULL REFERENCES users (id),
+    kind TEXT NOT NULL,
+    amount_minor_units BIGINT NOT NULL,
+    amount_currency VARCHAR(3) NOT NULL,
+    coffee_log_id BIGINT REFERENCES coffee_logs (id),
+    note TEXT,
+    created_by BIGINT NOT NULL REFERENCES users (id),
+    created_at TIMESTAMPTZ
+);
+CREATE INDEX idx_wallet_entries_team_user ON wallet_entries (team_id, user_id);
+CREATE INDEX idx_wallet_entries_coffee_log_id ON wallet_entries (coffee_log_id);
@@ -0,0 +1,31 @@
+CREATE TABLE ledger_accounts (
+    id BIGSERIAL PRIMARY KEY,
+    code TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    user_id BIGINT REFERENCES users (id),
+    team_id BIGINT REFERENCES teams (id),
+    box_id BIGINT REFERENCES boxes (id),
+    created_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX idx_ledger_accounts_code ON ledger_accounts (code);
+
+CREATE TABLE ledger_transactions (
+    id BIGSERIAL PRIMARY KEY,
+    kind TEXT NOT NULL,
+    box_id BIGINT REFERENCES boxes (id),
+    coffee_log_id BIGINT REFERENCES coffee_logs (id),
+    payment_id BIGINT REFERENCES payments (id),
+    wallet_entry_id BIGINT REFERENCES wallet_entries (id),
+    created_at TIMESTAMPTZ
+);
+CREATE INDEX idx_ledger_transactions_created_at ON ledger_transactions (created_at);
+
+CREATE TABLE ledger_postings (
+    id BIGSERIAL PRIMARY KEY,
+    transaction_id BIGINT NOT NULL REFERENCES ledger_transactions (id),
+    account_id BIGINT NOT NULL REFERENCES ledger_accounts (id),
+    amount_minor_units BIGINT NOT NULL,
+    amount_currency VARCHAR(3) NOT NULL
+);
+CREATE INDEX idx_ledger_postings_transaction_id ON ledger_postings (transaction_id);
+CREATE INDEX idx_ledger_postings_account_id ON ledger_postings (account_id);
@@ -0,0 +1,6 @@
+ALTER TABLE boxes ADD COLUMN status TEXT NOT NULL DEFAULT 'open';
+UPDATE boxes SET status = CASE
+    WHEN closed_at IS NOT NULL THEN 'settled'
+    WHEN is_active THEN 'open'
+    ELSE 'finished'
+END;
@@ -0,0 +1,8 @@
+CREATE TABLE bot_conversations (
+    chat_id BIGINT PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users (id),
+    command TEXT NOT NULL,
+    step TEXT NOT NULL,
+    data TEXT NOT NULL,
+    updated_at TIMESTAMPTZ
+);
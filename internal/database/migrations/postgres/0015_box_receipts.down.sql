@@ -0,0 +1 @@
+ALTER TABLE boxes DROP COLUMN receipt_file_id;
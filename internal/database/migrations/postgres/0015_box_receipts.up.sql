@@ -0,0 +1 @@
+ALTER TABLE boxes ADD COLUMN receipt_file_id TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,56 @@
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    telegram_id INTEGER NOT NULL,
+    username TEXT,
+    first_name TEXT,
+    last_name TEXT,
+    is_active NUMERIC DEFAULT TRUE,
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_telegram_id ON users (telegram_id);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS boxes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    total_cups INTEGER NOT NULL,
+    price_minor_units INTEGER NOT NULL,
+    price_currency TEXT NOT NULL,
+    is_active NUMERIC DEFAULT TRUE,
+    created_by INTEGER NOT NULL REFERENCES users (id),
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_boxes_deleted_at ON boxes (deleted_at);
+
+CREATE TABLE IF NOT EXISTS coffee_logs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users (id),
+    box_id INTEGER NOT NULL REFERENCES boxes (id),
+    logged_at DATETIME NOT NULL,
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_coffee_logs_user_id ON coffee_logs (user_id);
+CREATE INDEX IF NOT EXISTS idx_coffee_logs_box_id ON coffee_logs (box_id);
+CREATE INDEX IF NOT EXISTS idx_coffee_logs_deleted_at ON coffee_logs (deleted_at);
+
+CREATE TABLE IF NOT EXISTS payments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users (id),
+    box_id INTEGER NOT NULL REFERENCES boxes (id),
+    amount_minor_units INTEGER NOT NULL,
+    amount_currency TEXT NOT NULL,
+    is_paid NUMERIC DEFAULT FALSE,
+    paid_at DATETIME,
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_payments_user_id ON payments (user_id);
+CREATE INDEX IF NOT EXISTS idx_payments_box_id ON payments (box_id);
+CREATE INDEX IF NOT EXISTS idx_payments_deleted_at ON payments (deleted_at);
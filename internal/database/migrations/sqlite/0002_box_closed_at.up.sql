@@ -0,0 +1 @@
+ALTER TABLE boxes ADD COLUMN closed_at DATETIME;
@@ -0,0 +1,3 @@
+DROP INDEX idx_payments_settlement_id;
+ALTER TABLE payments DROP COLUMN settlement_id;
+DROP TABLE settlements;
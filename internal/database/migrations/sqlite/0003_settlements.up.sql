@@ -0,0 +1,13 @@
+CREATE TABLE settlements (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    token TEXT NOT NULL,
+    accepted_by INTEGER NOT NULL REFERENCES users (id),
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME
+);
+CREATE UNIQUE INDEX idx_settlements_token ON settlements (token);
+CREATE INDEX idx_settlements_deleted_at ON settlements (deleted_at);
+
+ALTER TABLE payments ADD COLUMN settlement_id INTEGER REFERENCES settlements (id);
+CREATE INDEX idx_payments_settlement_id ON payments (settlement_id);
@@ -0,0 +1 @@
+ALTER TABLE payments ADD COLUMN cancelled_at DATETIME;
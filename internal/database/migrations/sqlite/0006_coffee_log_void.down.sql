@@ -0,0 +1,3 @@
+ALTER TABLE coffee_logs DROP COLUMN void_reason;
+ALTER TABLE coffee_logs DROP COLUMN voided_by;
+ALTER TABLE coffee_logs DROP COLUMN voided_at;
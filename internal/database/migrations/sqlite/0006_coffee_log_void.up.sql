@@ -0,0 +1,3 @@
+ALTER TABLE coffee_logs ADD COLUMN voided_at DATETIME;
+ALTER TABLE coffee_logs ADD COLUMN voided_by INTEGER REFERENCES users (id);
+ALTER TABLE coffee_logs ADD COLUMN void_reason TEXT;
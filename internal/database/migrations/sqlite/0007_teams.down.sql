@@ -0,0 +1,3 @@
+DROP INDEX idx_boxes_team_id;
+ALTER TABLE boxes DROP COLUMN team_id;
+DROP TABLE teams;
@@ -0,0 +1,13 @@
+CREATE TABLE teams (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    chat_id INTEGER,
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME
+);
+CREATE UNIQUE INDEX idx_teams_chat_id ON teams (chat_id);
+CREATE INDEX idx_teams_deleted_at ON teams (deleted_at);
+
+ALTER TABLE boxes ADD COLUMN team_id INTEGER REFERENCES teams (id);
+CREATE INDEX idx_boxes_team_id ON boxes (team_id);
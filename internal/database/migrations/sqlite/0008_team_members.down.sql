@@ -0,0 +1,3 @@
+DROP INDEX idx_settlements_team_id;
+ALTER TABLE settlements DROP COLUMN team_id;
+DROP TABLE team_members;
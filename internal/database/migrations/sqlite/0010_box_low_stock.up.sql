@@ -0,0 +1 @@
+ALTER TABLE boxes ADD COLUMN low_stock_threshold INTEGER NOT NULL DEFAULT 3;
@@ -0,0 +1,4 @@
+DROP INDEX idx_wallet_entries_coffee_log_id;
+DROP INDEX idx_wallet_entries_team_user;
+DROP TABLE wallet_entries;
+ALTER TABLE teams DROP COLUMN billing_mode;
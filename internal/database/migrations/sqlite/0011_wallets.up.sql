@@ -0,0 +1,16 @@
+ALTER TABLE teams ADD COLUMN billing_mode TEXT NOT NULL DEFAULT 'postpaid';
+
+CREATE TABLE wallet_entries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    team_id INTEGER NOT NULL REFERENCES teams (id),
+    user_id INTEGER NOT NULL REFERENCES users (id),
+    kind TEXT NOT NULL,
+    amount_minor_units INTEGER NOT NULL,
+    amount_currency TEXT NOT NULL,
+    coffee_log_id INTEGER REFERENCES coffee_logs (id),
+    note TEXT,
+    created_by INTEGER NOT NULL REFERENCES users (id),
+    created_at DATETIME
+);
+CREATE INDEX idx_wallet_entries_team_user ON wallet_entries (team_id, user_id);
+CREATE INDEX idx_wallet_entries_coffee_log_id ON wallet_entries (coffee_log_id);
@@ -0,0 +1,7 @@
+DROP INDEX idx_ledger_postings_account_id;
+DROP INDEX idx_ledger_postings_transaction_id;
+DROP TABLE ledger_postings;
+DROP INDEX idx_ledger_transactions_created_at;
+DROP TABLE ledger_transactions;
+DROP INDEX idx_ledger_accounts_code;
+DROP TABLE ledger_accounts;
@@ -0,0 +1,31 @@
+CREATE TABLE ledger_accounts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    code TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    user_id INTEGER REFERENCES users (id),
+    team_id INTEGER REFERENCES teams (id),
+    box_id INTEGER REFERENCES boxes (id),
+    created_at DATETIME
+);
+CREATE UNIQUE INDEX idx_ledger_accounts_code ON ledger_accounts (code);
+
+CREATE TABLE ledger_transactions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL,
+    box_id INTEGER REFERENCES boxes (id),
+    coffee_log_id INTEGER REFERENCES coffee_logs (id),
+    payment_id INTEGER REFERENCES payments (id),
+    wallet_entry_id INTEGER REFERENCES wallet_entries (id),
+    created_at DATETIME
+);
+CREATE INDEX idx_ledger_transactions_created_at ON ledger_transactions (created_at);
+
+CREATE TABLE ledger_postings (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    transaction_id INTEGER NOT NULL REFERENCES ledger_transactions (id),
+    account_id INTEGER NOT NULL REFERENCES ledger_accounts (id),
+    amount_minor_units INTEGER NOT NULL,
+    amount_currency TEXT NOT NULL
+);
+CREATE INDEX idx_ledger_postings_transaction_id ON ledger_postings (transaction_id);
+CREATE INDEX idx_ledger_postings_account_id ON ledger_postings (account_id);
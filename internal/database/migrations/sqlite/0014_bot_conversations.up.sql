@@ -0,0 +1,8 @@
+CREATE TABLE bot_conversations (
+    chat_id INTEGER PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users (id),
+    command TEXT NOT NULL,
+    step TEXT NOT NULL,
+    data TEXT NOT NULL,
+    updated_at DATETIME
+);
@@ -0,0 +1 @@
+ALTER TABLE boxes DROP COLUMN low_stock_alerted;
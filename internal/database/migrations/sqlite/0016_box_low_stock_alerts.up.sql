@@ -0,0 +1 @@
+ALTER TABLE boxes ADD COLUMN low_stock_alerted BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,3 @@
+ALTER TABLE boxes ADD COLUMN billing_mode TEXT NOT NULL DEFAULT 'postpaid';
+UPDATE boxes SET billing_mode = (SELECT teams.billing_mode FROM teams WHERE teams.id = boxes.team_id)
+WHERE team_id IN (SELECT id FROM teams);
@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// GetUsageAnalytics handles GET /api/v1/teams/{team_id}/analytics/usage
+func (h *Handlers) GetUsageAnalytics(w http.ResponseWriter, r *http.Request) {
+	usage, err := h.services.Analytics.GetUsage(CurrentTeam(r).ID, r.URL.Query().Get("period"), time.Now())
+	if err != nil {
+		writeServiceError(w, err, "Failed to load usage analytics")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(usage)
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/your-username/coffee-cups-system/internal/models"
+)
+
+// contextKey is the type of request context keys set by this package
+type contextKey string
+
+// userContextKey holds the authenticated user in the request context
+const userContextKey contextKey = "user"
+
+// Authenticate is middleware that requires a valid bearer token and stores
+// the token's user in the request context
+func (h *Handlers) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || token == "" {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, CodeUnauthorized, "Missing bearer token")
+			return
+		}
+
+		user, err := h.services.Auth.Authenticate(token)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			writeError(w, http.StatusUnauthorized, CodeUnauthorized, "Invalid token")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(ContextWithUser(r.Context(), user)))
+	})
+}
+
+// RequireAdmin wraps a handler so that only admins can call it
+func (h *Handlers) RequireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := CurrentUser(r)
+		if user == nil || !user.IsAdmin() {
+			writeError(w, http.StatusForbidden, CodeForbidden, "Admin role required")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// ContextWithUser returns a copy of ctx carrying the authenticated user
+func ContextWithUser(ctx context.Context, user *models.User) context.Context {
+	return context.WithValue(ctx, userContextKey, user)
+}
+
+// CurrentUser returns the authenticated user of the request, if any
+func CurrentUser(r *http.Request) *models.User {
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	return user
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// GetUserBalance handles GET /api/v1/users/{id}/balance.
+// Like GET /users/{id} it takes a Telegram ID; users only see their own
+// balance unless they are an admin.
+func (h *Handlers) GetUserBalance(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "Invalid user ID")
+		return
+	}
+
+	user, err := h.services.User.GetUserByTelegramID(id)
+	if err != nil {
+		writeServiceError(w, err, "Failed to get user")
+		return
+	}
+	if current := CurrentUser(r); current.ID != user.ID && !current.IsAdmin() {
+		writeError(w, http.StatusForbidden, CodeForbidden, "You can only view your own balance")
+		return
+	}
+
+	balance, err := h.services.Balance.GetUserBalance(user.ID)
+	if err != nil {
+		writeServiceError(w, err, "Failed to get balance")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(balance)
+}
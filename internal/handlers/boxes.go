@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/your-username/coffee-cups-system/internal/models"
+)
+
+// loadOwnedBox loads the box in the request path for its purchaser or an
+// admin, writing an error if it fails or the user may not manage the box
+func (h *Handlers) loadOwnedBox(w http.ResponseWriter, r *http.Request) (*models.Box, bool) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "Invalid box ID")
+		return nil, false
+	}
+
+	user := CurrentUser(r)
+	box, ok := h.loadBox(w, user, uint(id))
+	if !ok {
+		return nil, false
+	}
+	if box.CreatedBy != user.ID && !user.IsAdmin() {
+		writeError(w, http.StatusForbidden, CodeForbidden, "Only the box owner can manage it")
+		return nil, false
+	}
+	return box, true
+}
+
+// UpdateBox handles PATCH /api/v1/boxes/{id}
+func (h *Handlers) UpdateBox(w http.ResponseWriter, r *http.Request) {
+	box, ok := h.loadOwnedBox(w, r)
+	if !ok {
+		return
+	}
+
+	var req updateBoxRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	box, err := h.services.Box.UpdateBox(box.ID, req.changes())
+	if err != nil {
+		writeServiceError(w, err, "Failed to update box")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(box)
+}
+
+// DeleteBox handles DELETE /api/v1/boxes/{id}
+func (h *Handlers) DeleteBox(w http.ResponseWriter, r *http.Request) {
+	box, ok := h.loadOwnedBox(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.services.Box.DeleteBox(box.ID); err != nil {
+		writeServiceError(w, err, "Failed to delete box")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// FinishBox handles POST /api/v1/boxes/{id}/finish
+func (h *Handlers) FinishBox(w http.ResponseWriter, r *http.Request) {
+	h.writeBoxTransition(w, r, h.services.Box.FinishBox)
+}
+
+// ReopenBox handles POST /api/v1/boxes/{id}/reopen
+func (h *Handlers) ReopenBox(w http.ResponseWriter, r *http.Request) {
+	h.writeBoxTransition(w, r, h.services.Box.ReopenBox)
+}
+
+// ArchiveBox handles POST /api/v1/boxes/{id}/archive
+func (h *Handlers) ArchiveBox(w http.ResponseWriter, r *http.Request) {
+	h.writeBoxTransition(w, r, h.services.Box.ArchiveBox)
+}
+
+// writeBoxTransition moves the owned box in the request path to another
+// status and writes the updated box
+func (h *Handlers) writeBoxTransition(w http.ResponseWriter, r *http.Request, transition func(uint) (*models.Box, error)) {
+	box, ok := h.loadOwnedBox(w, r)
+	if !ok {
+		return
+	}
+
+	box, err := transition(box.ID)
+	if err != nil {
+		writeServiceError(w, err, "Failed to change box status")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(box)
+}
+
+// CloseBox handles POST /api/v1/boxes/{id}/close, settling the box
+func (h *Handlers) CloseBox(w http.ResponseWriter, r *http.Request) {
+	box, ok := h.loadOwnedBox(w, r)
+	if !ok {
+		return
+	}
+
+	settlement, err := h.services.Box.CloseBox(box.ID)
+	if err != nil {
+		writeServiceError(w, err, "Failed to close box")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settlement)
+}
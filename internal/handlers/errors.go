@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/your-username/coffee-cups-system/internal/services"
+)
+
+// Error codes for failures detected by the handlers themselves.
+// Failures reported by services carry their own codes, such as BOX_EXHAUSTED.
+const (
+	CodeInvalidRequest = "INVALID_REQUEST"
+	CodeUnauthorized   = "UNAUTHORIZED"
+	CodeForbidden      = "FORBIDDEN"
+	CodeInternal       = "INTERNAL_ERROR"
+)
+
+// ErrorResponse is the body of every error response.
+// Details lists the rejected fields of an invalid request.
+type ErrorResponse struct {
+	Error   string                `json:"error"`
+	Message string                `json:"message"`
+	Details []services.FieldError `json:"details,omitempty"`
+}
+
+// kindStatus maps the kinds of service errors to HTTP statuses
+var kindStatus = map[services.ErrorKind]int{
+	services.KindNotFound:          http.StatusNotFound,
+	services.KindConflict:          http.StatusConflict,
+	services.KindValidation:        http.StatusUnprocessableEntity,
+	services.KindForbidden:         http.StatusForbidden,
+	services.KindUnauthenticated:   http.StatusUnauthorized,
+	services.KindInsufficientFunds: http.StatusPaymentRequired,
+}
+
+// writeError writes an error response with a machine-readable code
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeErrorResponse(w, status, ErrorResponse{Error: code, Message: message})
+}
+
+// writeErrorResponse writes an error body with the given status
+func writeErrorResponse(w http.ResponseWriter, status int, body ErrorResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeServiceError writes the response for an error returned by a service.
+// Domain errors keep their code and message; anything else is reported as
+// an internal error with the fallback message so no internals leak.
+func writeServiceError(w http.ResponseWriter, err error, fallback string) {
+	var domainErr *services.Error
+	if errors.As(err, &domainErr) {
+		status, ok := kindStatus[domainErr.Kind]
+		if !ok {
+			status = http.StatusInternalServerError
+		}
+		writeErrorResponse(w, status, ErrorResponse{
+			Error:   domainErr.Code,
+			Message: domainErr.Message,
+			Details: domainErr.Fields,
+		})
+		return
+	}
+	writeError(w, http.StatusInternalServerError, CodeInternal, fallback)
+}
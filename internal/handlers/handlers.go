@@ -24,30 +24,40 @@ func New(services *services.Services, logger interface{}) *Handlers {
 	}
 }
 
-// GetUsers handles GET /api/v1/users
+// GetUsers handles GET /api/v1/users, listing the caller's teammates
 func (h *Handlers) GetUsers(w http.ResponseWriter, r *http.Request) {
-	users, err := h.services.User.GetAllActiveUsers()
+	query := newListQuery(r)
+	filter := services.UserFilter{Active: query.flag("active"), Page: query.page()}
+	if query.err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, query.err.Error())
+		return
+	}
+
+	users, next, err := h.services.User.ListTeammates(CurrentUser(r).ID, filter)
 	if err != nil {
-		http.Error(w, "Failed to get users", http.StatusInternalServerError)
+		writeServiceError(w, err, "Failed to get users")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(users)
+	writeList(w, r, users, next)
 }
 
-// GetUser handles GET /api/v1/users/{id}
+// GetUser handles GET /api/v1/users/{id}. Users outside the caller's teams
+// are reported as not found.
 func (h *Handlers) GetUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.ParseUint(vars["id"], 10, 32)
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "Invalid user ID")
 		return
 	}
 
 	user, err := h.services.User.GetUserByTelegramID(int64(id))
+	if err == nil && !h.canSeeUser(CurrentUser(r), user) {
+		err = services.ErrUserNotFound
+	}
 	if err != nil {
-		http.Error(w, "User not found", http.StatusNotFound)
+		writeServiceError(w, err, "Failed to get user")
 		return
 	}
 
@@ -55,35 +65,61 @@ func (h *Handlers) GetUser(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(user)
 }
 
-// GetBoxes handles GET /api/v1/boxes
+// canSeeUser reports whether the user may see another user: themselves,
+// their teammates, or anyone if they are an admin
+func (h *Handlers) canSeeUser(user, other *models.User) bool {
+	if user == nil {
+		return false
+	}
+	if user.ID == other.ID || user.IsAdmin() {
+		return true
+	}
+	shared, err := h.services.User.SharesTeam(user.ID, other.ID)
+	return err == nil && shared
+}
+
+// GetBoxes handles GET /api/v1/teams/{team_id}/boxes
 func (h *Handlers) GetBoxes(w http.ResponseWriter, r *http.Request) {
-	boxes, err := h.services.Box.GetActiveBoxes()
+	query := newListQuery(r)
+	filter := services.BoxFilter{
+		TeamID:    CurrentTeam(r).ID,
+		CreatedBy: query.id("created_by"),
+		Active:    query.flag("active"),
+		From:      query.date("from"),
+		To:        query.date("to"),
+		Page:      query.page(),
+	}
+	if query.err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, query.err.Error())
+		return
+	}
+
+	boxes, next, err := h.services.Box.ListBoxes(filter)
 	if err != nil {
-		http.Error(w, "Failed to get boxes", http.StatusInternalServerError)
+		writeServiceError(w, err, "Failed to get boxes")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(boxes)
+	writeList(w, r, boxes, next)
 }
 
-// CreateBox handles POST /api/v1/boxes
+// CreateBox handles POST /api/v1/teams/{team_id}/boxes
 func (h *Handlers) CreateBox(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		Name      string  `json:"name"`
-		TotalCups int     `json:"total_cups"`
-		Price     float64 `json:"price"`
-		CreatedBy uint    `json:"created_by"`
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	var req createBoxRequest
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
-	box, err := h.services.Box.CreateBox(req.Name, req.TotalCups, req.Price, req.CreatedBy)
+	box, err := h.services.Box.AddBox(services.NewBox{
+		Name:              req.Name,
+		TotalCups:         req.TotalCups,
+		Price:             req.Price,
+		CreatedBy:         CurrentUser(r).ID,
+		TeamID:            CurrentTeam(r).ID,
+		LowStockThreshold: req.LowStockThreshold,
+	})
 	if err != nil {
-		http.Error(w, "Failed to create box", http.StatusInternalServerError)
+		writeServiceError(w, err, "Failed to create box")
 		return
 	}
 
@@ -97,13 +133,12 @@ func (h *Handlers) GetBox(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.ParseUint(vars["id"], 10, 32)
 	if err != nil {
-		http.Error(w, "Invalid box ID", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "Invalid box ID")
 		return
 	}
 
-	box, err := h.services.Box.GetBoxByID(uint(id))
-	if err != nil {
-		http.Error(w, "Box not found", http.StatusNotFound)
+	box, ok := h.loadBox(w, CurrentUser(r), uint(id))
+	if !ok {
 		return
 	}
 
@@ -111,45 +146,58 @@ func (h *Handlers) GetBox(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(box)
 }
 
-// GetCoffeeLogs handles GET /api/v1/coffee-logs
-func (h *Handlers) GetCoffeeLogs(w http.ResponseWriter, r *http.Request) {
-	userIDStr := r.URL.Query().Get("user_id")
-	if userIDStr == "" {
-		http.Error(w, "user_id parameter is required", http.StatusBadRequest)
-		return
+// loadBox loads a box the user may see, writing an error if it fails.
+// Boxes of other teams are reported as not found.
+func (h *Handlers) loadBox(w http.ResponseWriter, user *models.User, id uint) (*models.Box, bool) {
+	box, err := h.services.Box.GetBoxByID(id)
+	if err == nil && !h.canAccessTeam(user, box.TeamID) {
+		err = services.ErrBoxNotFound
 	}
-
-	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		writeServiceError(w, err, "Failed to get box")
+		return nil, false
+	}
+	return box, true
+}
+
+// GetCoffeeLogs handles GET /api/v1/teams/{team_id}/coffee-logs
+func (h *Handlers) GetCoffeeLogs(w http.ResponseWriter, r *http.Request) {
+	query := newListQuery(r)
+	filter := services.CoffeeLogFilter{
+		TeamID: CurrentTeam(r).ID,
+		UserID: query.id("user_id"),
+		BoxID:  query.id("box_id"),
+		From:   query.date("from"),
+		To:     query.date("to"),
+		Page:   query.page(),
+	}
+	if query.err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, query.err.Error())
 		return
 	}
 
-	logs, err := h.services.Coffee.GetUserCoffeeLogs(uint(userID), 0)
+	logs, next, err := h.services.Coffee.ListCoffeeLogs(filter)
 	if err != nil {
-		http.Error(w, "Failed to get coffee logs", http.StatusInternalServerError)
+		writeServiceError(w, err, "Failed to get coffee logs")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(logs)
+	writeList(w, r, logs, next)
 }
 
 // LogCoffee handles POST /api/v1/coffee-logs
 func (h *Handlers) LogCoffee(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		UserID uint `json:"user_id"`
-		BoxID  uint `json:"box_id"`
+	var req logCoffeeRequest
+	if !decodeRequest(w, r, &req) {
+		return
 	}
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if _, ok := h.loadBox(w, CurrentUser(r), req.BoxID); !ok {
 		return
 	}
 
-	log, err := h.services.Coffee.LogCoffee(req.UserID, req.BoxID)
+	log, err := h.services.Coffee.LogCoffee(CurrentUser(r).ID, req.BoxID)
 	if err != nil {
-		http.Error(w, "Failed to log coffee", http.StatusInternalServerError)
+		writeServiceError(w, err, "Failed to log coffee")
 		return
 	}
 
@@ -158,38 +206,25 @@ func (h *Handlers) LogCoffee(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(log)
 }
 
-// GetPayments handles GET /api/v1/payments
-func (h *Handlers) GetPayments(w http.ResponseWriter, r *http.Request) {
-	userIDStr := r.URL.Query().Get("user_id")
-	boxIDStr := r.URL.Query().Get("box_id")
-
-	var payments []models.Payment
-	var err error
-
-	if userIDStr != "" {
-		userID, parseErr := strconv.ParseUint(userIDStr, 10, 32)
-		if parseErr != nil {
-			http.Error(w, "Invalid user ID", http.StatusBadRequest)
-			return
-		}
-		payments, err = h.services.Payment.GetUserPayments(uint(userID))
-	} else if boxIDStr != "" {
-		boxID, parseErr := strconv.ParseUint(boxIDStr, 10, 32)
-		if parseErr != nil {
-			http.Error(w, "Invalid box ID", http.StatusBadRequest)
-			return
-		}
-		payments, err = h.services.Payment.GetBoxPayments(uint(boxID))
-	} else {
-		http.Error(w, "user_id or box_id parameter is required", http.StatusBadRequest)
+// VoidCoffeeLog handles POST /api/v1/coffee-logs/{id}/void
+func (h *Handlers) VoidCoffeeLog(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "Invalid coffee log ID")
 		return
 	}
 
+	var req voidCoffeeLogRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	log, err := h.services.Coffee.VoidCoffeeLog(uint(id), CurrentUser(r).ID, req.Reason)
 	if err != nil {
-		http.Error(w, "Failed to get payments", http.StatusInternalServerError)
+		writeServiceError(w, err, "Failed to void coffee log")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(payments)
+	json.NewEncoder(w).Encode(log)
 }
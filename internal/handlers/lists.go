@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/your-username/coffee-cups-system/internal/services"
+)
+
+// listQuery reads the filter, sort and page parameters of a list request.
+// It keeps the first parameter that fails to parse in err, so a handler
+// reads every parameter and checks err once.
+type listQuery struct {
+	values url.Values
+	err    error
+}
+
+// newListQuery reads the query string of r
+func newListQuery(r *http.Request) *listQuery {
+	return &listQuery{values: r.URL.Query()}
+}
+
+// fail records a parameter that failed to parse
+func (q *listQuery) fail(message string) {
+	if q.err == nil {
+		q.err = errors.New(message)
+	}
+}
+
+// id reads an optional record ID, zero when absent
+func (q *listQuery) id(name string) uint {
+	value := q.values.Get(name)
+	if value == "" {
+		return 0
+	}
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		q.fail("invalid " + name)
+	}
+	return uint(id)
+}
+
+// date reads an optional date, nil when absent
+func (q *listQuery) date(name string) *time.Time {
+	value := q.values.Get(name)
+	if value == "" {
+		return nil
+	}
+	t, err := parseDate(value)
+	if err != nil {
+		q.fail("invalid " + name + ", use YYYY-MM-DD or RFC 3339")
+		return nil
+	}
+	return &t
+}
+
+// flag reads an optional boolean, nil when absent
+func (q *listQuery) flag(name string) *bool {
+	value := q.values.Get(name)
+	if value == "" {
+		return nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		q.fail("invalid " + name + ", use true or false")
+		return nil
+	}
+	return &b
+}
+
+// page reads the cursor, limit and sort parameters
+func (q *listQuery) page() services.Page {
+	page := services.Page{Cursor: q.values.Get("cursor"), Sort: q.values.Get("sort")}
+	if value := q.values.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			q.fail("invalid limit")
+		}
+		page.Limit = limit
+	}
+	return page
+}
+
+// parseDate accepts either a calendar date or an RFC 3339 timestamp
+func parseDate(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// writeList writes one page of a list as a JSON array. When there is a next
+// page, its cursor is sent in X-Next-Cursor and as a Link header pointing at
+// the same request with the cursor set.
+func writeList(w http.ResponseWriter, r *http.Request, items interface{}, next string) {
+	if next != "" {
+		nextURL := *r.URL
+		query := nextURL.Query()
+		query.Set("cursor", next)
+		nextURL.RawQuery = query.Encode()
+		w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"next\"", nextURL.RequestURI()))
+		w.Header().Set("X-Next-Cursor", next)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
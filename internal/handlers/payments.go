@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"github.com/your-username/coffee-cups-system/internal/services"
+)
+
+// GetPayments handles GET /api/v1/teams/{team_id}/payments
+func (h *Handlers) GetPayments(w http.ResponseWriter, r *http.Request) {
+	query := newListQuery(r)
+	filter := services.PaymentFilter{
+		TeamID: CurrentTeam(r).ID,
+		UserID: query.id("user_id"),
+		BoxID:  query.id("box_id"),
+		Status: query.values.Get("status"),
+		From:   query.date("from"),
+		To:     query.date("to"),
+		Page:   query.page(),
+	}
+	if query.err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, query.err.Error())
+		return
+	}
+
+	payments, next, err := h.services.Payment.ListPayments(filter)
+	if err != nil {
+		writeServiceError(w, err, "Failed to get payments")
+		return
+	}
+
+	writeList(w, r, payments, next)
+}
+
+// CreatePayment handles POST /api/v1/payments
+func (h *Handlers) CreatePayment(w http.ResponseWriter, r *http.Request) {
+	var req createPaymentRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	user := CurrentUser(r)
+	box, ok := h.loadBox(w, user, req.BoxID)
+	if !ok {
+		return
+	}
+
+	// Payments are owed to the box purchaser, so only they or an admin create them
+	if box.CreatedBy != user.ID && !user.IsAdmin() {
+		writeError(w, http.StatusForbidden, CodeForbidden, "Only the box owner can create payments for it")
+		return
+	}
+
+	payment, err := h.services.Payment.CreatePayment(req.UserID, box.ID, req.Amount)
+	if err != nil {
+		writeServiceError(w, err, "Failed to create payment")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(payment)
+}
+
+// GetPayment handles GET /api/v1/payments/{id}
+func (h *Handlers) GetPayment(w http.ResponseWriter, r *http.Request) {
+	payment, ok := h.loadPayment(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(payment)
+}
+
+// MarkPaymentAsPaid handles POST /api/v1/payments/{id}/pay
+func (h *Handlers) MarkPaymentAsPaid(w http.ResponseWriter, r *http.Request) {
+	payment, ok := h.loadPayment(w, r)
+	if !ok {
+		return
+	}
+
+	// The payer, the payee or an admin can record the payment
+	user := CurrentUser(r)
+	if payment.UserID != user.ID && payment.Box.CreatedBy != user.ID && !user.IsAdmin() {
+		writeError(w, http.StatusForbidden, CodeForbidden, "Not allowed to mark this payment as paid")
+		return
+	}
+
+	h.writePaymentTransition(w, payment.ID, h.services.Payment.MarkPaymentAsPaid)
+}
+
+// CancelPayment handles POST /api/v1/payments/{id}/cancel
+func (h *Handlers) CancelPayment(w http.ResponseWriter, r *http.Request) {
+	payment, ok := h.loadPayment(w, r)
+	if !ok {
+		return
+	}
+
+	// Only the payee or an admin can forgive a debt
+	user := CurrentUser(r)
+	if payment.Box.CreatedBy != user.ID && !user.IsAdmin() {
+		writeError(w, http.StatusForbidden, CodeForbidden, "Only the box owner can cancel this payment")
+		return
+	}
+
+	h.writePaymentTransition(w, payment.ID, h.services.Payment.CancelPayment)
+}
+
+// loadPayment loads the payment named in the path, writing an error if it fails
+func (h *Handlers) loadPayment(w http.ResponseWriter, r *http.Request) (*models.Payment, bool) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "Invalid payment ID")
+		return nil, false
+	}
+
+	payment, err := h.services.Payment.GetPaymentByID(uint(id))
+	if err == nil && !h.canAccessTeam(CurrentUser(r), payment.Box.TeamID) {
+		// Payments of other teams are hidden rather than forbidden
+		err = services.ErrPaymentNotFound
+	}
+	if err != nil {
+		writeServiceError(w, err, "Failed to get payment")
+		return nil, false
+	}
+	return payment, true
+}
+
+// writePaymentTransition applies a status change and writes the updated payment
+func (h *Handlers) writePaymentTransition(w http.ResponseWriter, id uint, transition func(uint) (*models.Payment, error)) {
+	payment, err := transition(id)
+	if err != nil {
+		writeServiceError(w, err, "Failed to update payment")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(payment)
+}
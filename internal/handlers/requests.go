@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"github.com/your-username/coffee-cups-system/internal/services"
+)
+
+// request is a JSON request body that declares the rules for its fields.
+// The rules are the ones the services apply, so a body that passes here
+// is not rejected by the service for the same field.
+type request interface {
+	Rules() []services.Rule
+}
+
+// decodeRequest reads a JSON body into req and validates it, writing a 400
+// for a malformed body or a 422 listing every invalid field
+func decodeRequest(w http.ResponseWriter, r *http.Request, req request) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "Invalid request body")
+		return false
+	}
+	if err := services.Validate(req.Rules()...); err != nil {
+		writeServiceError(w, err, "")
+		return false
+	}
+	return true
+}
+
+// createTeamRequest is the body of POST /teams
+type createTeamRequest struct {
+	Name string `json:"name"`
+}
+
+// Rules declares the rules for a new team
+func (req *createTeamRequest) Rules() []services.Rule {
+	return services.TeamRules(req.Name)
+}
+
+// addTeamMemberRequest is the body of POST /teams/{team_id}/members
+type addTeamMemberRequest struct {
+	UserID uint `json:"user_id"`
+}
+
+// Rules declares the rules for adding a member
+func (req *addTeamMemberRequest) Rules() []services.Rule {
+	return []services.Rule{services.ID("user_id", req.UserID)}
+}
+
+// createBoxRequest is the body of POST /teams/{team_id}/boxes
+type createBoxRequest struct {
+	Name              string       `json:"name"`
+	TotalCups         int          `json:"total_cups"`
+	Price             models.Money `json:"price"`
+	LowStockThreshold *int         `json:"low_stock_threshold"`
+}
+
+// Rules declares the rules for a new box
+func (req *createBoxRequest) Rules() []services.Rule {
+	rules := services.BoxRules(req.Name, req.TotalCups, req.Price)
+	if req.LowStockThreshold != nil {
+		rules = append(rules, services.LowStockRule(*req.LowStockThreshold, req.TotalCups))
+	}
+	return rules
+}
+
+// updateBoxRequest is the body of PATCH /boxes/{id}; absent fields stay unchanged
+type updateBoxRequest struct {
+	Name              *string       `json:"name"`
+	TotalCups         *int          `json:"total_cups"`
+	Price             *models.Money `json:"price"`
+	LowStockThreshold *int          `json:"low_stock_threshold"`
+}
+
+// changes returns the corrections the request asks for
+func (req *updateBoxRequest) changes() services.BoxChanges {
+	return services.BoxChanges{
+		Name:              req.Name,
+		TotalCups:         req.TotalCups,
+		Price:             req.Price,
+		LowStockThreshold: req.LowStockThreshold,
+	}
+}
+
+// Rules declares the rules for correcting a box
+func (req *updateBoxRequest) Rules() []services.Rule {
+	return req.changes().Rules()
+}
+
+// logCoffeeRequest is the body of POST /coffee-logs
+type logCoffeeRequest struct {
+	BoxID uint `json:"box_id"`
+}
+
+// Rules declares the rules for logging a coffee
+func (req *logCoffeeRequest) Rules() []services.Rule {
+	return []services.Rule{services.ID("box_id", req.BoxID)}
+}
+
+// voidCoffeeLogRequest is the body of POST /coffee-logs/{id}/void
+type voidCoffeeLogRequest struct {
+	Reason string `json:"reason"`
+}
+
+// Rules declares the rules for voiding a coffee log
+func (req *voidCoffeeLogRequest) Rules() []services.Rule {
+	return services.VoidRules(req.Reason)
+}
+
+// createPaymentRequest is the body of POST /payments
+type createPaymentRequest struct {
+	UserID uint         `json:"user_id"`
+	BoxID  uint         `json:"box_id"`
+	Amount models.Money `json:"amount"`
+}
+
+// Rules declares the rules for a new payment
+func (req *createPaymentRequest) Rules() []services.Rule {
+	return services.PaymentRules(req.UserID, req.BoxID, req.Amount)
+}
+
+// acceptSettlementRequest is the body of POST /teams/{team_id}/settlements/plan/accept
+type acceptSettlementRequest struct {
+	Token string `json:"token"`
+}
+
+// Rules declares the rules for accepting a settlement plan
+func (req *acceptSettlementRequest) Rules() []services.Rule {
+	return []services.Rule{services.Required("token", req.Token)}
+}
+
+// setBillingModeRequest is the body of PUT /teams/{team_id}/billing-mode
+type setBillingModeRequest struct {
+	BillingMode string `json:"billing_mode"`
+}
+
+// Rules declares the rules for changing the billing mode
+func (req *setBillingModeRequest) Rules() []services.Rule {
+	return []services.Rule{services.OneOf("billing_mode", req.BillingMode, models.BillingPostpaid, models.BillingPrepaid)}
+}
+
+// topUpRequest is the body of POST /teams/{team_id}/wallet/top-ups
+type topUpRequest struct {
+	UserID uint         `json:"user_id"`
+	Amount models.Money `json:"amount"`
+	Note   string       `json:"note"`
+}
+
+// Rules declares the rules for a wallet top-up
+func (req *topUpRequest) Rules() []services.Rule {
+	return []services.Rule{
+		services.ID("user_id", req.UserID),
+		services.PositiveAmount("amount", req.Amount),
+		services.MaxLength("note", req.Note, services.MaxNoteLength),
+	}
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// GetSettlementPlan handles GET /api/v1/teams/{team_id}/settlements/plan
+func (h *Handlers) GetSettlementPlan(w http.ResponseWriter, r *http.Request) {
+	plan, err := h.services.Settlement.GetPlan(CurrentTeam(r).ID)
+	if err != nil {
+		writeServiceError(w, err, "Failed to build settlement plan")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(plan)
+}
+
+// AcceptSettlementPlan handles POST /api/v1/teams/{team_id}/settlements/plan/accept
+func (h *Handlers) AcceptSettlementPlan(w http.ResponseWriter, r *http.Request) {
+	var req acceptSettlementRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	settlement, err := h.services.Settlement.AcceptPlan(CurrentTeam(r).ID, req.Token, CurrentUser(r).ID)
+	if err != nil {
+		writeServiceError(w, err, "Failed to accept settlement plan")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(settlement)
+}
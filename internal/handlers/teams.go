@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"github.com/your-username/coffee-cups-system/internal/services"
+)
+
+// teamContextKey holds the team of a team-scoped route in the request context
+const teamContextKey contextKey = "team"
+
+// RequireTeamMember is middleware that loads the team named by {team_id} and
+// only lets its members and admins through
+func (h *Handlers) RequireTeamMember(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseUint(mux.Vars(r)["team_id"], 10, 32)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, CodeInvalidRequest, "Invalid team ID")
+			return
+		}
+
+		team, err := h.services.Team.GetTeamByID(uint(id))
+		if err != nil {
+			writeServiceError(w, err, "Failed to get team")
+			return
+		}
+
+		if !h.canAccessTeam(CurrentUser(r), team.ID) {
+			writeServiceError(w, services.ErrNotTeamMember, "")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(ContextWithTeam(r.Context(), team)))
+	})
+}
+
+// canAccessTeam reports whether the user may see the team's data
+func (h *Handlers) canAccessTeam(user *models.User, teamID uint) bool {
+	if user == nil {
+		return false
+	}
+	if user.IsAdmin() {
+		return true
+	}
+	member, err := h.services.Team.IsMember(teamID, user.ID)
+	return err == nil && member
+}
+
+// ContextWithTeam returns a copy of ctx carrying the team of a team-scoped route
+func ContextWithTeam(ctx context.Context, team *models.Team) context.Context {
+	return context.WithValue(ctx, teamContextKey, team)
+}
+
+// CurrentTeam returns the team of a team-scoped request, if any
+func CurrentTeam(r *http.Request) *models.Team {
+	team, _ := r.Context().Value(teamContextKey).(*models.Team)
+	return team
+}
+
+// GetTeams handles GET /api/v1/teams
+func (h *Handlers) GetTeams(w http.ResponseWriter, r *http.Request) {
+	user := CurrentUser(r)
+	var teams []models.Team
+	var err error
+	if user.IsAdmin() {
+		teams, err = h.services.Team.GetAllTeams()
+	} else {
+		teams, err = h.services.Team.GetUserTeams(user.ID)
+	}
+	if err != nil {
+		writeServiceError(w, err, "Failed to get teams")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(teams)
+}
+
+// CreateTeam handles POST /api/v1/teams
+func (h *Handlers) CreateTeam(w http.ResponseWriter, r *http.Request) {
+	var req createTeamRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	team, err := h.services.Team.CreateTeam(req.Name)
+	if err != nil {
+		writeServiceError(w, err, "Failed to create team")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(team)
+}
+
+// GetTeamMembers handles GET /api/v1/teams/{team_id}/members
+func (h *Handlers) GetTeamMembers(w http.ResponseWriter, r *http.Request) {
+	users, err := h.services.Team.GetMembers(CurrentTeam(r).ID)
+	if err != nil {
+		writeServiceError(w, err, "Failed to get team members")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(users)
+}
+
+// AddTeamMember handles POST /api/v1/teams/{team_id}/members
+func (h *Handlers) AddTeamMember(w http.ResponseWriter, r *http.Request) {
+	var req addTeamMemberRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	if _, err := h.services.User.GetUserByID(req.UserID); err != nil {
+		writeServiceError(w, err, "Failed to get user")
+		return
+	}
+
+	if err := h.services.Team.AddMember(CurrentTeam(r).ID, req.UserID); err != nil {
+		writeServiceError(w, err, "Failed to add team member")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/your-username/coffee-cups-system/internal/models"
+)
+
+// walletHistoryLimit is how many wallet entries GetWallet returns
+const walletHistoryLimit = 50
+
+// SetTeamBillingMode handles PUT /api/v1/teams/{team_id}/billing-mode
+func (h *Handlers) SetTeamBillingMode(w http.ResponseWriter, r *http.Request) {
+	var req setBillingModeRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	team, err := h.services.Team.SetBillingMode(CurrentTeam(r).ID, req.BillingMode)
+	if err != nil {
+		writeServiceError(w, err, "Failed to set billing mode")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(team)
+}
+
+// GetWallet handles GET /api/v1/teams/{team_id}/wallet.
+// Users see their own wallet; admins may pass ?user_id= to see anyone's.
+func (h *Handlers) GetWallet(w http.ResponseWriter, r *http.Request) {
+	user := CurrentUser(r)
+	userID := user.ID
+	if raw := r.URL.Query().Get("user_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, CodeInvalidRequest, "Invalid user ID")
+			return
+		}
+		userID = uint(id)
+	}
+	if userID != user.ID && !user.IsAdmin() {
+		writeError(w, http.StatusForbidden, CodeForbidden, "You can only view your own wallet")
+		return
+	}
+
+	wallet, err := h.services.Wallet.GetWallet(CurrentTeam(r).ID, userID, walletHistoryLimit)
+	if err != nil {
+		writeServiceError(w, err, "Failed to get wallet")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(wallet)
+}
+
+// TopUpWallet handles POST /api/v1/teams/{team_id}/wallet/top-ups
+func (h *Handlers) TopUpWallet(w http.ResponseWriter, r *http.Request) {
+	var req topUpRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	amount := models.NewMoney(req.Amount.MinorUnits, req.Amount.Currency)
+	entry, err := h.services.Wallet.TopUp(CurrentTeam(r).ID, req.UserID, amount, strings.TrimSpace(req.Note), CurrentUser(r).ID)
+	if err != nil {
+		writeServiceError(w, err, "Failed to top up wallet")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(entry)
+}
@@ -6,18 +6,47 @@ import (
 	"gorm.io/gorm"
 )
 
-// Box represents a coffee box/capsule package
+// DefaultLowStockThreshold is the number of remaining cups at which a new
+// box's owner is warned that it is running out
+const DefaultLowStockThreshold = 3
+
+// Box lifecycle statuses. A box is open while cups are taken from it and
+// finished once its owner says it is used up; it is settled when it is closed
+// and its cost split, and archived when a settled box is put away.
+// Only open boxes are active.
+const (
+	BoxOpen     = "open"
+	BoxFinished = "finished"
+	BoxSettled  = "settled"
+	BoxArchived = "archived"
+)
+
+// Box represents a coffee box/capsule package.
+// LowStockThreshold is the number of remaining cups at which the owner is
+// warned; zero turns the warning off. LowStockAlerted records that the
+// warning for the current threshold went out, so it is sent only once.
+// ReceiptFileID is the Telegram file ID of a photo of the receipt, if the
+// buyer sent one. BillingMode is the billing mode of the team the box was
+// used in, which the team can't change until the box is closed.
 type Box struct {
 	ID        uint           `json:"id" gorm:"primaryKey"`
 	Name      string         `json:"name" gorm:"not null"`
 	TotalCups int            `json:"total_cups" gorm:"not null"`
-	Price     float64        `json:"price" gorm:"not null"`
+	Price     Money          `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+	Status    string         `json:"status" gorm:"not null;default:open"`
 	IsActive  bool           `json:"is_active" gorm:"default:true"`
 	CreatedBy uint           `json:"created_by" gorm:"not null"`
+	TeamID    uint           `json:"team_id" gorm:"index"`
+	ClosedAt  *time.Time     `json:"closed_at"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 
+	LowStockThreshold int    `json:"low_stock_threshold" gorm:"not null"`
+	LowStockAlerted   bool   `json:"-" gorm:"not null;default:false"`
+	ReceiptFileID     string `json:"receipt_file_id" gorm:"not null;default:''"`
+	BillingMode       string `json:"billing_mode" gorm:"not null;default:postpaid"`
+
 	// Relationships
 	Creator    User        `json:"creator,omitempty" gorm:"foreignKey:CreatedBy"`
 	CoffeeLogs []CoffeeLog `json:"coffee_logs,omitempty" gorm:"foreignKey:BoxID"`
@@ -29,10 +58,20 @@ func (Box) TableName() string {
 	return "boxes"
 }
 
+// IsClosed reports whether the box has been closed and settled
+func (b *Box) IsClosed() bool {
+	return b.ClosedAt != nil
+}
+
+// IsPrepaid reports whether the box's cups are paid from wallets
+func (b *Box) IsPrepaid() bool {
+	return b.BillingMode == BillingPrepaid
+}
+
 // GetUsedCups returns the number of cups used from this box
 func (b *Box) GetUsedCups(db *gorm.DB) (int, error) {
 	var count int64
-	err := db.Model(&CoffeeLog{}).Where("box_id = ?", b.ID).Count(&count).Error
+	err := db.Model(&CoffeeLog{}).Scopes(NotVoided).Where("box_id = ?", b.ID).Count(&count).Error
 	return int(count), err
 }
 
@@ -44,3 +83,27 @@ func (b *Box) GetRemainingCups(db *gorm.DB) (int, error) {
 	}
 	return b.TotalCups - used, nil
 }
+
+// GetCostPerCup returns the price of a single cup.
+// When the price does not divide evenly the first cups carry the extra
+// minor units, so this is the most any single cup costs.
+func (b *Box) GetCostPerCup() Money {
+	if b.TotalCups <= 0 {
+		return NewMoney(0, b.Price.Currency)
+	}
+	cups := int64(b.TotalCups)
+	return NewMoney((b.Price.MinorUnits+cups-1)/cups, b.Price.Currency)
+}
+
+// CostOfCups returns what the first n cups cost together when the price is
+// split over every cup, the first cups carrying the extra minor units as in
+// GetCostPerCup. All the cups together cost exactly the price.
+func (b *Box) CostOfCups(n int) Money {
+	if b.TotalCups <= 0 {
+		return NewMoney(0, b.Price.Currency)
+	}
+	n = min(max(n, 0), b.TotalCups)
+	cups := int64(b.TotalCups)
+	base, extra := b.Price.MinorUnits/cups, b.Price.MinorUnits%cups
+	return NewMoney(base*int64(n)+min(int64(n), extra), b.Price.Currency)
+}
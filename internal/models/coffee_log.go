@@ -6,15 +6,20 @@ import (
 	"gorm.io/gorm"
 )
 
-// CoffeeLog represents a coffee consumption log entry
+// CoffeeLog represents a coffee consumption log entry.
+// Logs are never edited or deleted; a mistaken log is voided instead,
+// recording who voided it and why.
 type CoffeeLog struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	UserID    uint           `json:"user_id" gorm:"not null;index"`
-	BoxID     uint           `json:"box_id" gorm:"not null;index"`
-	LoggedAt  time.Time      `json:"logged_at" gorm:"not null"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	UserID     uint           `json:"user_id" gorm:"not null;index"`
+	BoxID      uint           `json:"box_id" gorm:"not null;index"`
+	LoggedAt   time.Time      `json:"logged_at" gorm:"not null"`
+	VoidedAt   *time.Time     `json:"voided_at,omitempty"`
+	VoidedBy   *uint          `json:"voided_by,omitempty"`
+	VoidReason string         `json:"void_reason,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 
 	// Relationships
 	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
@@ -25,3 +30,13 @@ type CoffeeLog struct {
 func (CoffeeLog) TableName() string {
 	return "coffee_logs"
 }
+
+// IsVoided reports whether the log has been voided
+func (l *CoffeeLog) IsVoided() bool {
+	return l.VoidedAt != nil
+}
+
+// NotVoided limits a coffee log query to logs that count towards consumption
+func NotVoided(db *gorm.DB) *gorm.DB {
+	return db.Where("coffee_logs.voided_at IS NULL")
+}
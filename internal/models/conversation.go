@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Conversation is the state of a multi-step bot command in progress, one per
+// chat. Step is the question awaiting an answer and Data holds the answers
+// given so far as JSON; their shape depends on Command.
+type Conversation struct {
+	ChatID    int64     `json:"chat_id" gorm:"primaryKey;autoIncrement:false"`
+	UserID    uint      `json:"user_id" gorm:"not null"`
+	Command   string    `json:"command" gorm:"not null"`
+	Step      string    `json:"step" gorm:"not null"`
+	Data      string    `json:"data" gorm:"not null"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for Conversation
+func (Conversation) TableName() string {
+	return "bot_conversations"
+}
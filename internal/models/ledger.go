@@ -0,0 +1,83 @@
+package models
+
+import "time"
+
+// Ledger account kinds. Postings are signed with debits positive, so box,
+// user and team cash accounts normally carry a positive balance and
+// purchaser and wallet accounts a negative one.
+const (
+	// AccountBox holds the value of the coffee left in a box
+	AccountBox = "box"
+	// AccountUser holds what a user owes for the cups they took
+	AccountUser = "user"
+	// AccountPurchaser holds what is owed to a user for the boxes they bought
+	AccountPurchaser = "purchaser"
+	// AccountWallet holds a user's prepaid money in a team
+	AccountWallet = "wallet"
+	// AccountTeamCash holds the money a prepaid team collected from top-ups
+	AccountTeamCash = "team_cash"
+)
+
+// Ledger transaction kinds
+const (
+	LedgerOpening   = "opening"
+	LedgerPurchase  = "purchase"
+	LedgerCup       = "cup"
+	LedgerVoid      = "void"
+	LedgerBoxClose  = "box_close"
+	LedgerBoxEdit   = "box_edit"
+	LedgerBoxDelete = "box_delete"
+	LedgerCharge    = "charge"
+	LedgerPayment   = "payment"
+	LedgerWriteOff  = "write_off"
+	LedgerTopUp     = "top_up"
+)
+
+// LedgerAccount is an account of the double-entry ledger.
+// Code identifies it, such as "box:3" or "wallet:1:7".
+type LedgerAccount struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Code      string    `json:"code" gorm:"not null;uniqueIndex"`
+	Kind      string    `json:"kind" gorm:"not null"`
+	UserID    *uint     `json:"user_id,omitempty"`
+	TeamID    *uint     `json:"team_id,omitempty"`
+	BoxID     *uint     `json:"box_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for LedgerAccount
+func (LedgerAccount) TableName() string {
+	return "ledger_accounts"
+}
+
+// LedgerTransaction is one money movement. Its postings add up to zero in
+// every currency, and it points at the record that caused it.
+// Transactions are append-only: mistakes are corrected by new transactions.
+type LedgerTransaction struct {
+	ID            uint            `json:"id" gorm:"primaryKey"`
+	Kind          string          `json:"kind" gorm:"not null"`
+	BoxID         *uint           `json:"box_id,omitempty"`
+	CoffeeLogID   *uint           `json:"coffee_log_id,omitempty"`
+	PaymentID     *uint           `json:"payment_id,omitempty"`
+	WalletEntryID *uint           `json:"wallet_entry_id,omitempty"`
+	CreatedAt     time.Time       `json:"created_at" gorm:"index"`
+	Postings      []LedgerPosting `json:"postings" gorm:"foreignKey:TransactionID"`
+}
+
+// TableName returns the table name for LedgerTransaction
+func (LedgerTransaction) TableName() string {
+	return "ledger_transactions"
+}
+
+// LedgerPosting debits (positive amount) or credits (negative amount) an account
+type LedgerPosting struct {
+	ID            uint  `json:"id" gorm:"primaryKey"`
+	TransactionID uint  `json:"transaction_id" gorm:"not null;index"`
+	AccountID     uint  `json:"account_id" gorm:"not null;index"`
+	Amount        Money `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+}
+
+// TableName returns the table name for LedgerPosting
+func (LedgerPosting) TableName() string {
+	return "ledger_postings"
+}
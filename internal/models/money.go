@@ -0,0 +1,145 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is used when an amount is given without a currency
+const DefaultCurrency = "EUR"
+
+// minorUnitsPerMajor is the number of minor units (cents) in one major unit
+const minorUnitsPerMajor = 100
+
+// Money represents an exact monetary amount in integer minor units (cents)
+type Money struct {
+	MinorUnits int64  `json:"minor_units" gorm:"not null"`
+	Currency   string `json:"currency" gorm:"size:3;not null"`
+}
+
+// NewMoney creates a Money value, falling back to DefaultCurrency
+func NewMoney(minorUnits int64, currency string) Money {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	return Money{MinorUnits: minorUnits, Currency: strings.ToUpper(currency)}
+}
+
+// amountPattern is a decimal amount with an optional minus sign and at most
+// two decimal places after a dot or comma
+var amountPattern = regexp.MustCompile(`^-?(\d+)(?:[.,](\d{1,2}))?$`)
+
+// ParseMoney parses a decimal string such as "15.99" or "15,99" into Money
+func ParseMoney(value, currency string) (Money, error) {
+	value = strings.TrimSpace(value)
+	match := amountPattern.FindStringSubmatch(value)
+	if match == nil {
+		return Money{}, fmt.Errorf("invalid amount %q", value)
+	}
+
+	major, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil || major > (math.MaxInt64-(minorUnitsPerMajor-1))/minorUnitsPerMajor {
+		return Money{}, fmt.Errorf("amount %q is too large", value)
+	}
+	minor := int64(0)
+	if match[2] != "" {
+		frac := match[2] + strings.Repeat("0", 2-len(match[2]))
+		if minor, err = strconv.ParseInt(frac, 10, 64); err != nil {
+			return Money{}, fmt.Errorf("invalid amount %q", value)
+		}
+	}
+
+	total := major*minorUnitsPerMajor + minor
+	if strings.HasPrefix(value, "-") {
+		total = -total
+	}
+	return NewMoney(total, currency), nil
+}
+
+// String formats the amount as "15.99 EUR"
+func (m Money) String() string {
+	sign := ""
+	units := m.MinorUnits
+	if units < 0 {
+		sign = "-"
+		units = -units
+	}
+	return fmt.Sprintf("%s%d.%02d %s", sign, units/minorUnitsPerMajor, units%minorUnitsPerMajor, m.Currency)
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.MinorUnits == 0
+}
+
+// Add returns the sum of two amounts in the same currency.
+// It panics when the currencies differ, since such a sum means nothing.
+func (m Money) Add(other Money) Money {
+	m.mustMatch(other)
+	return Money{MinorUnits: m.MinorUnits + other.MinorUnits, Currency: m.Currency}
+}
+
+// Sub returns the difference of two amounts in the same currency.
+// It panics when the currencies differ, like Add.
+func (m Money) Sub(other Money) Money {
+	m.mustMatch(other)
+	return Money{MinorUnits: m.MinorUnits - other.MinorUnits, Currency: m.Currency}
+}
+
+// mustMatch panics unless other is in the same currency as m
+func (m Money) mustMatch(other Money) {
+	if m.Currency != other.Currency {
+		panic(fmt.Sprintf("money: can't combine %s with %s", m.Currency, other.Currency))
+	}
+}
+
+// Allocate splits the amount proportionally to the given weights.
+// Every share is rounded down to a whole minor unit and the leftover
+// units are handed out one at a time to the shares with the largest
+// rounding remainder, ties going to the lower index. The shares always
+// sum to exactly the original amount.
+func (m Money) Allocate(weights []int64) []Money {
+	if m.MinorUnits < 0 {
+		shares := Money{MinorUnits: -m.MinorUnits, Currency: m.Currency}.Allocate(weights)
+		for i := range shares {
+			shares[i].MinorUnits = -shares[i].MinorUnits
+		}
+		return shares
+	}
+
+	shares := make([]Money, len(weights))
+	var totalWeight int64
+	for i, w := range weights {
+		shares[i] = Money{Currency: m.Currency}
+		totalWeight += w
+	}
+	if totalWeight <= 0 {
+		return shares
+	}
+
+	remainders := make([]int64, len(weights))
+	distributed := int64(0)
+	for i, w := range weights {
+		shares[i].MinorUnits = m.MinorUnits * w / totalWeight
+		remainders[i] = m.MinorUnits * w % totalWeight
+		distributed += shares[i].MinorUnits
+	}
+
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]] > remainders[order[b]]
+	})
+
+	for i := int64(0); i < m.MinorUnits-distributed; i++ {
+		shares[order[i]].MinorUnits++
+	}
+
+	return shares
+}
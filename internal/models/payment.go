@@ -6,17 +6,27 @@ import (
 	"gorm.io/gorm"
 )
 
+// Payment statuses
+const (
+	PaymentStatusPending   = "pending"
+	PaymentStatusPaid      = "paid"
+	PaymentStatusCancelled = "cancelled"
+)
+
 // Payment represents a payment made by a user for coffee consumption
 type Payment struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	UserID    uint           `json:"user_id" gorm:"not null;index"`
-	BoxID     uint           `json:"box_id" gorm:"not null;index"`
-	Amount    float64        `json:"amount" gorm:"not null"`
-	IsPaid    bool           `json:"is_paid" gorm:"default:false"`
-	PaidAt    *time.Time     `json:"paid_at"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	UserID       uint           `json:"user_id" gorm:"not null;index"`
+	BoxID        uint           `json:"box_id" gorm:"not null;index"`
+	Amount       Money          `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	IsPaid       bool           `json:"is_paid" gorm:"default:false"`
+	PaidAt       *time.Time     `json:"paid_at"`
+	CancelledAt  *time.Time     `json:"cancelled_at"`
+	Status       string         `json:"status" gorm:"-"`
+	SettlementID *uint          `json:"settlement_id"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 
 	// Relationships
 	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
@@ -27,3 +37,21 @@ type Payment struct {
 func (Payment) TableName() string {
 	return "payments"
 }
+
+// GetStatus derives the payment status from its timestamps
+func (p *Payment) GetStatus() string {
+	switch {
+	case p.CancelledAt != nil:
+		return PaymentStatusCancelled
+	case p.IsPaid:
+		return PaymentStatusPaid
+	default:
+		return PaymentStatusPending
+	}
+}
+
+// AfterFind fills in the derived status after loading a payment
+func (p *Payment) AfterFind(tx *gorm.DB) error {
+	p.Status = p.GetStatus()
+	return nil
+}
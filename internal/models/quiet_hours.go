@@ -0,0 +1,66 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// QuietHours is a daily period in which a user gets no reminders.
+// Start and End are minutes after midnight; a period whose end is before its
+// start runs past midnight, and equal bounds mean no quiet hours at all.
+type QuietHours struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// ParseQuietHours parses a period such as "22:00-08:00"
+func ParseQuietHours(value string) (QuietHours, error) {
+	startPart, endPart, ok := strings.Cut(strings.TrimSpace(value), "-")
+	if !ok {
+		return QuietHours{}, fmt.Errorf("quiet hours %q must look like 22:00-08:00", value)
+	}
+
+	start, err := parseClock(startPart)
+	if err != nil {
+		return QuietHours{}, err
+	}
+	end, err := parseClock(endPart)
+	if err != nil {
+		return QuietHours{}, err
+	}
+	return QuietHours{Start: start, End: end}, nil
+}
+
+// parseClock parses "HH:MM" into minutes after midnight
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Contains reports whether the wall clock time of t falls in the period
+func (q QuietHours) Contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	if q.Start <= q.End {
+		return minute >= q.Start && minute < q.End
+	}
+	return minute >= q.Start || minute < q.End
+}
+
+// EndAfter returns the first time after t at which the period ends
+func (q QuietHours) EndAfter(t time.Time) time.Time {
+	end := time.Date(t.Year(), t.Month(), t.Day(), q.End/60, q.End%60, 0, 0, t.Location())
+	if !end.After(t) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return end
+}
+
+// String formats the period as "22:00-08:00"
+func (q QuietHours) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d",
+		q.Start/60, q.Start%60, q.End/60, q.End%60)
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Settlement records an accepted plan that settled a team's payments
+type Settlement struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	Token      string         `json:"token" gorm:"uniqueIndex;not null"`
+	AcceptedBy uint           `json:"accepted_by" gorm:"not null"`
+	TeamID     uint           `json:"team_id" gorm:"index"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	// Relationships
+	Acceptor User      `json:"acceptor,omitempty" gorm:"foreignKey:AcceptedBy"`
+	Payments []Payment `json:"payments,omitempty" gorm:"foreignKey:SettlementID"`
+}
+
+// TableName returns the table name for Settlement
+func (Settlement) TableName() string {
+	return "settlements"
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Billing modes of a team
+const (
+	// BillingPostpaid splits each box between its consumers when it is closed
+	BillingPostpaid = "postpaid"
+	// BillingPrepaid deducts every cup from the consumer's wallet as it is logged
+	BillingPrepaid = "prepaid"
+)
+
+// Team is an office or group of colleagues sharing boxes.
+// ChatID is the Telegram group chat linked to the team, if any.
+type Team struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	Name        string         `json:"name" gorm:"not null"`
+	ChatID      *int64         `json:"chat_id,omitempty" gorm:"uniqueIndex"`
+	BillingMode string         `json:"billing_mode" gorm:"not null;default:postpaid"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// IsPrepaid reports whether the team's members pay for cups from their wallets
+func (t *Team) IsPrepaid() bool {
+	return t.BillingMode == BillingPrepaid
+}
+
+// TableName returns the table name for Team
+func (Team) TableName() string {
+	return "teams"
+}
+
+// TeamMember grants a user access to a team's boxes, payments and statistics
+type TeamMember struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	TeamID    uint      `json:"team_id" gorm:"not null;uniqueIndex:idx_team_members_team_user"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_team_members_team_user;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for TeamMember
+func (TeamMember) TableName() string {
+	return "team_members"
+}
@@ -6,6 +6,12 @@ import (
 	"gorm.io/gorm"
 )
 
+// User roles
+const (
+	RoleMember = "member"
+	RoleAdmin  = "admin"
+)
+
 // User represents a user in the system
 type User struct {
 	ID         uint           `json:"id" gorm:"primaryKey"`
@@ -14,10 +20,16 @@ type User struct {
 	FirstName  string         `json:"first_name"`
 	LastName   string         `json:"last_name"`
 	IsActive   bool           `json:"is_active" gorm:"default:true"`
+	Role       string         `json:"role" gorm:"not null;default:member"`
 	CreatedAt  time.Time      `json:"created_at"`
 	UpdatedAt  time.Time      `json:"updated_at"`
 	DeletedAt  gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 
+	// Payment reminder preferences; quiet hours are minutes after midnight
+	RemindersEnabled bool `json:"reminders_enabled" gorm:"not null;default:true"`
+	QuietHoursStart  *int `json:"quiet_hours_start,omitempty"`
+	QuietHoursEnd    *int `json:"quiet_hours_end,omitempty"`
+
 	// Relationships
 	CoffeeLogs []CoffeeLog `json:"coffee_logs,omitempty" gorm:"foreignKey:UserID"`
 	Payments   []Payment   `json:"payments,omitempty" gorm:"foreignKey:UserID"`
@@ -27,3 +39,16 @@ type User struct {
 func (User) TableName() string {
 	return "users"
 }
+
+// IsAdmin reports whether the user has the admin role
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+// QuietHours returns the user's own quiet hours, if they set any
+func (u *User) QuietHours() (QuietHours, bool) {
+	if u.QuietHoursStart == nil || u.QuietHoursEnd == nil {
+		return QuietHours{}, false
+	}
+	return QuietHours{Start: *u.QuietHoursStart, End: *u.QuietHoursEnd}, true
+}
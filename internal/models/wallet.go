@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Wallet entry kinds
+const (
+	WalletTopUp     = "top_up"
+	WalletDeduction = "deduction"
+	WalletRefund    = "refund"
+)
+
+// WalletEntry is one movement in a user's prepaid wallet within a team.
+// Top-ups and refunds are positive and cup deductions negative, so the
+// balance is the sum of the amounts. Deductions and the refunds of voided
+// cups point at their coffee log.
+type WalletEntry struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	TeamID      uint      `json:"team_id" gorm:"not null"`
+	UserID      uint      `json:"user_id" gorm:"not null"`
+	Kind        string    `json:"kind" gorm:"not null"`
+	Amount      Money     `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	CoffeeLogID *uint     `json:"coffee_log_id,omitempty" gorm:"index"`
+	Note        string    `json:"note,omitempty"`
+	CreatedBy   uint      `json:"created_by" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for WalletEntry
+func (WalletEntry) TableName() string {
+	return "wallet_entries"
+}
@@ -0,0 +1,22 @@
+package scheduler
+
+import "time"
+
+// Clock tells the scheduler the time and lets it wait; tests use a fake one
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the wall clock
+type realClock struct{}
+
+// Now returns the current time
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// After waits for the duration to elapse
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
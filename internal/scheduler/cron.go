@@ -0,0 +1,140 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxScheduleSearch bounds how far ahead Next looks for a matching minute
+const maxScheduleSearch = 5 * 366 * 24 * time.Hour
+
+// cronField is the allowed range of one field of a cron expression
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 6},
+}
+
+// Schedule is a parsed five-field cron expression: minute, hour, day of
+// month, month and day of week. Each field accepts "*", numbers, ranges
+// ("1-5"), lists ("1,15") and steps ("*/15", "9-17/2").
+type Schedule struct {
+	spec   string
+	fields [5]map[int]bool
+	// restricted day fields combine with OR, as in cron
+	anyDayOfMonth, anyDayOfWeek bool
+}
+
+// ParseSchedule parses a five-field cron expression
+func ParseSchedule(spec string) (*Schedule, error) {
+	parts := strings.Fields(spec)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("schedule %q must have %d fields", spec, len(cronFields))
+	}
+
+	schedule := &Schedule{spec: spec}
+	for i, part := range parts {
+		values, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+		}
+		schedule.fields[i] = values
+	}
+	schedule.anyDayOfMonth = parts[2] == "*"
+	schedule.anyDayOfWeek = parts[4] == "*"
+	return schedule, nil
+}
+
+// parseCronField expands one field into the set of values it matches
+func parseCronField(part string, field cronField) (map[int]bool, error) {
+	values := make(map[int]bool)
+	for _, item := range strings.Split(part, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step %q in %s", stepPart, field.name)
+			}
+		}
+
+		low, high := field.min, field.max
+		if rangePart != "*" {
+			lowPart, highPart, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = strconv.Atoi(lowPart); err != nil {
+				return nil, fmt.Errorf("invalid %s %q", field.name, item)
+			}
+			high = low
+			if isRange {
+				if high, err = strconv.Atoi(highPart); err != nil {
+					return nil, fmt.Errorf("invalid %s %q", field.name, item)
+				}
+			} else if hasStep {
+				high = field.max
+			}
+		}
+		if low < field.min || high > field.max || low > high {
+			return nil, fmt.Errorf("%s %q is out of range %d-%d", field.name, item, field.min, field.max)
+		}
+
+		for v := low; v <= high; v += step {
+			values[v] = true
+		}
+	}
+	return values, nil
+}
+
+// String returns the expression the schedule was parsed from
+func (s *Schedule) String() string {
+	return s.spec
+}
+
+// Next returns the first matching minute strictly after t, in t's location.
+// It returns the zero time if nothing matches within five years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxScheduleSearch)
+
+	for next.Before(limit) {
+		switch {
+		case !s.fields[3][int(next.Month())]:
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, next.Location())
+		case !s.matchesDay(next):
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, next.Location())
+		case !s.fields[1][next.Hour()]:
+			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour()+1, 0, 0, 0, next.Location())
+		case !s.fields[0][next.Minute()]:
+			next = next.Add(time.Minute)
+		default:
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+// matchesDay applies cron's rule that a restricted day of month and day of
+// week match when either of them does
+func (s *Schedule) matchesDay(t time.Time) bool {
+	dayOfMonth := s.fields[2][t.Day()]
+	dayOfWeek := s.fields[4][int(t.Weekday())]
+	switch {
+	case s.anyDayOfMonth && s.anyDayOfWeek:
+		return true
+	case s.anyDayOfMonth:
+		return dayOfWeek
+	case s.anyDayOfWeek:
+		return dayOfMonth
+	default:
+		return dayOfMonth || dayOfWeek
+	}
+}
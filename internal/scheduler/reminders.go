@@ -0,0 +1,132 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/your-username/coffee-cups-system/internal/config"
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"github.com/your-username/coffee-cups-system/internal/services"
+)
+
+// Notifier delivers a direct message to a user; the Telegram bot implements it
+type Notifier interface {
+	NotifyUser(telegramID int64, text string) error
+}
+
+// PaymentReminders is the job that messages users about what they owe.
+// Reminders that fall in a user's quiet hours are queued until they end.
+type PaymentReminders struct {
+	services   *services.Services
+	notifier   Notifier
+	minAmount  int64
+	quietHours *models.QuietHours
+	// queued maps user IDs to when their held-back reminder is due. The
+	// scheduler runs one job at a time, so it needs no lock.
+	queued map[uint]time.Time
+}
+
+// NewPaymentReminders creates the reminder job from its configuration
+func NewPaymentReminders(cfg config.RemindersConfig, services *services.Services, notifier Notifier) (*PaymentReminders, error) {
+	reminders := &PaymentReminders{
+		services:  services,
+		notifier:  notifier,
+		minAmount: cfg.MinAmount,
+		queued:    make(map[uint]time.Time),
+	}
+	if cfg.QuietHours != "" {
+		quiet, err := models.ParseQuietHours(cfg.QuietHours)
+		if err != nil {
+			return nil, fmt.Errorf("invalid default quiet hours: %w", err)
+		}
+		reminders.quietHours = &quiet
+	}
+	return reminders, nil
+}
+
+// Run sends a reminder to every user who owes money. Users in their quiet
+// hours are queued for RunQueued instead. Users who could not be reached
+// are reported together.
+func (p *PaymentReminders) Run(ctx context.Context, now time.Time) error {
+	return p.remind(ctx, now, func(uint) bool { return true })
+}
+
+// RunQueued sends the queued reminders whose quiet hours have ended, if the
+// users still owe money. It is meant to run every minute.
+func (p *PaymentReminders) RunQueued(ctx context.Context, now time.Time) error {
+	due := make(map[uint]bool)
+	for userID, at := range p.queued {
+		if !at.After(now) {
+			due[userID] = true
+			delete(p.queued, userID)
+		}
+	}
+	if len(due) == 0 {
+		return nil
+	}
+	return p.remind(ctx, now, func(userID uint) bool { return due[userID] })
+}
+
+// remind sends the due reminders of the users selected by include
+func (p *PaymentReminders) remind(ctx context.Context, now time.Time, include func(userID uint) bool) error {
+	reminders, err := p.services.Reminder.GetDueReminders(p.minAmount)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, reminder := range reminders {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		user := reminder.User
+		if !include(user.ID) {
+			continue
+		}
+		if end, quiet := p.quietUntil(&user, now); quiet {
+			p.queued[user.ID] = end
+			continue
+		}
+		delete(p.queued, user.ID)
+		if err := p.notifier.NotifyUser(user.TelegramID, reminderText(reminder)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remind user %d: %w", user.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// quietUntil reports whether now is in the user's own quiet hours, or the
+// default ones, and when they end
+func (p *PaymentReminders) quietUntil(user *models.User, now time.Time) (time.Time, bool) {
+	quiet, ok := user.QuietHours()
+	if !ok && p.quietHours != nil {
+		quiet, ok = *p.quietHours, true
+	}
+	if !ok || !quiet.Contains(now) {
+		return time.Time{}, false
+	}
+	return quiet.EndAfter(now), true
+}
+
+// reminderText summarises what a user owes
+func reminderText(reminder services.Reminder) string {
+	var b strings.Builder
+	b.WriteString("⏰ Payment reminder\n\nYou still owe:\n")
+	for _, payment := range reminder.Payments {
+		fmt.Fprintf(&b, "💸 %s: %s (payment #%d)\n", payment.Box.Name, payment.Amount, payment.ID)
+	}
+	for _, debt := range reminder.OpenBoxes {
+		fmt.Fprintf(&b, "☕ %s: %s so far (box still open)\n", debt.Box.Name, debt.Amount)
+	}
+
+	totals := make([]string, 0, len(reminder.Totals))
+	for _, total := range reminder.Totals {
+		totals = append(totals, total.String())
+	}
+	fmt.Fprintf(&b, "\nTotal: %s\n\nUse /settle to see whom to pay, or /reminders off to stop these messages.",
+		strings.Join(totals, ", "))
+	return b.String()
+}
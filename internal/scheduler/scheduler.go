@@ -0,0 +1,94 @@
+// Package scheduler runs background jobs on cron-like schedules
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/your-username/coffee-cups-system/internal/logger"
+)
+
+// Job is a unit of scheduled work; now is the time it was started at
+type Job func(ctx context.Context, now time.Time) error
+
+// entry is a job together with its schedule and next run time
+type entry struct {
+	name     string
+	schedule *Schedule
+	job      Job
+	next     time.Time
+}
+
+// Scheduler runs jobs one at a time whenever their schedule comes due
+type Scheduler struct {
+	clock    Clock
+	location *time.Location
+	logger   *logger.Logger
+	entries  []*entry
+}
+
+// New creates a scheduler on the wall clock evaluating schedules in location
+func New(location *time.Location, logger *logger.Logger) *Scheduler {
+	return NewWithClock(realClock{}, location, logger)
+}
+
+// NewWithClock creates a scheduler on the given clock
+func NewWithClock(clock Clock, location *time.Location, logger *logger.Logger) *Scheduler {
+	return &Scheduler{clock: clock, location: location, logger: logger}
+}
+
+// Add registers a job under a name to run on a cron expression
+func (s *Scheduler) Add(name, spec string, job Job) error {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return fmt.Errorf("failed to schedule %s: %w", name, err)
+	}
+	s.entries = append(s.entries, &entry{name: name, schedule: schedule, job: job})
+	return nil
+}
+
+// Run runs the jobs as they come due until ctx is cancelled. A failing job
+// is logged and runs again at its next scheduled time.
+func (s *Scheduler) Run(ctx context.Context) error {
+	now := s.clock.Now().In(s.location)
+	for _, e := range s.entries {
+		e.next = e.schedule.Next(now)
+	}
+
+	for {
+		next, ok := s.nextRun()
+		if !ok {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.clock.After(next.Sub(s.clock.Now())):
+		}
+
+		now = s.clock.Now().In(s.location)
+		for _, e := range s.entries {
+			if e.next.IsZero() || e.next.After(now) {
+				continue
+			}
+			if err := e.job(ctx, now); err != nil {
+				s.logger.WithField("job", e.name).WithError(err).Error("Scheduled job failed")
+			}
+			e.next = e.schedule.Next(now)
+		}
+	}
+}
+
+// nextRun returns the earliest time any job is due
+func (s *Scheduler) nextRun() (time.Time, bool) {
+	var next time.Time
+	for _, e := range s.entries {
+		if !e.next.IsZero() && (next.IsZero() || e.next.Before(next)) {
+			next = e.next
+		}
+	}
+	return next, !next.IsZero()
+}
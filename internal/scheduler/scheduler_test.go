@@ -0,0 +1,179 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-username/coffee-cups-system/internal/config"
+	"github.com/your-username/coffee-cups-system/internal/database"
+	"github.com/your-username/coffee-cups-system/internal/logger"
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"github.com/your-username/coffee-cups-system/internal/scheduler/schedulertest"
+	"github.com/your-username/coffee-cups-system/internal/services"
+)
+
+// fakeNotifier records the messages it is asked to deliver
+type fakeNotifier struct {
+	sent map[int64]string
+	fail map[int64]bool
+}
+
+// NotifyUser records the message, or fails for users listed in fail
+func (n *fakeNotifier) NotifyUser(telegramID int64, text string) error {
+	if n.fail[telegramID] {
+		return errors.New("bot was blocked by the user")
+	}
+	n.sent[telegramID] = text
+	return nil
+}
+
+// TestScheduleNext tests parsing cron expressions and finding their next run
+func TestScheduleNext(t *testing.T) {
+	// Friday 2024-03-15 10:30 UTC
+	from := time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 3, 15, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 3, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2024, 3, 18, 9, 0, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2024, 3, 16, 10, 30, 0, 0, time.UTC)},
+		{"0 12 1,15 * *", time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 8 1 * 0", time.Date(2024, 3, 17, 8, 0, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2024, 3, 15, 13, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		schedule, err := ParseSchedule(c.spec)
+		require.NoError(t, err, c.spec)
+		assert.Equal(t, c.want, schedule.Next(from), c.spec)
+	}
+
+	never, err := ParseSchedule("0 0 31 2 *")
+	require.NoError(t, err)
+	assert.True(t, never.Next(from).IsZero())
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 9-5 * * *", "*/0 * * * *", "a * * * *"} {
+		_, err := ParseSchedule(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+// TestSchedulerRun tests that jobs run when the fake clock reaches their schedule
+func TestSchedulerRun(t *testing.T) {
+	clock := schedulertest.NewFakeClock(time.Date(2024, 3, 15, 8, 0, 0, 0, time.UTC))
+	s := NewWithClock(clock, time.UTC, logger.New("error"))
+
+	runs := make(chan time.Time, 10)
+	require.NoError(t, s.Add("hourly", "0 * * * *", func(ctx context.Context, now time.Time) error {
+		runs <- now
+		return errors.New("failures do not stop the scheduler")
+	}))
+	assert.Error(t, s.Add("broken", "every hour", nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+
+	for _, want := range []time.Time{
+		time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC),
+	} {
+		require.Eventually(t, func() bool { return clock.Waiters() == 1 }, time.Second, time.Millisecond)
+		clock.Advance(30 * time.Minute)
+		assert.Empty(t, runs, "job ran before it was due")
+		require.Eventually(t, func() bool { return clock.Waiters() == 1 }, time.Second, time.Millisecond)
+		clock.Advance(want.Sub(clock.Now()))
+		assert.Equal(t, want, <-runs)
+	}
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+// TestPaymentReminders tests who gets reminded of what
+func TestPaymentReminders(t *testing.T) {
+	db, err := database.New(config.DatabaseConfig{Driver: database.DriverSQLite, Path: ":memory:"})
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	_, err = db.EnsureSchema(true)
+	require.NoError(t, err)
+	svc := services.NewServices(db.DB, nil)
+
+	newUser := func(telegramID int64, name string) *models.User {
+		user, err := svc.User.CreateOrUpdateUser(telegramID, "", name, "")
+		require.NoError(t, err)
+		return user
+	}
+	owner, anna, ben, cleo, dan := newUser(1, "Owner"), newUser(2, "Anna"), newUser(3, "Ben"), newUser(4, "Cleo"), newUser(5, "Dan")
+	team, err := svc.Team.CreateTeam("Office")
+	require.NoError(t, err)
+	for _, user := range []*models.User{owner, anna, ben, cleo, dan} {
+		require.NoError(t, svc.Team.AddMember(team.ID, user.ID))
+	}
+
+	// Anna owes a payment from a closed box, Ben and Cleo only their running
+	// share of an open box, Dan barely anything
+	closed, err := svc.Box.CreateBox("Closed Box", 2, models.NewMoney(1000, "EUR"), owner.ID, team.ID)
+	require.NoError(t, err)
+	open, err := svc.Box.CreateBox("Open Box", 10, models.NewMoney(1000, "EUR"), owner.ID, team.ID)
+	require.NoError(t, err)
+	for _, log := range []struct{ user, box uint }{
+		{anna.ID, closed.ID}, {owner.ID, closed.ID}, {ben.ID, open.ID}, {ben.ID, open.ID}, {cleo.ID, open.ID}, {owner.ID, open.ID},
+	} {
+		_, err := svc.Coffee.LogCoffee(log.user, log.box)
+		require.NoError(t, err)
+	}
+	_, err = svc.Box.FinishBox(closed.ID)
+	require.NoError(t, err)
+	_, err = svc.Box.CloseBox(closed.ID)
+	require.NoError(t, err)
+	require.NoError(t, svc.User.SetRemindersEnabled(cleo.ID, false))
+	require.NoError(t, svc.User.SetQuietHours(ben.ID, &models.QuietHours{Start: 8 * 60, End: 10 * 60}))
+
+	reminders, err := svc.Reminder.GetDueReminders(150)
+	require.NoError(t, err)
+	require.Len(t, reminders, 2)
+	assert.Equal(t, anna.ID, reminders[0].User.ID)
+	assert.Equal(t, []models.Money{models.NewMoney(500, "EUR")}, reminders[0].Totals)
+	assert.Equal(t, ben.ID, reminders[1].User.ID)
+	assert.Equal(t, []models.Money{models.NewMoney(200, "EUR")}, reminders[1].Totals)
+
+	notifier := &fakeNotifier{sent: map[int64]string{}, fail: map[int64]bool{}}
+	job, err := NewPaymentReminders(config.RemindersConfig{MinAmount: 150, QuietHours: "22:00-07:00"}, svc, notifier)
+	require.NoError(t, err)
+
+	// Ben's own quiet hours replace the default ones
+	require.NoError(t, job.Run(context.Background(), time.Date(2024, 3, 15, 23, 0, 0, 0, time.UTC)))
+	assert.Len(t, notifier.sent, 1)
+	assert.Contains(t, notifier.sent[ben.TelegramID], "Open Box: 2.00 EUR so far")
+	delete(notifier.sent, ben.TelegramID)
+	require.NoError(t, job.Run(context.Background(), time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC)))
+	assert.Len(t, notifier.sent, 1)
+	assert.Contains(t, notifier.sent[anna.TelegramID], "Closed Box: 5.00 EUR")
+
+	// Ben's reminder was held back until the end of the quiet hours
+	delete(notifier.sent, anna.TelegramID)
+	require.NoError(t, job.RunQueued(context.Background(), time.Date(2024, 3, 15, 9, 59, 0, 0, time.UTC)))
+	assert.Empty(t, notifier.sent)
+	require.NoError(t, job.RunQueued(context.Background(), time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC)))
+	assert.Len(t, notifier.sent, 1)
+	assert.Contains(t, notifier.sent[ben.TelegramID], "Open Box: 2.00 EUR so far")
+	delete(notifier.sent, ben.TelegramID)
+	require.NoError(t, job.RunQueued(context.Background(), time.Date(2024, 3, 15, 10, 1, 0, 0, time.UTC)))
+	assert.Empty(t, notifier.sent)
+
+	notifier.fail[ben.TelegramID] = true
+	err = job.Run(context.Background(), time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC))
+	assert.ErrorContains(t, err, "blocked")
+	assert.Contains(t, notifier.sent[anna.TelegramID], "Total: 5.00 EUR")
+
+	_, err = NewPaymentReminders(config.RemindersConfig{QuietHours: "late"}, svc, notifier)
+	assert.Error(t, err)
+}
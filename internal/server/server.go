@@ -19,23 +19,56 @@ type Server struct {
 	logger     interface{}
 }
 
+// Mount is an extra POST handler served outside the authenticated API
+type Mount struct {
+	Path    string
+	Handler http.Handler
+}
+
 // New creates a new HTTP server
-func New(cfg config.ServerConfig, services *services.Services, logger interface{}) *Server {
+func New(cfg config.ServerConfig, services *services.Services, logger interface{}, mounts ...Mount) *Server {
 	router := mux.NewRouter()
 
 	// Initialize handlers
 	handlers := handlers.New(services, logger)
 
-	// API routes
+	// API routes, all of which require a bearer token
 	api := router.PathPrefix("/api/v1").Subrouter()
+	api.Use(handlers.Authenticate)
 	api.HandleFunc("/users", handlers.GetUsers).Methods("GET")
 	api.HandleFunc("/users/{id}", handlers.GetUser).Methods("GET")
-	api.HandleFunc("/boxes", handlers.GetBoxes).Methods("GET")
-	api.HandleFunc("/boxes", handlers.CreateBox).Methods("POST")
+	api.HandleFunc("/users/{id}/balance", handlers.GetUserBalance).Methods("GET")
+	api.HandleFunc("/teams", handlers.GetTeams).Methods("GET")
+	api.HandleFunc("/teams", handlers.RequireAdmin(handlers.CreateTeam)).Methods("POST")
 	api.HandleFunc("/boxes/{id}", handlers.GetBox).Methods("GET")
-	api.HandleFunc("/coffee-logs", handlers.GetCoffeeLogs).Methods("GET")
+	api.HandleFunc("/boxes/{id}", handlers.UpdateBox).Methods("PATCH")
+	api.HandleFunc("/boxes/{id}", handlers.DeleteBox).Methods("DELETE")
+	api.HandleFunc("/boxes/{id}/finish", handlers.FinishBox).Methods("POST")
+	api.HandleFunc("/boxes/{id}/close", handlers.CloseBox).Methods("POST")
+	api.HandleFunc("/boxes/{id}/archive", handlers.ArchiveBox).Methods("POST")
+	api.HandleFunc("/boxes/{id}/reopen", handlers.ReopenBox).Methods("POST")
 	api.HandleFunc("/coffee-logs", handlers.LogCoffee).Methods("POST")
-	api.HandleFunc("/payments", handlers.GetPayments).Methods("GET")
+	api.HandleFunc("/coffee-logs/{id}/void", handlers.RequireAdmin(handlers.VoidCoffeeLog)).Methods("POST")
+	api.HandleFunc("/payments", handlers.CreatePayment).Methods("POST")
+	api.HandleFunc("/payments/{id}", handlers.GetPayment).Methods("GET")
+	api.HandleFunc("/payments/{id}/pay", handlers.MarkPaymentAsPaid).Methods("POST")
+	api.HandleFunc("/payments/{id}/cancel", handlers.CancelPayment).Methods("POST")
+
+	// Team-scoped routes, only open to the team's members and admins
+	team := api.PathPrefix("/teams/{team_id}").Subrouter()
+	team.Use(handlers.RequireTeamMember)
+	team.HandleFunc("/members", handlers.GetTeamMembers).Methods("GET")
+	team.HandleFunc("/members", handlers.RequireAdmin(handlers.AddTeamMember)).Methods("POST")
+	team.HandleFunc("/boxes", handlers.GetBoxes).Methods("GET")
+	team.HandleFunc("/boxes", handlers.CreateBox).Methods("POST")
+	team.HandleFunc("/coffee-logs", handlers.GetCoffeeLogs).Methods("GET")
+	team.HandleFunc("/payments", handlers.GetPayments).Methods("GET")
+	team.HandleFunc("/settlements/plan", handlers.GetSettlementPlan).Methods("GET")
+	team.HandleFunc("/settlements/plan/accept", handlers.RequireAdmin(handlers.AcceptSettlementPlan)).Methods("POST")
+	team.HandleFunc("/analytics/usage", handlers.GetUsageAnalytics).Methods("GET")
+	team.HandleFunc("/billing-mode", handlers.RequireAdmin(handlers.SetTeamBillingMode)).Methods("PUT")
+	team.HandleFunc("/wallet", handlers.GetWallet).Methods("GET")
+	team.HandleFunc("/wallet/top-ups", handlers.RequireAdmin(handlers.TopUpWallet)).Methods("POST")
 
 	// Health check
 	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -43,6 +76,11 @@ func New(cfg config.ServerConfig, services *services.Services, logger interface{
 		w.Write([]byte("OK"))
 	}).Methods("GET")
 
+	// Extra handlers such as the Telegram webhook bring their own authentication
+	for _, mount := range mounts {
+		router.Handle(mount.Path, mount.Handler).Methods("POST")
+	}
+
 	httpServer := &http.Server{
 		Addr:         fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
 		Handler:      router,
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidPeriod is returned for an unknown analytics period
+var ErrInvalidPeriod = newError(KindValidation, "INVALID_PERIOD", "period must be one of day, week, month or year").
+	WithFields(FieldError{Field: "period", Message: "must be one of day, week, month, year"})
+
+// DefaultAnalyticsPeriod is used when no period is requested
+const DefaultAnalyticsPeriod = "month"
+
+// topConsumersLimit is the number of users listed as top consumers
+const topConsumersLimit = 5
+
+// analyticsPeriod describes the window covered by a period and its timeline granularity
+type analyticsPeriod struct {
+	since  func(time.Time) time.Time
+	bucket string
+}
+
+var analyticsPeriods = map[string]analyticsPeriod{
+	"day":   {since: func(t time.Time) time.Time { return t.AddDate(0, 0, -1) }, bucket: "hour"},
+	"week":  {since: func(t time.Time) time.Time { return t.AddDate(0, 0, -7) }, bucket: "day"},
+	"month": {since: func(t time.Time) time.Time { return t.AddDate(0, -1, 0) }, bucket: "day"},
+	"year":  {since: func(t time.Time) time.Time { return t.AddDate(-1, 0, 0) }, bucket: "month"},
+}
+
+// bucketFormats holds the date format for each timeline granularity per SQL dialect
+var bucketFormats = map[string]map[string]string{
+	"postgres": {"hour": `YYYY-MM-DD"T"HH24:00`, "day": "YYYY-MM-DD", "month": "YYYY-MM"},
+	"sqlite":   {"hour": "%Y-%m-%dT%H:00", "day": "%Y-%m-%d", "month": "%Y-%m"},
+}
+
+// UsageBucket is the consumption within one slot of the timeline
+type UsageBucket struct {
+	Bucket      string `json:"bucket"`
+	Cups        int64  `json:"cups"`
+	ActiveUsers int64  `json:"active_users"`
+}
+
+// BoxUtilization is the consumption of a single box within the period
+type BoxUtilization struct {
+	BoxID       uint         `json:"box_id"`
+	Name        string       `json:"name"`
+	Cups        int64        `json:"cups"`
+	TotalCups   int          `json:"total_cups"`
+	Utilization float64      `json:"utilization"`
+	Spend       models.Money `json:"spend"`
+}
+
+// Consumer is a user and the number of cups they drank within the period
+type Consumer struct {
+	UserID uint   `json:"user_id"`
+	Name   string `json:"name"`
+	Cups   int64  `json:"cups"`
+}
+
+// UsageAnalytics summarizes coffee consumption over a period
+type UsageAnalytics struct {
+	TeamID               uint             `json:"team_id"`
+	Period               string           `json:"period"`
+	From                 time.Time        `json:"from"`
+	To                   time.Time        `json:"to"`
+	TotalUsers           int64            `json:"total_users"`
+	TotalBoxes           int64            `json:"total_boxes"`
+	TotalCoffeeLogs      int64            `json:"total_coffee_logs"`
+	TotalPayments        int64            `json:"total_payments"`
+	ActiveUsers          int64            `json:"active_users"`
+	AverageCoffeePerUser float64          `json:"average_coffee_per_user"`
+	Spend                []models.Money   `json:"spend"`
+	TotalRevenue         []models.Money   `json:"total_revenue"`
+	Timeline             []UsageBucket    `json:"timeline"`
+	BoxUtilization       []BoxUtilization `json:"box_utilization"`
+	TopConsumers         []Consumer       `json:"top_consumers"`
+}
+
+// boxUsageRow is a per-box aggregate of coffee logs
+type boxUsageRow struct {
+	BoxID           uint
+	Name            string
+	TotalCups       int
+	PriceMinorUnits int64
+	PriceCurrency   string
+	Cups            int64
+}
+
+// AnalyticsService aggregates coffee consumption statistics
+type AnalyticsService struct {
+	db *gorm.DB
+}
+
+// NewAnalyticsService creates a new AnalyticsService
+func NewAnalyticsService(db *gorm.DB) *AnalyticsService {
+	return &AnalyticsService{db: db}
+}
+
+// GetUsage aggregates a team's coffee logs over the period ending at now
+func (s *AnalyticsService) GetUsage(teamID uint, period string, now time.Time) (*UsageAnalytics, error) {
+	if period == "" {
+		period = DefaultAnalyticsPeriod
+	}
+	spec, ok := analyticsPeriods[period]
+	if !ok {
+		return nil, ErrInvalidPeriod
+	}
+
+	usage := &UsageAnalytics{TeamID: teamID, Period: period, From: spec.since(now), To: now}
+	if err := s.loadTotals(usage); err != nil {
+		return nil, err
+	}
+	if err := s.loadTimeline(usage, spec.bucket); err != nil {
+		return nil, err
+	}
+	if err := s.loadBoxUtilization(usage); err != nil {
+		return nil, err
+	}
+	if err := s.loadTopConsumers(usage); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// logsInWindow scopes coffee logs to the team's boxes and the analytics window
+func (s *AnalyticsService) logsInWindow(usage *UsageAnalytics) *gorm.DB {
+	return s.db.Model(&models.CoffeeLog{}).Scopes(models.NotVoided).
+		Where("coffee_logs.box_id IN (?)", teamBoxIDs(s.db, usage.TeamID)).
+		Where("coffee_logs.logged_at >= ? AND coffee_logs.logged_at < ?", usage.From, usage.To)
+}
+
+// teamPayments scopes payments to the team's boxes
+func (s *AnalyticsService) teamPayments(usage *UsageAnalytics) *gorm.DB {
+	return s.db.Model(&models.Payment{}).Where("box_id IN (?)", teamBoxIDs(s.db, usage.TeamID))
+}
+
+// loadTotals fills in the overall counters and revenue
+func (s *AnalyticsService) loadTotals(usage *UsageAnalytics) error {
+	members := s.db.Model(&models.TeamMember{}).Select("user_id").Where("team_id = ?", usage.TeamID)
+	counts := []struct {
+		name  string
+		query *gorm.DB
+		dest  *int64
+	}{
+		{"users", s.db.Model(&models.User{}).Where("is_active = ? AND id IN (?)", true, members), &usage.TotalUsers},
+		{"boxes", s.db.Model(&models.Box{}).Where("team_id = ?", usage.TeamID), &usage.TotalBoxes},
+		{"coffee logs", s.logsInWindow(usage), &usage.TotalCoffeeLogs},
+		{"active users", s.logsInWindow(usage).Distinct("user_id"), &usage.ActiveUsers},
+		{"payments", s.teamPayments(usage).Where("created_at >= ? AND created_at < ?", usage.From, usage.To), &usage.TotalPayments},
+	}
+	for _, c := range counts {
+		if err := c.query.Count(c.dest).Error; err != nil {
+			return fmt.Errorf("failed to count %s: %w", c.name, err)
+		}
+	}
+
+	if usage.ActiveUsers > 0 {
+		usage.AverageCoffeePerUser = float64(usage.TotalCoffeeLogs) / float64(usage.ActiveUsers)
+	}
+
+	usage.TotalRevenue = []models.Money{}
+	err := s.teamPayments(usage).
+		Select("amount_currency AS currency, SUM(amount_minor_units) AS minor_units").
+		Where("is_paid = ? AND paid_at >= ? AND paid_at < ?", true, usage.From, usage.To).
+		Group("amount_currency").Order("amount_currency").
+		Scan(&usage.TotalRevenue).Error
+	if err != nil {
+		return fmt.Errorf("failed to sum revenue: %w", err)
+	}
+	return nil
+}
+
+// loadTimeline groups the period's coffee logs into time buckets
+func (s *AnalyticsService) loadTimeline(usage *UsageAnalytics, bucket string) error {
+	expr, err := s.bucketExpression(bucket)
+	if err != nil {
+		return err
+	}
+
+	usage.Timeline = []UsageBucket{}
+	err = s.logsInWindow(usage).
+		Select(expr + " AS bucket, COUNT(*) AS cups, COUNT(DISTINCT coffee_logs.user_id) AS active_users").
+		Group("bucket").Order("bucket").
+		Scan(&usage.Timeline).Error
+	if err != nil {
+		return fmt.Errorf("failed to load usage timeline: %w", err)
+	}
+	return nil
+}
+
+// bucketExpression returns the SQL that truncates logged_at to a timeline bucket
+func (s *AnalyticsService) bucketExpression(bucket string) (string, error) {
+	dialect := s.db.Dialector.Name()
+	format, ok := bucketFormats[dialect][bucket]
+	if !ok {
+		return "", fmt.Errorf("analytics are not supported on %q", dialect)
+	}
+	if dialect == "sqlite" {
+		return fmt.Sprintf("strftime('%s', coffee_logs.logged_at)", format), nil
+	}
+	return fmt.Sprintf("to_char(coffee_logs.logged_at, '%s')", format), nil
+}
+
+// loadBoxUtilization aggregates the period's cups per box and derives spend from them
+func (s *AnalyticsService) loadBoxUtilization(usage *UsageAnalytics) error {
+	var rows []boxUsageRow
+	err := s.logsInWindow(usage).
+		Select("coffee_logs.box_id, boxes.name, boxes.total_cups, boxes.price_minor_units, boxes.price_currency, COUNT(*) AS cups").
+		Joins("JOIN boxes ON boxes.id = coffee_logs.box_id").
+		Group("coffee_logs.box_id, boxes.name, boxes.total_cups, boxes.price_minor_units, boxes.price_currency").
+		Order("cups DESC, coffee_logs.box_id").
+		Scan(&rows).Error
+	if err != nil {
+		return fmt.Errorf("failed to load box utilization: %w", err)
+	}
+
+	spend := make(map[string]int64)
+	usage.BoxUtilization = make([]BoxUtilization, 0, len(rows))
+	for _, row := range rows {
+		utilization := BoxUtilization{BoxID: row.BoxID, Name: row.Name, Cups: row.Cups, TotalCups: row.TotalCups}
+		price := models.NewMoney(row.PriceMinorUnits, row.PriceCurrency)
+		utilization.Spend = models.NewMoney(0, price.Currency)
+		if row.TotalCups > 0 {
+			utilization.Utilization = float64(row.Cups) / float64(row.TotalCups)
+			utilization.Spend = price.Allocate([]int64{row.Cups, int64(row.TotalCups) - row.Cups})[0]
+		}
+		spend[price.Currency] += utilization.Spend.MinorUnits
+		usage.BoxUtilization = append(usage.BoxUtilization, utilization)
+	}
+
+	usage.Spend = sumByCurrency(spend)
+	return nil
+}
+
+// sumByCurrency turns per-currency totals into amounts ordered by currency
+func sumByCurrency(totals map[string]int64) []models.Money {
+	currencies := make([]string, 0, len(totals))
+	for currency := range totals {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	amounts := make([]models.Money, 0, len(currencies))
+	for _, currency := range currencies {
+		amounts = append(amounts, models.NewMoney(totals[currency], currency))
+	}
+	return amounts
+}
+
+// loadTopConsumers lists the users who drank the most cups in the period
+func (s *AnalyticsService) loadTopConsumers(usage *UsageAnalytics) error {
+	usage.TopConsumers = []Consumer{}
+	err := s.logsInWindow(usage).
+		Select("coffee_logs.user_id, users.first_name AS name, COUNT(*) AS cups").
+		Joins("JOIN users ON users.id = coffee_logs.user_id").
+		Group("coffee_logs.user_id, users.first_name").
+		Order("cups DESC, coffee_logs.user_id").
+		Limit(topConsumersLimit).
+		Scan(&usage.TopConsumers).Error
+	if err != nil {
+		return fmt.Errorf("failed to load top consumers: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"gorm.io/gorm"
+)
+
+// BoxBalance is where a user stands on one box.
+// Cost is the user's share of the cups they took; in prepaid teams Paid is
+// what their wallet was charged for them. Outstanding is what they
+// still owe for it: their pending payments once the box is closed, or their
+// running share minus what they paid while it is open. OwedToUser is what
+// others still owe the user for a box they bought, worked out the same way.
+// Nothing is outstanding or owed on boxes of prepaid teams, whose cups are
+// paid from wallets as they are logged.
+type BoxBalance struct {
+	BoxID       uint         `json:"box_id"`
+	BoxName     string       `json:"box_name"`
+	IsOwner     bool         `json:"is_owner"`
+	IsClosed    bool         `json:"is_closed"`
+	IsPrepaid   bool         `json:"is_prepaid"`
+	Cups        int64        `json:"cups"`
+	Cost        models.Money `json:"cost"`
+	Paid        models.Money `json:"paid"`
+	Outstanding models.Money `json:"outstanding"`
+	OwedToUser  models.Money `json:"owed_to_user"`
+}
+
+// UserBalance sums a user's box balances per currency.
+// Net is OwedToUser minus Outstanding, so it is negative while the user owes money.
+type UserBalance struct {
+	UserID      uint           `json:"user_id"`
+	Boxes       []BoxBalance   `json:"boxes"`
+	Cost        []models.Money `json:"cost"`
+	Paid        []models.Money `json:"paid"`
+	Outstanding []models.Money `json:"outstanding"`
+	OwedToUser  []models.Money `json:"owed_to_user"`
+	Net         []models.Money `json:"net"`
+}
+
+// balanceRow is the aggregated payments of one box
+type balanceRow struct {
+	BoxID           uint
+	Name            string
+	TotalCups       int
+	PriceMinorUnits int64
+	PriceCurrency   string
+	CreatedBy       uint
+	ClosedAt        *time.Time
+	BillingMode     string
+	Charged         int64
+	Paid            int64
+	Pending         int64
+	OthersPaid      int64
+	OthersPending   int64
+}
+
+// BalanceService computes what users owe and are owed across all boxes
+type BalanceService struct {
+	db *gorm.DB
+}
+
+// NewBalanceService creates a new BalanceService
+func NewBalanceService(db *gorm.DB) *BalanceService {
+	return &BalanceService{db: db}
+}
+
+// GetUserBalance computes a user's balance over every box they bought,
+// drank from or have payments for
+func (s *BalanceService) GetUserBalance(userID uint) (*UserBalance, error) {
+	rows, err := s.loadBalanceRows(userID)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.loadCupCounts(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	balance := &UserBalance{UserID: userID, Boxes: make([]BoxBalance, 0, len(rows))}
+	cost, paid := make(map[string]int64), make(map[string]int64)
+	outstanding, owed, net := make(map[string]int64), make(map[string]int64), make(map[string]int64)
+	for _, row := range rows {
+		box := row.boxBalance(userID, counts[row.BoxID])
+		currency := box.Cost.Currency
+		cost[currency] += box.Cost.MinorUnits
+		paid[currency] += box.Paid.MinorUnits
+		outstanding[currency] += box.Outstanding.MinorUnits
+		owed[currency] += box.OwedToUser.MinorUnits
+		net[currency] += box.OwedToUser.MinorUnits - box.Outstanding.MinorUnits
+		balance.Boxes = append(balance.Boxes, box)
+	}
+
+	balance.Cost = sumByCurrency(cost)
+	balance.Paid = sumByCurrency(paid)
+	balance.Outstanding = sumByCurrency(outstanding)
+	balance.OwedToUser = sumByCurrency(owed)
+	balance.Net = sumByCurrency(net)
+	return balance, nil
+}
+
+// loadBalanceRows aggregates payments per box in a single query
+func (s *BalanceService) loadBalanceRows(userID uint) ([]balanceRow, error) {
+	sum := func(userFilter string, scope func(*gorm.DB) *gorm.DB) *gorm.DB {
+		return s.db.Model(&models.Payment{}).Scopes(scope).
+			Select("COALESCE(SUM(amount_minor_units), 0)").
+			Where("payments.box_id = boxes.id AND payments.user_id "+userFilter+" ?", userID)
+	}
+	paid := func(db *gorm.DB) *gorm.DB { return db.Where("is_paid = ?", true) }
+	charged := s.db.Model(&models.WalletEntry{}).
+		Joins("JOIN coffee_logs ON coffee_logs.id = wallet_entries.coffee_log_id").
+		Scopes(models.NotVoided).
+		Select("COALESCE(-SUM(wallet_entries.amount_minor_units), 0)").
+		Where("coffee_logs.box_id = boxes.id AND coffee_logs.user_id = ? AND wallet_entries.kind = ?", userID, models.WalletDeduction)
+
+	involved := s.db.Model(&models.CoffeeLog{}).Scopes(models.NotVoided).Select("box_id").Where("user_id = ?", userID)
+	withPayments := s.db.Model(&models.Payment{}).Select("box_id").Where("user_id = ?", userID)
+
+	var rows []balanceRow
+	err := s.db.Model(&models.Box{}).
+		Select("boxes.id AS box_id, boxes.name, boxes.total_cups, boxes.price_minor_units, boxes.price_currency, "+
+			"boxes.created_by, boxes.closed_at, boxes.billing_mode, (?) AS charged, (?) AS paid, (?) AS pending, "+
+			"(?) AS others_paid, (?) AS others_pending",
+			charged, sum("=", paid), sum("=", OutstandingPayments),
+			sum("<>", paid), sum("<>", OutstandingPayments)).
+		Where("boxes.created_by = ? OR boxes.id IN (?) OR boxes.id IN (?)", userID, involved, withPayments).
+		Order("boxes.id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load balance: %w", err)
+	}
+	return rows, nil
+}
+
+// loadCupCounts counts the cups each user took from the boxes of rows in a
+// single query, grouped by box and ordered by user ID
+func (s *BalanceService) loadCupCounts(rows []balanceRow) (map[uint][]cupCount, error) {
+	boxIDs := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		boxIDs = append(boxIDs, row.BoxID)
+	}
+	if len(boxIDs) == 0 {
+		return nil, nil
+	}
+
+	var counts []struct {
+		BoxID  uint
+		UserID uint
+		Cups   int64
+	}
+	if err := s.db.Model(&models.CoffeeLog{}).Scopes(models.NotVoided).
+		Select("box_id, user_id, COUNT(*) AS cups").
+		Where("box_id IN ?", boxIDs).
+		Group("box_id, user_id").
+		Order("box_id, user_id").
+		Scan(&counts).Error; err != nil {
+		return nil, fmt.Errorf("failed to count coffee logs: %w", err)
+	}
+
+	byBox := make(map[uint][]cupCount, len(boxIDs))
+	for _, c := range counts {
+		byBox[c.BoxID] = append(byBox[c.BoxID], cupCount{UserID: c.UserID, Cups: c.Cups})
+	}
+	return byBox, nil
+}
+
+// boxBalance works out the user's position on the box from its aggregates
+// and cup counts. The price is split as when the box is closed, so Cost
+// matches the payment closing creates.
+func (r balanceRow) boxBalance(userID uint, counts []cupCount) BoxBalance {
+	price := models.NewMoney(r.PriceMinorUnits, r.PriceCurrency)
+	shares := splitBoxPrice(price, r.TotalCups, counts)
+	cost, others := models.NewMoney(0, price.Currency), models.NewMoney(0, price.Currency)
+	var cups int64
+	for _, c := range counts {
+		if c.UserID == userID {
+			cost, cups = shares[c.UserID], c.Cups
+		} else {
+			others = others.Add(shares[c.UserID])
+		}
+	}
+
+	box := BoxBalance{
+		BoxID:      r.BoxID,
+		BoxName:    r.Name,
+		IsOwner:    r.CreatedBy == userID,
+		IsClosed:   r.ClosedAt != nil,
+		IsPrepaid:  r.BillingMode == models.BillingPrepaid,
+		Cups:       cups,
+		Cost:       cost,
+		Paid:       models.NewMoney(r.Paid, price.Currency),
+		OwedToUser: models.NewMoney(0, price.Currency),
+	}
+
+	switch {
+	case box.IsPrepaid:
+		// Every cup was deducted from the user's wallet when it was logged
+		box.Paid = models.NewMoney(r.Charged, price.Currency)
+		box.Outstanding = models.NewMoney(0, price.Currency)
+	case box.IsOwner && box.IsClosed:
+		box.Outstanding = models.NewMoney(0, price.Currency)
+		box.OwedToUser = models.NewMoney(r.OthersPending, price.Currency)
+	case box.IsOwner:
+		box.Outstanding = models.NewMoney(0, price.Currency)
+		box.OwedToUser = others.Sub(models.NewMoney(r.OthersPaid, price.Currency))
+	case box.IsClosed:
+		box.Outstanding = models.NewMoney(r.Pending, price.Currency)
+	default:
+		box.Outstanding = box.Cost.Sub(box.Paid)
+	}
+	return box
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BoxSettlement is the outcome of closing a box
+type BoxSettlement struct {
+	Box      models.Box       `json:"box"`
+	Payments []models.Payment `json:"payments"`
+}
+
+// CloseBox settles a finished box: it is frozen and one payment per
+// consumer is created, owed to the box purchaser, for their share of the price
+// less what they already paid or owe for the box. Closing an already closed
+// box returns the existing settlement unchanged.
+func (s *BoxService) CloseBox(id uint) (*BoxSettlement, error) {
+	var box models.Box
+	closed := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the box so concurrent closes and coffee logs are serialized
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&box, id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrBoxNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load box: %w", err)
+		}
+		if box.IsClosed() {
+			return nil
+		}
+		if !canMoveBox(box.Status, models.BoxSettled) {
+			return ErrInvalidBoxTransition.WithMessage(fmt.Sprintf("this box is %s, finish it before closing it", box.Status))
+		}
+
+		now := time.Now()
+		if err := tx.Model(&box).Updates(map[string]interface{}{
+			"status":    models.BoxSettled,
+			"is_active": false,
+			"closed_at": &now,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to close box: %w", err)
+		}
+
+		closed = true
+		prior, err := boxPaymentsByUser(tx, box.ID)
+		if err != nil {
+			return err
+		}
+		if err := createSettlementPayments(tx, &box, prior); err != nil {
+			return err
+		}
+		return postBoxClose(tx, &box, prior)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var payments []models.Payment
+	if err := s.db.Where("box_id = ?", id).Preload("User").Order("user_id").Find(&payments).Error; err != nil {
+		return nil, fmt.Errorf("failed to load box payments: %w", err)
+	}
+
+	settlement := &BoxSettlement{Box: box, Payments: payments}
+	if closed {
+		s.events.Publish(BoxClosedEvent{Settlement: settlement})
+	}
+	return settlement, nil
+}
+
+// createSettlementPayments creates a payment for what is left of every
+// consumer's share after the payments they already had for the box, prior.
+// The purchaser's own share is skipped since nobody owes it to them, and
+// cups nobody logged stay with the purchaser as in CalculateUserDebt.
+// Consumers in prepaid teams already paid for every cup from their wallets.
+func createSettlementPayments(tx *gorm.DB, box *models.Box, prior map[uint]models.Money) error {
+	if box.IsPrepaid() {
+		return nil
+	}
+
+	shares, err := calculateBoxShares(tx, box)
+	if err != nil {
+		return err
+	}
+
+	userIDs := make([]uint, 0, len(shares))
+	for userID := range shares {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Slice(userIDs, func(i, j int) bool { return userIDs[i] < userIDs[j] })
+
+	for _, userID := range userIDs {
+		left := shares[userID].Sub(priorShare(prior, userID, shares[userID]))
+		if userID == box.CreatedBy || left.IsZero() {
+			continue
+		}
+		payment := models.Payment{UserID: userID, BoxID: box.ID, Amount: left}
+		if err := tx.Create(&payment).Error; err != nil {
+			return fmt.Errorf("failed to create payment: %w", err)
+		}
+	}
+	return nil
+}
+
+// boxPaymentsByUser sums the payments each user has for a box that were not cancelled
+func boxPaymentsByUser(tx *gorm.DB, boxID uint) (map[uint]models.Money, error) {
+	var sums []struct {
+		UserID     uint
+		MinorUnits int64
+		Currency   string
+	}
+	if err := tx.Model(&models.Payment{}).
+		Select("user_id, SUM(amount_minor_units) AS minor_units, amount_currency AS currency").
+		Where("box_id = ? AND cancelled_at IS NULL", boxID).
+		Group("user_id, amount_currency").
+		Scan(&sums).Error; err != nil {
+		return nil, fmt.Errorf("failed to sum box payments: %w", err)
+	}
+	prior := make(map[uint]models.Money, len(sums))
+	for _, sum := range sums {
+		prior[sum.UserID] = models.NewMoney(sum.MinorUnits, sum.Currency)
+	}
+	return prior, nil
+}
+
+// priorShare is the part of a user's share covered by their earlier
+// payments; paying more than the share doesn't make the purchaser owe them
+func priorShare(prior map[uint]models.Money, userID uint, share models.Money) models.Money {
+	paid, ok := prior[userID]
+	if !ok || paid.Currency != share.Currency {
+		return models.NewMoney(0, share.Currency)
+	}
+	return models.NewMoney(min(paid.MinorUnits, share.MinorUnits), share.Currency)
+}
@@ -0,0 +1,271 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidBoxTransition is returned when a box can't move to the requested
+// status from the one it is in; the message names both
+var ErrInvalidBoxTransition = newError(KindConflict, "INVALID_BOX_TRANSITION", "the box can't change to that status")
+
+// ErrBoxSettled is returned when changing a box that has already been settled
+var ErrBoxSettled = newError(KindConflict, "BOX_SETTLED", "a settled box can't be changed")
+
+// ErrBoxInUse is returned when deleting a box that cups or payments refer to
+var ErrBoxInUse = newError(KindConflict, "BOX_IN_USE", "a box with cups or payments can't be deleted, finish and close it instead")
+
+// ErrBoxPriceLocked is returned when changing the cost per cup of a prepaid
+// team's box after cups were paid from wallets at the old cost
+var ErrBoxPriceLocked = newError(KindConflict, "BOX_PRICE_LOCKED", "the cost per cup can't change after cups were paid from prepaid wallets")
+
+// boxTransitions lists, for every status a box can be moved to, the statuses
+// it can be moved from. Settling is done by CloseBox, which creates the payments.
+var boxTransitions = map[string][]string{
+	models.BoxFinished: {models.BoxOpen},
+	models.BoxSettled:  {models.BoxFinished},
+	models.BoxArchived: {models.BoxSettled},
+}
+
+// canMoveBox reports whether the lifecycle allows a box to move from one status to another
+func canMoveBox(from, to string) bool {
+	for _, allowed := range boxTransitions[to] {
+		if allowed == from {
+			return true
+		}
+	}
+	return false
+}
+
+// reopenedStatus is the status a box returns to when reopened
+var reopenedStatus = map[string]string{
+	models.BoxFinished: models.BoxOpen,
+	models.BoxArchived: models.BoxSettled,
+}
+
+// BoxChanges are corrections to a box; nil fields are left as they are
+type BoxChanges struct {
+	Name              *string
+	TotalCups         *int
+	Price             *models.Money
+	LowStockThreshold *int
+}
+
+// FinishBox marks an open box as used up, so no more cups can be logged from it
+func (s *BoxService) FinishBox(id uint) (*models.Box, error) {
+	return s.moveBox(id, models.BoxFinished)
+}
+
+// ArchiveBox puts a settled box away
+func (s *BoxService) ArchiveBox(id uint) (*models.Box, error) {
+	return s.moveBox(id, models.BoxArchived)
+}
+
+// ReopenBox undoes finishing or archiving a box: a finished box is open
+// again and an archived box is back to settled. Settled boxes can't be
+// reopened since their payments have been created.
+func (s *BoxService) ReopenBox(id uint) (*models.Box, error) {
+	var box models.Box
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockBox(tx, &box, id); err != nil {
+			return err
+		}
+		status, ok := reopenedStatus[box.Status]
+		if !ok {
+			return ErrInvalidBoxTransition.WithMessage(fmt.Sprintf("this box is %s and can't be reopened", box.Status))
+		}
+		return setBoxStatus(tx, &box, status)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &box, nil
+}
+
+// moveBox moves a box to status if the lifecycle allows it from its current status
+func (s *BoxService) moveBox(id uint, status string) (*models.Box, error) {
+	var box models.Box
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockBox(tx, &box, id); err != nil {
+			return err
+		}
+		if canMoveBox(box.Status, status) {
+			return setBoxStatus(tx, &box, status)
+		}
+		return ErrInvalidBoxTransition.WithMessage(fmt.Sprintf("this box is %s and can't be %s", box.Status, status))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &box, nil
+}
+
+// setBoxStatus saves a box's new status; only open boxes stay active
+func setBoxStatus(tx *gorm.DB, box *models.Box, status string) error {
+	box.Status = status
+	box.IsActive = status == models.BoxOpen
+	if err := tx.Model(box).Updates(map[string]interface{}{"status": box.Status, "is_active": box.IsActive}).Error; err != nil {
+		return fmt.Errorf("failed to change box status: %w", err)
+	}
+	return nil
+}
+
+// lockBox loads a box and locks its row for the rest of the transaction
+func lockBox(tx *gorm.DB, box *models.Box, id uint) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(box, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrBoxNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load box: %w", err)
+	}
+	return nil
+}
+
+// UpdateBox corrects the name, size, price or low-stock threshold of a box
+// that has not been settled. The box can't shrink below the cups already
+// taken, and its currency can't change. Money already posted for the box
+// is revalued at the new cost per cup.
+func (s *BoxService) UpdateBox(id uint, changes BoxChanges) (*models.Box, error) {
+	var box models.Box
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockBox(tx, &box, id); err != nil {
+			return err
+		}
+		if box.IsClosed() {
+			return ErrBoxSettled
+		}
+		before := box
+		changes.apply(&box)
+
+		used, err := box.GetUsedCups(tx)
+		if err != nil {
+			return fmt.Errorf("failed to count used cups: %w", err)
+		}
+		if err := Validate(changes.boxRules(&before, &box, used)...); err != nil {
+			return err
+		}
+		if err := checkCupCostChange(&before, &box, used); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&box).Updates(map[string]interface{}{
+			"name":                box.Name,
+			"total_cups":          box.TotalCups,
+			"price_minor_units":   box.Price.MinorUnits,
+			"price_currency":      box.Price.Currency,
+			"low_stock_threshold": box.LowStockThreshold,
+			"low_stock_alerted":   box.LowStockAlerted,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to update box: %w", err)
+		}
+		return postBoxEdit(tx, &before, &box)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &box, nil
+}
+
+// Rules are the rules for the fields being changed that don't depend on the box
+func (c BoxChanges) Rules() []Rule {
+	var rules []Rule
+	if c.Name != nil {
+		rules = append(rules, Required("name", *c.Name), MaxLength("name", *c.Name, MaxNameLength))
+	}
+	if c.TotalCups != nil {
+		rules = append(rules, Between("total_cups", *c.TotalCups, 1, MaxBoxCups))
+	}
+	if c.Price != nil {
+		rules = append(rules, NonNegativeAmount("price", *c.Price))
+	}
+	if c.LowStockThreshold != nil {
+		rules = append(rules, Check("low_stock_threshold", *c.LowStockThreshold >= 0, "must not be negative"))
+	}
+	return rules
+}
+
+// apply copies the set fields onto box. A threshold that no longer fits a
+// shrunk box is lowered to one cup below its size.
+func (c BoxChanges) apply(box *models.Box) {
+	if c.Name != nil {
+		box.Name = strings.TrimSpace(*c.Name)
+	}
+	if c.TotalCups != nil {
+		box.TotalCups = *c.TotalCups
+	}
+	if c.Price != nil {
+		box.Price = models.NewMoney(c.Price.MinorUnits, c.Price.Currency)
+	}
+	threshold := box.LowStockThreshold
+	if c.LowStockThreshold != nil {
+		threshold = *c.LowStockThreshold
+	} else if threshold >= box.TotalCups {
+		threshold = max(box.TotalCups-1, 0)
+	}
+	if threshold != box.LowStockThreshold {
+		// A new threshold is a new crossing to warn about
+		box.LowStockThreshold = threshold
+		box.LowStockAlerted = false
+	}
+}
+
+// boxRules are the rules for a corrected box, given the box before the
+// change and the number of cups already taken from it. The threshold is
+// only checked when it is being changed.
+func (c BoxChanges) boxRules(before, after *models.Box, used int) []Rule {
+	rules := append(BoxRules(after.Name, after.TotalCups, after.Price),
+		Check("total_cups", after.TotalCups >= used, fmt.Sprintf("must be at least %d, the number of cups already taken", used)),
+		Check("price.currency", after.Price.Currency == before.Price.Currency, "must stay "+before.Price.Currency),
+	)
+	if c.LowStockThreshold != nil {
+		rules = append(rules, LowStockRule(after.LowStockThreshold, after.TotalCups))
+	}
+	return rules
+}
+
+// checkCupCostChange refuses a new cost per cup for a prepaid box once cups
+// were taken, since their wallets were charged at the old cost
+func checkCupCostChange(before, after *models.Box, used int) error {
+	if used > 0 && after.IsPrepaid() && before.GetCostPerCup() != after.GetCostPerCup() {
+		return ErrBoxPriceLocked
+	}
+	return nil
+}
+
+// DeleteBox removes a box that was created by mistake. Only boxes that no
+// cups were taken from and no payments refer to can be deleted; the
+// purchase is taken back out of the ledger.
+func (s *BoxService) DeleteBox(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var box models.Box
+		if err := lockBox(tx, &box, id); err != nil {
+			return err
+		}
+		if box.IsClosed() {
+			return ErrBoxSettled
+		}
+
+		used, err := box.GetUsedCups(tx)
+		if err != nil {
+			return fmt.Errorf("failed to count used cups: %w", err)
+		}
+		var payments int64
+		if err := tx.Model(&models.Payment{}).Where("box_id = ?", box.ID).Count(&payments).Error; err != nil {
+			return fmt.Errorf("failed to count box payments: %w", err)
+		}
+		if used > 0 || payments > 0 {
+			return ErrBoxInUse
+		}
+
+		if err := tx.Delete(&box).Error; err != nil {
+			return fmt.Errorf("failed to delete box: %w", err)
+		}
+		return postBoxDelete(tx, &box)
+	})
+}
@@ -1,15 +1,32 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/your-username/coffee-cups-system/internal/models"
 	"gorm.io/gorm"
 )
 
+// ErrInvalidLowStockThreshold is returned for a negative low-stock threshold
+// or one that is not below the box's size
+var ErrInvalidLowStockThreshold = newError(KindValidation, "INVALID_LOW_STOCK_THRESHOLD", "low-stock threshold must be between 0 and the number of cups in the box")
+
+// ErrBoxNotFound is returned when a box does not exist
+var ErrBoxNotFound = newError(KindNotFound, "BOX_NOT_FOUND", "box not found")
+
+// ErrBoxInactive is returned when logging coffee from a box that is closed or out of use
+var ErrBoxInactive = newError(KindConflict, "BOX_INACTIVE", "this box is closed or no longer in use")
+
+// ErrBoxExhausted is returned when logging coffee from a box with no cups left
+var ErrBoxExhausted = newError(KindConflict, "BOX_EXHAUSTED", "there are no cups left in this box")
+
 // BoxService handles box-related operations
 type BoxService struct {
-	db *gorm.DB
+	db     *gorm.DB
+	events *Events
 }
 
 // NewBoxService creates a new BoxService
@@ -17,41 +34,212 @@ func NewBoxService(db *gorm.DB) *BoxService {
 	return &BoxService{db: db}
 }
 
-// CreateBox creates a new coffee box
-func (s *BoxService) CreateBox(name string, totalCups int, price float64, createdBy uint) (*models.Box, error) {
+// BoxRules are the rules for the fields of a new box
+func BoxRules(name string, totalCups int, price models.Money) []Rule {
+	return []Rule{
+		Required("name", name),
+		MaxLength("name", name, MaxNameLength),
+		Between("total_cups", totalCups, 1, MaxBoxCups),
+		NonNegativeAmount("price", price),
+	}
+}
+
+// LowStockRule is the rule for a box's low-stock threshold
+func LowStockRule(threshold, totalCups int) Rule {
+	return Check("low_stock_threshold", threshold >= 0 && threshold < totalCups, "must be at least 0 and below total_cups")
+}
+
+// NewBox describes a box to create. LowStockThreshold is optional and
+// defaults to models.DefaultLowStockThreshold, or one cup fewer than the box
+// holds if it is smaller. ReceiptFileID is the Telegram file ID of a photo
+// of the receipt, if one was sent.
+type NewBox struct {
+	Name              string
+	TotalCups         int
+	Price             models.Money
+	CreatedBy         uint
+	TeamID            uint
+	LowStockThreshold *int
+	ReceiptFileID     string
+}
+
+// CreateBox creates a new coffee box in a team
+func (s *BoxService) CreateBox(name string, totalCups int, price models.Money, createdBy, teamID uint) (*models.Box, error) {
+	return s.AddBox(NewBox{Name: name, TotalCups: totalCups, Price: price, CreatedBy: createdBy, TeamID: teamID})
+}
+
+// AddBox validates and creates the described box and posts its purchase in one transaction
+func (s *BoxService) AddBox(spec NewBox) (*models.Box, error) {
+	name := strings.TrimSpace(spec.Name)
+	rules := BoxRules(name, spec.TotalCups, spec.Price)
+	threshold := min(models.DefaultLowStockThreshold, spec.TotalCups-1)
+	if spec.LowStockThreshold != nil {
+		threshold = *spec.LowStockThreshold
+		rules = append(rules, LowStockRule(threshold, spec.TotalCups))
+	}
+	if err := Validate(rules...); err != nil {
+		return nil, err
+	}
+
 	box := models.Box{
+		TeamID:    spec.TeamID,
 		Name:      name,
-		TotalCups: totalCups,
-		Price:     price,
-		CreatedBy: createdBy,
+		TotalCups: spec.TotalCups,
+		Price:     models.NewMoney(spec.Price.MinorUnits, spec.Price.Currency),
+		CreatedBy: spec.CreatedBy,
+		Status:    models.BoxOpen,
 		IsActive:  true,
+
+		LowStockThreshold: threshold,
+		ReceiptFileID:     spec.ReceiptFileID,
 	}
 
-	if err := s.db.Create(&box).Error; err != nil {
-		return nil, fmt.Errorf("failed to create box: %w", err)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := requireRecord(tx, &models.User{}, "created_by", spec.CreatedBy); err != nil {
+			return err
+		}
+		if err := requireRecord(tx, &models.Team{}, "team_id", spec.TeamID); err != nil {
+			return err
+		}
+		mode, err := teamBillingMode(tx, spec.TeamID)
+		if err != nil {
+			return err
+		}
+		box.BillingMode = mode
+		if err := tx.Create(&box).Error; err != nil {
+			return fmt.Errorf("failed to create box: %w", err)
+		}
+		return postPurchase(tx, &box)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return &box, nil
 }
 
-// GetActiveBoxes retrieves all active boxes
-func (s *BoxService) GetActiveBoxes() ([]models.Box, error) {
+// GetActiveUserBoxes retrieves the active boxes of every team the user belongs to
+func (s *BoxService) GetActiveUserBoxes(userID uint) ([]models.Box, error) {
 	var boxes []models.Box
-	err := s.db.Where("is_active = ?", true).Preload("Creator").Find(&boxes).Error
+	err := s.db.Where("is_active = ? AND team_id IN (?)", true, memberTeamIDs(s.db, userID)).
+		Preload("Creator").Order("id").Find(&boxes).Error
 	return boxes, err
 }
 
+// GetActiveTeamBoxes retrieves the active boxes of a team
+func (s *BoxService) GetActiveTeamBoxes(teamID uint) ([]models.Box, error) {
+	var boxes []models.Box
+	err := s.db.Where("is_active = ? AND team_id = ?", true, teamID).Preload("Creator").Order("id").Find(&boxes).Error
+	return boxes, err
+}
+
+// BoxFilter narrows down the boxes returned by ListBoxes. Status selects
+// boxes in one lifecycle status; without it Active selects active or
+// inactive boxes and defaults to active. From and To bound when the box was created.
+type BoxFilter struct {
+	TeamID    uint
+	CreatedBy uint
+	Status    string
+	Active    *bool
+	From      *time.Time
+	To        *time.Time
+	Page
+}
+
+// boxListing sorts boxes by ID, name, creation time or size
+var boxListing = listing[models.Box]{
+	table: "boxes",
+	keys: map[string]sortKey[models.Box]{
+		"id":         {"boxes.id", func(b *models.Box) interface{} { return b.ID }},
+		"name":       {"boxes.name", func(b *models.Box) interface{} { return b.Name }},
+		"created_at": {"boxes.created_at", func(b *models.Box) interface{} { return b.CreatedAt }},
+		"total_cups": {"boxes.total_cups", func(b *models.Box) interface{} { return b.TotalCups }},
+	},
+	defaultSort: "id",
+	id:          func(b *models.Box) uint { return b.ID },
+}
+
+// ListBoxes retrieves one page of the boxes matching the filter and the cursor of the next page
+func (s *BoxService) ListBoxes(filter BoxFilter) ([]models.Box, string, error) {
+	if err := Validate(
+		OptionalOneOf("status", filter.Status, models.BoxOpen, models.BoxFinished, models.BoxSettled, models.BoxArchived),
+		DateRangeRule(filter.From, filter.To),
+	); err != nil {
+		return nil, "", err
+	}
+
+	query := s.db.Scopes(dateRange("boxes.created_at", filter.From, filter.To))
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	} else {
+		query = query.Where("is_active = ?", filter.Active == nil || *filter.Active)
+	}
+	if filter.TeamID != 0 {
+		query = query.Where("team_id = ?", filter.TeamID)
+	}
+	if filter.CreatedBy != 0 {
+		query = query.Where("created_by = ?", filter.CreatedBy)
+	}
+	return boxListing.find(query.Preload("Creator"), filter.Page)
+}
+
+// AssignTeam moves a box to a team, taking on its billing mode. A box that
+// has been used can't move to a team with another billing mode.
+func (s *BoxService) AssignTeam(boxID, teamID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var box models.Box
+		if err := lockBox(tx, &box, boxID); err != nil {
+			return err
+		}
+		mode, err := teamBillingMode(tx, teamID)
+		if err != nil {
+			return err
+		}
+		used, err := box.GetUsedCups(tx)
+		if err != nil {
+			return fmt.Errorf("failed to count used cups: %w", err)
+		}
+		if mode != box.BillingMode && (used > 0 || box.IsClosed()) {
+			return ErrBillingModeInUse
+		}
+
+		if err := tx.Model(&box).Updates(map[string]interface{}{"team_id": teamID, "billing_mode": mode}).Error; err != nil {
+			return fmt.Errorf("failed to assign box to team: %w", err)
+		}
+		return nil
+	})
+}
+
+// SetLowStockThreshold sets how many remaining cups trigger the low-stock warning
+func (s *BoxService) SetLowStockThreshold(boxID uint, threshold int) (*models.Box, error) {
+	box, err := s.GetBoxByID(boxID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateAs(ErrInvalidLowStockThreshold, LowStockRule(threshold, box.TotalCups)); err != nil {
+		return nil, err
+	}
+
+	alerted := box.LowStockAlerted && threshold == box.LowStockThreshold
+	if err := s.db.Model(box).Updates(map[string]interface{}{
+		"low_stock_threshold": threshold,
+		"low_stock_alerted":   alerted,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to set low-stock threshold: %w", err)
+	}
+	box.LowStockThreshold, box.LowStockAlerted = threshold, alerted
+	return box, nil
+}
+
 // GetBoxByID retrieves a box by ID
 func (s *BoxService) GetBoxByID(id uint) (*models.Box, error) {
 	var box models.Box
 	err := s.db.Preload("Creator").First(&box, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrBoxNotFound
+	}
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to load box: %w", err)
 	}
 	return &box, nil
 }
-
-// DeactivateBox deactivates a box
-func (s *BoxService) DeactivateBox(id uint) error {
-	return s.db.Model(&models.Box{}).Where("id = ?", id).Update("is_active", false).Error
-}
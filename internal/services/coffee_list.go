@@ -0,0 +1,49 @@
+package services
+
+import (
+	"time"
+
+	"github.com/your-username/coffee-cups-system/internal/models"
+)
+
+// CoffeeLogFilter narrows down the coffee logs returned by ListCoffeeLogs.
+// Zero values mean "no filter"; From and To bound when the cup was logged.
+type CoffeeLogFilter struct {
+	TeamID uint
+	UserID uint
+	BoxID  uint
+	From   *time.Time
+	To     *time.Time
+	Page
+}
+
+// coffeeLogListing sorts coffee logs by when they were logged or by ID
+var coffeeLogListing = listing[models.CoffeeLog]{
+	table: "coffee_logs",
+	keys: map[string]sortKey[models.CoffeeLog]{
+		"id":        {"coffee_logs.id", func(l *models.CoffeeLog) interface{} { return l.ID }},
+		"logged_at": {"coffee_logs.logged_at", func(l *models.CoffeeLog) interface{} { return l.LoggedAt }},
+	},
+	defaultSort: "-logged_at",
+	id:          func(l *models.CoffeeLog) uint { return l.ID },
+}
+
+// ListCoffeeLogs retrieves one page of the coffee logs matching the filter,
+// most recent first unless the page asks for another order, and the cursor of the next page
+func (s *CoffeeService) ListCoffeeLogs(filter CoffeeLogFilter) ([]models.CoffeeLog, string, error) {
+	if err := Validate(DateRangeRule(filter.From, filter.To)); err != nil {
+		return nil, "", err
+	}
+
+	query := s.db.Scopes(models.NotVoided, dateRange("coffee_logs.logged_at", filter.From, filter.To))
+	if filter.TeamID != 0 {
+		query = query.Where("box_id IN (?)", teamBoxIDs(s.db, filter.TeamID))
+	}
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.BoxID != 0 {
+		query = query.Where("box_id = ?", filter.BoxID)
+	}
+	return coffeeLogListing.find(query.Preload("User").Preload("Box"), filter.Page)
+}
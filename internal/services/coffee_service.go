@@ -1,16 +1,35 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/your-username/coffee-cups-system/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrCoffeeLogNotFound is returned when a coffee log does not exist
+var ErrCoffeeLogNotFound = newError(KindNotFound, "COFFEE_LOG_NOT_FOUND", "coffee log not found")
+
+// ErrCoffeeLogVoided is returned when voiding a log that is already voided
+var ErrCoffeeLogVoided = newError(KindConflict, "COFFEE_LOG_VOIDED", "coffee log is already voided")
+
+// ErrCoffeeLogSettled is returned when voiding a log from a box that has been closed
+var ErrCoffeeLogSettled = newError(KindConflict, "COFFEE_LOG_SETTLED", "coffee log belongs to a closed box")
+
+// ErrNothingToUndo is returned when the user has no recent log to undo
+var ErrNothingToUndo = newError(KindNotFound, "NOTHING_TO_UNDO", "no coffee log to undo")
+
+// UndoReason is the void reason recorded by UndoLastCoffee
+const UndoReason = "undone by user"
+
 // CoffeeService handles coffee-related operations
 type CoffeeService struct {
-	db *gorm.DB
+	db     *gorm.DB
+	events *Events
 }
 
 // NewCoffeeService creates a new CoffeeService
@@ -23,41 +42,200 @@ func (s *CoffeeService) GetDB() *gorm.DB {
 	return s.db
 }
 
-// LogCoffee logs a coffee consumption
+// LogCoffee logs a coffee consumption.
+// Only members of the box's team can log coffee from it. In a prepaid team
+// the cup is deducted from the user's wallet, and refused with
+// ErrInsufficientFunds when the wallet does not cover it.
+// Taking the cup that reaches the low-stock threshold or empties the box
+// publishes a BoxLowStockEvent or BoxEmptyEvent. The low-stock event is
+// published once per threshold, even if cups are voided and taken again.
 func (s *CoffeeService) LogCoffee(userID, boxID uint) (*models.CoffeeLog, error) {
-	// Check if the box exists and is active
+	var coffeeLog models.CoffeeLog
 	var box models.Box
-	if err := s.db.Where("id = ? AND is_active = ?", boxID, true).First(&box).Error; err != nil {
-		return nil, fmt.Errorf("box not found or inactive: %w", err)
+	var remaining int
+	var lowStock bool
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if remaining, err = lockBoxForCup(tx, &box, boxID, userID); err != nil {
+			return err
+		}
+
+		// Create coffee log
+		coffeeLog = models.CoffeeLog{
+			UserID:   userID,
+			BoxID:    boxID,
+			LoggedAt: time.Now(),
+		}
+		if err := tx.Create(&coffeeLog).Error; err != nil {
+			return fmt.Errorf("failed to log coffee: %w", err)
+		}
+		if err := chargeCup(tx, &box, &coffeeLog); err != nil {
+			return err
+		}
+		if err := postCup(tx, &box, &coffeeLog); err != nil {
+			return err
+		}
+
+		remaining--
+		lowStock = remaining > 0 && remaining == box.LowStockThreshold && !box.LowStockAlerted
+		if lowStock {
+			if err := tx.Model(&box).Update("low_stock_alerted", true).Error; err != nil {
+				return fmt.Errorf("failed to record low-stock alert: %w", err)
+			}
+		}
+		if remaining == 0 || lowStock {
+			// The owner is who the stock notification goes to
+			if err := tx.First(&box.Creator, box.CreatedBy).Error; err != nil {
+				return fmt.Errorf("failed to load box owner: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishStock(&box, remaining, lowStock)
+	return &coffeeLog, nil
+}
+
+// lockBoxForCup loads an active box for a team member and returns how many
+// cups are left in it. The box row is locked for the rest of the transaction
+// so that concurrent logs against the same box cannot exceed its capacity.
+func lockBoxForCup(tx *gorm.DB, box *models.Box, boxID, userID uint) (int, error) {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(box, boxID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, ErrBoxNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to load box: %w", err)
+	}
+	if !box.IsActive {
+		return 0, ErrBoxInactive
 	}
 
-	// Check if there are remaining cups
-	remaining, err := box.GetRemainingCups(s.db)
+	member, err := isTeamMember(tx, box.TeamID, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get remaining cups: %w", err)
+		return 0, err
+	}
+	if !member {
+		return 0, ErrNotTeamMember
+	}
+
+	remaining, err := box.GetRemainingCups(tx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get remaining cups: %w", err)
 	}
 	if remaining <= 0 {
-		return nil, fmt.Errorf("no remaining cups in this box")
+		return 0, ErrBoxExhausted
+	}
+	return remaining, nil
+}
+
+// publishStock announces that a box is running out or empty after a cup was taken
+func (s *CoffeeService) publishStock(box *models.Box, remaining int, lowStock bool) {
+	switch {
+	case remaining == 0:
+		s.events.Publish(BoxEmptyEvent{Box: *box})
+	case lowStock:
+		s.events.Publish(BoxLowStockEvent{Box: *box, Remaining: remaining})
+	}
+}
+
+// VoidRules are the rules for voiding a coffee log
+func VoidRules(reason string) []Rule {
+	return []Rule{Required("reason", reason), MaxLength("reason", reason, MaxNoteLength)}
+}
+
+// VoidCoffeeLog marks a coffee log as voided by actorID.
+// The log is kept for the audit trail but no longer counts as consumption.
+func (s *CoffeeService) VoidCoffeeLog(logID, actorID uint, reason string) (*models.CoffeeLog, error) {
+	reason = strings.TrimSpace(reason)
+	if err := Validate(VoidRules(reason)...); err != nil {
+		return nil, err
 	}
 
-	// Create coffee log
-	coffeeLog := models.CoffeeLog{
-		UserID:   userID,
-		BoxID:    boxID,
-		LoggedAt: time.Now(),
+	var coffeeLog models.CoffeeLog
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Box").First(&coffeeLog, logID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCoffeeLogNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load coffee log: %w", err)
+		}
+		return voidCoffeeLog(tx, &coffeeLog, actorID, reason)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.db.Create(&coffeeLog).Error; err != nil {
-		return nil, fmt.Errorf("failed to log coffee: %w", err)
+	return &coffeeLog, nil
+}
+
+// UndoLastCoffee voids the user's most recent coffee log if it was logged
+// within window. Only the latest log can be undone: once it is voided or too
+// old there is nothing to undo, even if older logs are still counted.
+func (s *CoffeeService) UndoLastCoffee(userID uint, window time.Duration) (*models.CoffeeLog, error) {
+	var coffeeLog models.CoffeeLog
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Box").
+			Where("user_id = ?", userID).
+			Order("logged_at DESC, id DESC").
+			First(&coffeeLog).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNothingToUndo
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load coffee log: %w", err)
+		}
+		if coffeeLog.IsVoided() || coffeeLog.LoggedAt.Before(time.Now().Add(-window)) {
+			return ErrNothingToUndo
+		}
+		return voidCoffeeLog(tx, &coffeeLog, userID, UndoReason)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return &coffeeLog, nil
 }
 
+// voidCoffeeLog records the void on a loaded log, refunds any wallet
+// deduction and reverses the cup in the ledger
+func voidCoffeeLog(tx *gorm.DB, coffeeLog *models.CoffeeLog, actorID uint, reason string) error {
+	if coffeeLog.IsVoided() {
+		return ErrCoffeeLogVoided
+	}
+	if coffeeLog.Box.IsClosed() {
+		return ErrCoffeeLogSettled
+	}
+
+	now := time.Now()
+	coffeeLog.VoidedAt = &now
+	coffeeLog.VoidedBy = &actorID
+	coffeeLog.VoidReason = reason
+	err := tx.Model(coffeeLog).Updates(map[string]interface{}{
+		"voided_at":   coffeeLog.VoidedAt,
+		"voided_by":   actorID,
+		"void_reason": reason,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to void coffee log: %w", err)
+	}
+	if err := refundCup(tx, coffeeLog, actorID); err != nil {
+		return err
+	}
+	return postVoid(tx, coffeeLog)
+}
+
 // GetUserCoffeeLogs retrieves coffee logs for a user
 func (s *CoffeeService) GetUserCoffeeLogs(userID uint, limit int) ([]models.CoffeeLog, error) {
 	var logs []models.CoffeeLog
-	query := s.db.Where("user_id = ?", userID).Order("logged_at DESC")
+	query := s.db.Scopes(models.NotVoided).Where("user_id = ?", userID).Order("logged_at DESC")
 
 	if limit > 0 {
 		query = query.Limit(limit)
@@ -80,20 +258,19 @@ func (s *CoffeeService) GetBoxStats(boxID uint) (*BoxStats, error) {
 	}
 
 	remaining := box.TotalCups - used
-	costPerCup := box.Price / float64(box.TotalCups)
 
 	return &BoxStats{
 		Box:           box,
 		UsedCups:      used,
 		RemainingCups: remaining,
-		CostPerCup:    costPerCup,
+		CostPerCup:    box.GetCostPerCup(),
 	}, nil
 }
 
 // BoxStats represents statistics for a box
 type BoxStats struct {
-	Box           models.Box `json:"box"`
-	UsedCups      int        `json:"used_cups"`
-	RemainingCups int        `json:"remaining_cups"`
-	CostPerCup    float64    `json:"cost_per_cup"`
+	Box           models.Box   `json:"box"`
+	UsedCups      int          `json:"used_cups"`
+	RemainingCups int          `json:"remaining_cups"`
+	CostPerCup    models.Money `json:"cost_per_cup"`
 }
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ConversationService stores the state of multi-step bot commands, one per
+// chat. Keeping it in the database lets any bot replica take the next answer.
+type ConversationService struct {
+	db *gorm.DB
+}
+
+// NewConversationService creates a new ConversationService
+func NewConversationService(db *gorm.DB) *ConversationService {
+	return &ConversationService{db: db}
+}
+
+// GetConversation returns the chat's conversation, or nil if there is none
+func (s *ConversationService) GetConversation(chatID int64) (*models.Conversation, error) {
+	var conversation models.Conversation
+	err := s.db.First(&conversation, "chat_id = ?", chatID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load conversation: %w", err)
+	}
+	return &conversation, nil
+}
+
+// SaveConversation stores a conversation, replacing the chat's previous one
+func (s *ConversationService) SaveConversation(conversation *models.Conversation) error {
+	conversation.UpdatedAt = time.Now()
+	if err := s.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(conversation).Error; err != nil {
+		return fmt.Errorf("failed to save conversation: %w", err)
+	}
+	return nil
+}
+
+// EndConversation removes the chat's conversation, if it has one
+func (s *ConversationService) EndConversation(chatID int64) error {
+	if err := s.db.Delete(&models.Conversation{}, "chat_id = ?", chatID).Error; err != nil {
+		return fmt.Errorf("failed to end conversation: %w", err)
+	}
+	return nil
+}
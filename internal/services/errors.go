@@ -0,0 +1,59 @@
+package services
+
+// ErrorKind classifies expected failures of service operations so that
+// callers can react to a whole class of errors
+type ErrorKind string
+
+// Kinds of expected failures
+const (
+	// KindNotFound means the requested record does not exist
+	KindNotFound ErrorKind = "not_found"
+	// KindConflict means the operation clashes with the current state, such as an empty box
+	KindConflict ErrorKind = "conflict"
+	// KindValidation means the input is well-formed but not acceptable
+	KindValidation ErrorKind = "validation"
+	// KindForbidden means the user may not perform the operation
+	KindForbidden ErrorKind = "forbidden"
+	// KindUnauthenticated means the caller could not be identified
+	KindUnauthenticated ErrorKind = "unauthenticated"
+	// KindInsufficientFunds means a prepaid wallet does not cover the operation
+	KindInsufficientFunds ErrorKind = "insufficient_funds"
+)
+
+// Error is an expected failure of a service operation. Code is a stable,
+// machine-readable identifier and Message is safe to show to users.
+// Validation errors list the rejected input fields in Fields.
+// Errors with the same code match each other with errors.Is, so a
+// sentinel also matches a copy carrying a more specific message.
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	Fields  []FieldError
+}
+
+// newError creates a domain error
+func newError(kind ErrorKind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// Error returns the user-facing message
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is reports whether target is a domain error with the same code
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// WithMessage returns a copy of the error with a more specific message
+func (e *Error) WithMessage(message string) *Error {
+	return &Error{Kind: e.Kind, Code: e.Code, Message: message, Fields: e.Fields}
+}
+
+// WithFields returns a copy of the error listing the given rejected fields
+func (e *Error) WithFields(fields ...FieldError) *Error {
+	return &Error{Kind: e.Kind, Code: e.Code, Message: e.Message, Fields: fields}
+}
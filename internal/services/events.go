@@ -0,0 +1,72 @@
+package services
+
+import (
+	"sync"
+
+	"github.com/your-username/coffee-cups-system/internal/models"
+)
+
+// Event is something that happened which other parts of the system,
+// such as the Telegram bot, may want to announce
+type Event interface{}
+
+// BoxClosedEvent is published when a box is closed and its payments created
+type BoxClosedEvent struct {
+	Settlement *BoxSettlement
+}
+
+// BoxLowStockEvent is published when logging a coffee brings a box down to
+// its low-stock threshold. The box has its creator loaded.
+type BoxLowStockEvent struct {
+	Box       models.Box
+	Remaining int
+}
+
+// BoxEmptyEvent is published when the last cup of a box has been logged.
+// The box has its creator loaded.
+type BoxEmptyEvent struct {
+	Box models.Box
+}
+
+// SettlementAcceptedEvent is published when a settlement plan is accepted
+type SettlementAcceptedEvent struct {
+	Settlement *models.Settlement
+	Plan       *SettlementPlan
+}
+
+// Listener receives published events
+type Listener func(event Event)
+
+// Events delivers events to every subscribed listener
+type Events struct {
+	mu        sync.RWMutex
+	listeners []Listener
+}
+
+// NewEvents creates an Events without listeners
+func NewEvents() *Events {
+	return &Events{}
+}
+
+// Subscribe registers a listener for all future events
+func (e *Events) Subscribe(listener Listener) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.listeners = append(e.listeners, listener)
+}
+
+// Publish synchronously delivers an event to every listener.
+// Publishing on a nil Events is a no-op so services work without one.
+func (e *Events) Publish(event Event) {
+	if e == nil {
+		return
+	}
+
+	e.mu.RLock()
+	listeners := append([]Listener(nil), e.listeners...)
+	e.mu.RUnlock()
+
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
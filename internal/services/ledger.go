@@ -0,0 +1,275 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ledgerAccount identifies a ledger account before it is looked up or created
+type ledgerAccount struct {
+	kind   string
+	userID uint
+	teamID uint
+	boxID  uint
+}
+
+// Accounts of the ledger, see the Account kinds in models
+func boxAccount(boxID uint) ledgerAccount {
+	return ledgerAccount{kind: models.AccountBox, boxID: boxID}
+}
+
+func userAccount(userID uint) ledgerAccount {
+	return ledgerAccount{kind: models.AccountUser, userID: userID}
+}
+
+func purchaserAccount(userID uint) ledgerAccount {
+	return ledgerAccount{kind: models.AccountPurchaser, userID: userID}
+}
+
+func walletAccount(teamID, userID uint) ledgerAccount {
+	return ledgerAccount{kind: models.AccountWallet, teamID: teamID, userID: userID}
+}
+
+func teamCashAccount(teamID uint) ledgerAccount {
+	return ledgerAccount{kind: models.AccountTeamCash, teamID: teamID}
+}
+
+// code returns the unique code of the account, such as "wallet:1:7"
+func (a ledgerAccount) code() string {
+	switch a.kind {
+	case models.AccountBox:
+		return fmt.Sprintf("%s:%d", a.kind, a.boxID)
+	case models.AccountWallet:
+		return fmt.Sprintf("%s:%d:%d", a.kind, a.teamID, a.userID)
+	case models.AccountTeamCash:
+		return fmt.Sprintf("%s:%d", a.kind, a.teamID)
+	default:
+		return fmt.Sprintf("%s:%d", a.kind, a.userID)
+	}
+}
+
+// ledgerEntry is one posting of a transaction about to be recorded
+type ledgerEntry struct {
+	account ledgerAccount
+	amount  models.Money
+}
+
+// debit and credit build the two sides of a transfer of amount
+func debit(account ledgerAccount, amount models.Money) ledgerEntry {
+	return ledgerEntry{account: account, amount: amount}
+}
+func credit(account ledgerAccount, amount models.Money) ledgerEntry {
+	return ledgerEntry{account: account, amount: models.NewMoney(-amount.MinorUnits, amount.Currency)}
+}
+
+// postLedger records a transaction with the given entries, skipping zero
+// amounts; nothing is recorded when every amount is zero. It refuses entries
+// that don't add up to zero in every currency.
+func postLedger(tx *gorm.DB, txn *models.LedgerTransaction, entries ...ledgerEntry) error {
+	sums := make(map[string]int64)
+	for _, entry := range entries {
+		sums[entry.amount.Currency] += entry.amount.MinorUnits
+	}
+	for currency, sum := range sums {
+		if sum != 0 {
+			return fmt.Errorf("unbalanced %s ledger transaction: off by %s", txn.Kind, models.NewMoney(sum, currency))
+		}
+	}
+
+	for _, entry := range entries {
+		if entry.amount.IsZero() {
+			continue
+		}
+		account, err := findOrCreateAccount(tx, entry.account)
+		if err != nil {
+			return err
+		}
+		txn.Postings = append(txn.Postings, models.LedgerPosting{AccountID: account.ID, Amount: entry.amount})
+	}
+	if len(txn.Postings) == 0 {
+		return nil
+	}
+	if err := tx.Create(txn).Error; err != nil {
+		return fmt.Errorf("failed to post %s to the ledger: %w", txn.Kind, err)
+	}
+	return nil
+}
+
+// findOrCreateAccount loads a ledger account, creating it on first use
+func findOrCreateAccount(tx *gorm.DB, ref ledgerAccount) (*models.LedgerAccount, error) {
+	account := models.LedgerAccount{Code: ref.code(), Kind: ref.kind}
+	if ref.userID != 0 {
+		account.UserID = &ref.userID
+	}
+	if ref.teamID != 0 {
+		account.TeamID = &ref.teamID
+	}
+	if ref.boxID != 0 {
+		account.BoxID = &ref.boxID
+	}
+
+	// Concurrent first postings may race to create the account; both then read the winner
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&account).Error; err != nil {
+		return nil, fmt.Errorf("failed to create ledger account: %w", err)
+	}
+	if err := tx.Where("code = ?", account.Code).First(&account).Error; err != nil {
+		return nil, fmt.Errorf("failed to load ledger account: %w", err)
+	}
+	return &account, nil
+}
+
+// postPurchase records that the purchaser paid for a new box
+func postPurchase(tx *gorm.DB, box *models.Box) error {
+	return postLedger(tx, &models.LedgerTransaction{Kind: models.LedgerPurchase, BoxID: &box.ID},
+		debit(boxAccount(box.ID), box.Price),
+		credit(purchaserAccount(box.CreatedBy), box.Price))
+}
+
+// postBoxClose empties the account of a box being closed. In postpaid teams
+// every consumer's cups are trued up to their exact share, and the part of
+// it their prior payments for the box already charged is taken back, so the
+// settlement payment asks for the rest. The purchaser's own cups are taken
+// off what they are owed. In prepaid boxes the cups were posted at what the wallets
+// were charged. Whatever is left, the cups nobody took and any rounding,
+// goes back to the purchaser.
+func postBoxClose(tx *gorm.DB, box *models.Box, prior map[uint]models.Money) error {
+	if box.IsPrepaid() {
+		charged, err := boxWalletCharges(tx, box.ID, box.Price.Currency)
+		if err != nil {
+			return err
+		}
+		left := box.Price.Sub(charged)
+		return postLedger(tx, &models.LedgerTransaction{Kind: models.LedgerBoxClose, BoxID: &box.ID},
+			debit(purchaserAccount(box.CreatedBy), left), credit(boxAccount(box.ID), left))
+	}
+
+	counts, err := boxCupCounts(tx, box.ID)
+	if err != nil {
+		return err
+	}
+	shares, err := calculateBoxShares(tx, box)
+	if err != nil {
+		return err
+	}
+
+	price := box.GetCostPerCup()
+	left := box.Price
+	var entries []ledgerEntry
+	for _, count := range counts {
+		posted := models.NewMoney(price.MinorUnits*count.Cups, price.Currency)
+		left = left.Sub(posted)
+		switch {
+		case count.UserID == box.CreatedBy:
+			entries = append(entries, debit(purchaserAccount(box.CreatedBy), posted), credit(userAccount(box.CreatedBy), posted))
+		default:
+			adjustment := shares[count.UserID].Sub(posted)
+			charged := priorShare(prior, count.UserID, shares[count.UserID])
+			entries = append(entries, debit(userAccount(count.UserID), adjustment), credit(boxAccount(box.ID), adjustment),
+				debit(purchaserAccount(box.CreatedBy), charged), credit(userAccount(count.UserID), charged))
+			left = left.Sub(adjustment)
+		}
+	}
+	entries = append(entries, debit(purchaserAccount(box.CreatedBy), left), credit(boxAccount(box.ID), left))
+	return postLedger(tx, &models.LedgerTransaction{Kind: models.LedgerBoxClose, BoxID: &box.ID}, entries...)
+}
+
+// postBoxEdit revalues an open box whose price or size was corrected: the
+// purchaser is owed the new price and, in postpaid teams, the cups already
+// taken are charged again at the new cost per cup. Prepaid cups keep the
+// cost they were paid at, see checkCupCostChange.
+func postBoxEdit(tx *gorm.DB, before, after *models.Box) error {
+	counts, err := boxCupCounts(tx, after.ID)
+	if err != nil {
+		return err
+	}
+
+	priceChange := after.Price.Sub(before.Price)
+	entries := []ledgerEntry{debit(boxAccount(after.ID), priceChange), credit(purchaserAccount(after.CreatedBy), priceChange)}
+	cupChange := after.GetCostPerCup().Sub(before.GetCostPerCup())
+	if !after.IsPrepaid() {
+		for _, count := range counts {
+			change := models.NewMoney(cupChange.MinorUnits*count.Cups, cupChange.Currency)
+			entries = append(entries, debit(userAccount(count.UserID), change), credit(boxAccount(after.ID), change))
+		}
+	}
+	return postLedger(tx, &models.LedgerTransaction{Kind: models.LedgerBoxEdit, BoxID: &after.ID}, entries...)
+}
+
+// postBoxDelete takes the purchase of a deleted box back out of the ledger
+func postBoxDelete(tx *gorm.DB, box *models.Box) error {
+	return postLedger(tx, &models.LedgerTransaction{Kind: models.LedgerBoxDelete, BoxID: &box.ID},
+		debit(purchaserAccount(box.CreatedBy), box.Price),
+		credit(boxAccount(box.ID), box.Price))
+}
+
+// cupPosting returns the account a cup from box is charged to and what for:
+// the user's wallet and what it was charged if the cup was paid from a
+// wallet, otherwise what the user owes at the box's cost per cup
+func cupPosting(tx *gorm.DB, box *models.Box, coffeeLog *models.CoffeeLog) (ledgerAccount, models.Money, error) {
+	deduction, err := cupDeduction(tx, coffeeLog.ID)
+	if err != nil {
+		return ledgerAccount{}, models.Money{}, err
+	}
+	if deduction != nil {
+		charged := models.NewMoney(-deduction.Amount.MinorUnits, deduction.Amount.Currency)
+		return walletAccount(deduction.TeamID, deduction.UserID), charged, nil
+	}
+	return userAccount(coffeeLog.UserID), box.GetCostPerCup(), nil
+}
+
+// postCup records that a cup was taken from box
+func postCup(tx *gorm.DB, box *models.Box, coffeeLog *models.CoffeeLog) error {
+	account, price, err := cupPosting(tx, box, coffeeLog)
+	if err != nil {
+		return err
+	}
+	return postLedger(tx, &models.LedgerTransaction{Kind: models.LedgerCup, BoxID: &box.ID, CoffeeLogID: &coffeeLog.ID},
+		debit(account, price),
+		credit(boxAccount(box.ID), price))
+}
+
+// postVoid reverses the cup of a voided coffee log; the log's Box must be loaded
+func postVoid(tx *gorm.DB, coffeeLog *models.CoffeeLog) error {
+	account, price, err := cupPosting(tx, &coffeeLog.Box, coffeeLog)
+	if err != nil {
+		return err
+	}
+	return postLedger(tx, &models.LedgerTransaction{Kind: models.LedgerVoid, BoxID: &coffeeLog.BoxID, CoffeeLogID: &coffeeLog.ID},
+		debit(boxAccount(coffeeLog.BoxID), price),
+		credit(account, price))
+}
+
+// postPaymentSettled records that a payment was paid to the box purchaser,
+// or written off by them when kind is LedgerWriteOff
+func postPaymentSettled(tx *gorm.DB, payment *models.Payment, kind string) error {
+	purchaserID, err := boxPurchaser(tx, payment.BoxID)
+	if err != nil {
+		return err
+	}
+	return postLedger(tx, &models.LedgerTransaction{Kind: kind, BoxID: &payment.BoxID, PaymentID: &payment.ID},
+		debit(purchaserAccount(purchaserID), payment.Amount),
+		credit(userAccount(payment.UserID), payment.Amount))
+}
+
+// postCharge records a payment the box purchaser asked for outside of closing the box
+func postCharge(tx *gorm.DB, payment *models.Payment) error {
+	purchaserID, err := boxPurchaser(tx, payment.BoxID)
+	if err != nil {
+		return err
+	}
+	return postLedger(tx, &models.LedgerTransaction{Kind: models.LedgerCharge, BoxID: &payment.BoxID, PaymentID: &payment.ID},
+		debit(userAccount(payment.UserID), payment.Amount),
+		credit(purchaserAccount(purchaserID), payment.Amount))
+}
+
+// boxPurchaser returns the ID of the user who bought a box
+func boxPurchaser(tx *gorm.DB, boxID uint) (uint, error) {
+	var box models.Box
+	if err := tx.Select("id", "created_by").First(&box, boxID).Error; err != nil {
+		return 0, fmt.Errorf("failed to load box purchaser: %w", err)
+	}
+	return box.CreatedBy, nil
+}
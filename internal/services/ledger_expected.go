@@ -0,0 +1,178 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"gorm.io/gorm"
+)
+
+// expectations holds what each ledger account should hold, keyed by balanceKey
+type expectations map[string]ledgerEntry
+
+// add adds amount to what the account should hold
+func (e expectations) add(account ledgerAccount, amount models.Money) {
+	key := balanceKey(account.code(), amount.Currency)
+	sum := e[key].amount.MinorUnits + amount.MinorUnits
+	e[key] = ledgerEntry{account: account, amount: models.NewMoney(sum, amount.Currency)}
+}
+
+// entries returns the expected balances ordered by account code and currency
+func (e expectations) entries() []ledgerEntry {
+	keys := make([]string, 0, len(e))
+	for key := range e {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	entries := make([]ledgerEntry, 0, len(keys))
+	for _, key := range keys {
+		entries = append(entries, e[key])
+	}
+	return entries
+}
+
+// boxCups is the number of cups a user took from a box
+type boxCups struct {
+	BoxID  uint
+	UserID uint
+	Cups   int64
+}
+
+// expectedBalances works out every account's balance from the records the
+// ledger is posted from, independently of the postings themselves
+func expectedBalances(db *gorm.DB) (expectations, error) {
+	expected := make(expectations)
+	for _, expect := range []func(*gorm.DB, expectations) error{expectBoxes, expectPayments, expectWallets} {
+		if err := expect(db, expected); err != nil {
+			return nil, err
+		}
+	}
+	return expected, nil
+}
+
+// expectBoxes adds what is left in each open box, what its consumers owe for
+// it and what its purchaser is owed. Prepaid cups count at what the wallets
+// were charged for them. The purchaser of a closed prepaid box is owed the
+// cups the wallets paid for; closed postpaid boxes are owed through their
+// payments.
+func expectBoxes(db *gorm.DB, expected expectations) error {
+	var boxes []models.Box
+	if err := db.Order("id").Find(&boxes).Error; err != nil {
+		return fmt.Errorf("failed to load boxes: %w", err)
+	}
+	cups, err := cupsByBox(db)
+	if err != nil {
+		return err
+	}
+	charged, err := walletChargesByBox(db)
+	if err != nil {
+		return err
+	}
+
+	for _, box := range boxes {
+		price := box.GetCostPerCup()
+		consumed := models.NewMoney(charged[box.ID], price.Currency)
+		for _, count := range cups[box.ID] {
+			if box.IsPrepaid() {
+				continue
+			}
+			posted := models.NewMoney(price.MinorUnits*count.Cups, price.Currency)
+			consumed = consumed.Add(posted)
+			if !box.IsClosed() {
+				expected.add(userAccount(count.UserID), posted)
+			}
+		}
+		switch {
+		case !box.IsClosed():
+			expected.add(boxAccount(box.ID), box.Price.Sub(consumed))
+			expected.add(purchaserAccount(box.CreatedBy), models.NewMoney(-box.Price.MinorUnits, box.Price.Currency))
+		case box.IsPrepaid():
+			expected.add(purchaserAccount(box.CreatedBy), models.NewMoney(-consumed.MinorUnits, consumed.Currency))
+		}
+	}
+	return nil
+}
+
+// cupsByBox counts the cups every user took from every box
+func cupsByBox(db *gorm.DB) (map[uint][]boxCups, error) {
+	var counts []boxCups
+	if err := db.Model(&models.CoffeeLog{}).Scopes(models.NotVoided).
+		Select("box_id, user_id, COUNT(*) AS cups").Group("box_id, user_id").Scan(&counts).Error; err != nil {
+		return nil, fmt.Errorf("failed to count coffee logs: %w", err)
+	}
+	cups := make(map[uint][]boxCups)
+	for _, count := range counts {
+		cups[count.BoxID] = append(cups[count.BoxID], count)
+	}
+	return cups, nil
+}
+
+// walletChargesByBox sums what wallets were charged for the cups of every
+// box that have not been voided
+func walletChargesByBox(db *gorm.DB) (map[uint]int64, error) {
+	var sums []struct {
+		BoxID      uint
+		MinorUnits int64
+	}
+	if err := db.Model(&models.WalletEntry{}).
+		Joins("JOIN coffee_logs ON coffee_logs.id = wallet_entries.coffee_log_id").
+		Scopes(models.NotVoided).
+		Where("wallet_entries.kind = ?", models.WalletDeduction).
+		Select("coffee_logs.box_id, -SUM(wallet_entries.amount_minor_units) AS minor_units").
+		Group("coffee_logs.box_id").
+		Scan(&sums).Error; err != nil {
+		return nil, fmt.Errorf("failed to sum wallet charges: %w", err)
+	}
+	charged := make(map[uint]int64, len(sums))
+	for _, sum := range sums {
+		charged[sum.BoxID] = sum.MinorUnits
+	}
+	return charged, nil
+}
+
+// expectPayments adds the outstanding payments, owed by their user to the box purchaser
+func expectPayments(db *gorm.DB, expected expectations) error {
+	var payments []struct {
+		UserID     uint
+		CreatedBy  uint
+		MinorUnits int64
+		Currency   string
+	}
+	if err := db.Model(&models.Payment{}).Scopes(OutstandingPayments).
+		Select("payments.user_id, boxes.created_by, payments.amount_minor_units AS minor_units, payments.amount_currency AS currency").
+		Joins("JOIN boxes ON boxes.id = payments.box_id").
+		Scan(&payments).Error; err != nil {
+		return fmt.Errorf("failed to load outstanding payments: %w", err)
+	}
+	for _, payment := range payments {
+		expected.add(userAccount(payment.UserID), models.NewMoney(payment.MinorUnits, payment.Currency))
+		expected.add(purchaserAccount(payment.CreatedBy), models.NewMoney(-payment.MinorUnits, payment.Currency))
+	}
+	return nil
+}
+
+// expectWallets adds every wallet's balance and the top-ups each team collected
+func expectWallets(db *gorm.DB, expected expectations) error {
+	var sums []struct {
+		TeamID     uint
+		UserID     uint
+		Kind       string
+		Currency   string
+		MinorUnits int64
+	}
+	if err := db.Model(&models.WalletEntry{}).
+		Select("team_id, user_id, kind, amount_currency AS currency, SUM(amount_minor_units) AS minor_units").
+		Group("team_id, user_id, kind, amount_currency").
+		Scan(&sums).Error; err != nil {
+		return fmt.Errorf("failed to sum wallets: %w", err)
+	}
+	for _, sum := range sums {
+		expected.add(walletAccount(sum.TeamID, sum.UserID), models.NewMoney(-sum.MinorUnits, sum.Currency))
+		if sum.Kind == models.WalletTopUp {
+			expected.add(teamCashAccount(sum.TeamID), models.NewMoney(sum.MinorUnits, sum.Currency))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrLedgerNotEmpty is returned when opening balances are posted to a ledger that is in use
+var ErrLedgerNotEmpty = newError(KindConflict, "LEDGER_NOT_EMPTY", "the ledger already has transactions")
+
+// AccountBalance is the balance of a ledger account in one currency.
+// Debits are positive and credits negative.
+type AccountBalance struct {
+	Code    string       `json:"code"`
+	Kind    string       `json:"kind"`
+	Balance models.Money `json:"balance"`
+}
+
+// LedgerCheck is the outcome of checking the ledger against the records it
+// is posted from. The ledger is consistent when Problems is empty.
+type LedgerCheck struct {
+	Transactions int64    `json:"transactions"`
+	Accounts     int      `json:"accounts"`
+	Problems     []string `json:"problems"`
+}
+
+// LedgerService reads and verifies the double-entry ledger that the other
+// services post every money movement into
+type LedgerService struct {
+	db *gorm.DB
+}
+
+// NewLedgerService creates a new LedgerService
+func NewLedgerService(db *gorm.DB) *LedgerService {
+	return &LedgerService{db: db}
+}
+
+// Balances returns the balance of every account as it was at asOf, or now
+// when asOf is nil, ordered by account code and currency
+func (s *LedgerService) Balances(asOf *time.Time) ([]AccountBalance, error) {
+	query := s.db.Table("ledger_postings").
+		Select("ledger_accounts.code, ledger_accounts.kind, ledger_postings.amount_currency AS currency, " +
+			"SUM(ledger_postings.amount_minor_units) AS minor_units").
+		Joins("JOIN ledger_accounts ON ledger_accounts.id = ledger_postings.account_id").
+		Joins("JOIN ledger_transactions ON ledger_transactions.id = ledger_postings.transaction_id").
+		Group("ledger_accounts.code, ledger_accounts.kind, ledger_postings.amount_currency").
+		Order("ledger_accounts.code, ledger_postings.amount_currency")
+	if asOf != nil {
+		query = query.Where("ledger_transactions.created_at <= ?", *asOf)
+	}
+
+	var rows []struct {
+		Code       string
+		Kind       string
+		Currency   string
+		MinorUnits int64
+	}
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to sum ledger accounts: %w", err)
+	}
+
+	balances := make([]AccountBalance, 0, len(rows))
+	for _, row := range rows {
+		balances = append(balances, AccountBalance{
+			Code:    row.Code,
+			Kind:    row.Kind,
+			Balance: models.NewMoney(row.MinorUnits, row.Currency),
+		})
+	}
+	return balances, nil
+}
+
+// Check verifies that every transaction balances and that every account
+// holds what the boxes, coffee logs, payments and wallets say it should
+func (s *LedgerService) Check() (*LedgerCheck, error) {
+	check := &LedgerCheck{Problems: []string{}}
+	if err := s.db.Model(&models.LedgerTransaction{}).Count(&check.Transactions).Error; err != nil {
+		return nil, fmt.Errorf("failed to count ledger transactions: %w", err)
+	}
+
+	var unbalanced []struct {
+		TransactionID uint
+		Currency      string
+		MinorUnits    int64
+	}
+	if err := s.db.Model(&models.LedgerPosting{}).
+		Select("transaction_id, amount_currency AS currency, SUM(amount_minor_units) AS minor_units").
+		Group("transaction_id, amount_currency").
+		Having("SUM(amount_minor_units) <> 0").
+		Order("transaction_id").
+		Scan(&unbalanced).Error; err != nil {
+		return nil, fmt.Errorf("failed to check ledger transactions: %w", err)
+	}
+	for _, row := range unbalanced {
+		check.Problems = append(check.Problems, fmt.Sprintf("transaction %d does not balance: off by %s",
+			row.TransactionID, models.NewMoney(row.MinorUnits, row.Currency)))
+	}
+
+	actual, err := s.Balances(nil)
+	if err != nil {
+		return nil, err
+	}
+	expected, err := expectedBalances(s.db)
+	if err != nil {
+		return nil, err
+	}
+	check.Accounts = len(actual)
+	check.Problems = append(check.Problems, compareBalances(actual, expected)...)
+	return check, nil
+}
+
+// Open posts the balances the records imply as a single opening transaction.
+// It is meant for a database that was in use before the ledger existed, so
+// it refuses to run once the ledger has transactions.
+func (s *LedgerService) Open() (*models.LedgerTransaction, error) {
+	txn := &models.LedgerTransaction{Kind: models.LedgerOpening}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.LedgerTransaction{}).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to count ledger transactions: %w", err)
+		}
+		if count > 0 {
+			return ErrLedgerNotEmpty
+		}
+
+		expected, err := expectedBalances(tx)
+		if err != nil {
+			return err
+		}
+		return postLedger(tx, txn, expected.entries()...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return txn, nil
+}
+
+// compareBalances describes every account whose ledger balance differs from the expected one
+func compareBalances(actual []AccountBalance, expected expectations) []string {
+	seen := make(map[string]bool, len(actual))
+	var problems []string
+	for _, balance := range actual {
+		key := balanceKey(balance.Code, balance.Balance.Currency)
+		seen[key] = true
+		want := models.NewMoney(expected[key].amount.MinorUnits, balance.Balance.Currency)
+		if want != balance.Balance {
+			problems = append(problems, fmt.Sprintf("account %s holds %s, expected %s", balance.Code, balance.Balance, want))
+		}
+	}
+
+	for _, entry := range expected.entries() {
+		if !seen[balanceKey(entry.account.code(), entry.amount.Currency)] && !entry.amount.IsZero() {
+			problems = append(problems, fmt.Sprintf("account %s holds nothing, expected %s", entry.account.code(), entry.amount))
+		}
+	}
+	return problems
+}
+
+// balanceKey identifies the balance of an account in one currency
+func balanceKey(code, currency string) string {
+	return code + " " + currency
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultPageSize is how many items a list returns when no limit is given
+const DefaultPageSize = 50
+
+// MaxPageSize is the most items a list returns at once
+const MaxPageSize = 200
+
+// Page selects one page of a list. Cursor is the NextCursor of the previous
+// page, empty for the first page. Sort names a sort key, prefixed with "-"
+// for descending order; empty means the list's default order.
+type Page struct {
+	Cursor string
+	Limit  int
+	Sort   string
+}
+
+// sortKey is a column a list can be sorted by and how to read it from a row
+type sortKey[T any] struct {
+	column string
+	value  func(*T) interface{}
+}
+
+// listing describes how the rows of one table are sorted and paged.
+// Rows are paged by keyset: each page continues after the sort value and ID
+// of the previous page's last row, so rows added meanwhile do not shift pages.
+type listing[T any] struct {
+	table       string
+	keys        map[string]sortKey[T]
+	defaultSort string
+	id          func(*T) uint
+}
+
+// cursor is the position after the last row of a page
+type cursor struct {
+	Sort  string          `json:"s"`
+	Value json.RawMessage `json:"v"`
+	ID    uint            `json:"id"`
+}
+
+// find loads one page of the rows matching query and returns the cursor of
+// the next page, which is empty on the last page
+func (l listing[T]) find(query *gorm.DB, page Page) ([]T, string, error) {
+	order := page.Sort
+	if order == "" {
+		order = l.defaultSort
+	}
+	name := strings.TrimPrefix(order, "-")
+	key, known := l.keys[name]
+	limit := page.Limit
+	if limit == 0 {
+		limit = DefaultPageSize
+	}
+	if err := Validate(
+		Check("sort", known, "must be one of "+l.sortNames()),
+		Between("limit", limit, 1, MaxPageSize),
+	); err != nil {
+		return nil, "", err
+	}
+
+	direction, op := "ASC", ">"
+	if name != order {
+		direction, op = "DESC", "<"
+	}
+	idColumn := l.table + ".id"
+	if page.Cursor != "" {
+		value, id, err := l.decodeCursor(page.Cursor, order, key)
+		if err != nil {
+			return nil, "", err
+		}
+		query = query.Where(fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND %[3]s %[2]s ?))", key.column, op, idColumn),
+			value, value, id)
+	}
+
+	var rows []T
+	err := query.Order(fmt.Sprintf("%s %s, %s %s", key.column, direction, idColumn, direction)).
+		Limit(limit + 1).Find(&rows).Error
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list %s: %w", l.table, err)
+	}
+	if len(rows) <= limit {
+		return rows, "", nil
+	}
+
+	rows = rows[:limit]
+	last := &rows[limit-1]
+	next, err := encodeCursor(order, key.value(last), l.id(last))
+	return rows, next, err
+}
+
+// sortNames lists the sort keys for error messages
+func (l listing[T]) sortNames() string {
+	names := make([]string, 0, len(l.keys))
+	for name := range l.keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// decodeCursor reads the sort value and ID a cursor continues after.
+// The value is decoded into the Go type of the key's column.
+func (l listing[T]) decodeCursor(encoded, order string, key sortKey[T]) (interface{}, uint, error) {
+	invalid := Validate(Check("cursor", false, "is not a cursor for this list and sort order"))
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, 0, invalid
+	}
+	var c cursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.Sort != order {
+		return nil, 0, invalid
+	}
+
+	value := reflect.New(reflect.TypeOf(key.value(new(T))))
+	if err := json.Unmarshal(c.Value, value.Interface()); err != nil {
+		return nil, 0, invalid
+	}
+	return value.Elem().Interface(), c.ID, nil
+}
+
+// encodeCursor creates the opaque cursor continuing after a row
+func encodeCursor(order string, value interface{}, id uint) (string, error) {
+	encodedValue, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	raw, err := json.Marshal(cursor{Sort: order, Value: encodedValue, ID: id})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// dateRange scopes a query to rows whose column lies in [from, to); nil bounds are open
+func dateRange(column string, from, to *time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if from != nil {
+			db = db.Where(column+" >= ?", *from)
+		}
+		if to != nil {
+			db = db.Where(column+" < ?", *to)
+		}
+		return db
+	}
+}
+
+// DateRangeRule is the rule that a date range does not end before it starts
+func DateRangeRule(from, to *time.Time) Rule {
+	return Check("to", from == nil || to == nil || to.After(*from), "must be after from")
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"time"
+
+	"github.com/your-username/coffee-cups-system/internal/models"
+)
+
+// PaymentFilter narrows down the payments returned by ListPayments.
+// Zero values mean "no filter".
+type PaymentFilter struct {
+	TeamID uint
+	UserID uint
+	BoxID  uint
+	Status string
+	From   *time.Time
+	To     *time.Time
+	Page
+}
+
+// paymentListing sorts payments by creation time, amount or ID
+var paymentListing = listing[models.Payment]{
+	table: "payments",
+	keys: map[string]sortKey[models.Payment]{
+		"id":         {"payments.id", func(p *models.Payment) interface{} { return p.ID }},
+		"created_at": {"payments.created_at", func(p *models.Payment) interface{} { return p.CreatedAt }},
+		"amount":     {"payments.amount_minor_units", func(p *models.Payment) interface{} { return p.Amount.MinorUnits }},
+	},
+	defaultSort: "-created_at",
+	id:          func(p *models.Payment) uint { return p.ID },
+}
+
+// ListPayments retrieves one page of the payments matching the filter,
+// newest first unless the page asks for another order, and the cursor of the next page
+func (s *PaymentService) ListPayments(filter PaymentFilter) ([]models.Payment, string, error) {
+	if err := Validate(
+		OptionalOneOf("status", filter.Status, models.PaymentStatusPending, models.PaymentStatusPaid, models.PaymentStatusCancelled),
+		DateRangeRule(filter.From, filter.To),
+	); err != nil {
+		return nil, "", err
+	}
+
+	query := s.db.Model(&models.Payment{}).Scopes(dateRange("payments.created_at", filter.From, filter.To))
+	if filter.TeamID != 0 {
+		query = query.Where("box_id IN (?)", teamBoxIDs(s.db, filter.TeamID))
+	}
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.BoxID != 0 {
+		query = query.Where("box_id = ?", filter.BoxID)
+	}
+
+	switch filter.Status {
+	case models.PaymentStatusPending:
+		query = query.Scopes(OutstandingPayments)
+	case models.PaymentStatusPaid:
+		query = query.Where("is_paid = ?", true)
+	case models.PaymentStatusCancelled:
+		query = query.Where("cancelled_at IS NOT NULL")
+	}
+
+	return paymentListing.find(query.Preload("User").Preload("Box"), filter.Page)
+}
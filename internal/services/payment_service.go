@@ -1,13 +1,22 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/your-username/coffee-cups-system/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrPaymentNotFound is returned when a payment does not exist
+var ErrPaymentNotFound = newError(KindNotFound, "PAYMENT_NOT_FOUND", "payment not found")
+
+// ErrPaymentNotPending is returned when a paid payment is cancelled or a
+// cancelled payment is paid
+var ErrPaymentNotPending = newError(KindConflict, "PAYMENT_NOT_PENDING", "payment is no longer pending")
+
 // PaymentService handles payment-related operations
 type PaymentService struct {
 	db *gorm.DB
@@ -19,49 +28,208 @@ func NewPaymentService(db *gorm.DB) *PaymentService {
 }
 
 // CalculateUserDebt calculates the debt for a user for a specific box
-func (s *PaymentService) CalculateUserDebt(userID, boxID uint) (float64, error) {
+func (s *PaymentService) CalculateUserDebt(userID, boxID uint) (models.Money, error) {
 	// Get the box
 	var box models.Box
 	if err := s.db.First(&box, boxID).Error; err != nil {
-		return 0, fmt.Errorf("box not found: %w", err)
+		return models.Money{}, fmt.Errorf("box not found: %w", err)
 	}
 
-	// Count user's coffee logs for this box
-	var count int64
-	if err := s.db.Model(&models.CoffeeLog{}).Where("user_id = ? AND box_id = ?", userID, boxID).Count(&count).Error; err != nil {
-		return 0, fmt.Errorf("failed to count coffee logs: %w", err)
+	shares, err := calculateBoxShares(s.db, &box)
+	if err != nil {
+		return models.Money{}, err
 	}
 
-	// Calculate debt
-	costPerCup := box.Price / float64(box.TotalCups)
-	debt := float64(count) * costPerCup
+	if share, ok := shares[userID]; ok {
+		return share, nil
+	}
+	return models.NewMoney(0, box.Price.Currency), nil
+}
 
-	return debt, nil
+// cupCount holds the number of cups a user consumed from a box
+type cupCount struct {
+	UserID uint
+	Cups   int64
 }
 
-// CreatePayment creates a payment record
-func (s *PaymentService) CreatePayment(userID, boxID uint, amount float64) (*models.Payment, error) {
+// calculateBoxShares splits the box price between its consumers.
+// The price is allocated over every consumer's cup count plus one bucket
+// for the cups nobody has taken yet, which stays with the purchaser.
+// Consumers are ordered by user ID so that the extra minor units from
+// rounding always land on the same people; once the box is empty the
+// shares add up to exactly the box price.
+func calculateBoxShares(db *gorm.DB, box *models.Box) (map[uint]models.Money, error) {
+	counts, err := boxCupCounts(db, box.ID)
+	if err != nil {
+		return nil, err
+	}
+	return splitBoxPrice(box.Price, box.TotalCups, counts), nil
+}
+
+// splitBoxPrice allocates the price as calculateBoxShares describes, given
+// the cup counts of a box ordered by user ID
+func splitBoxPrice(price models.Money, totalCups int, counts []cupCount) map[uint]models.Money {
+	weights := make([]int64, 0, len(counts)+1)
+	var used int64
+	for _, c := range counts {
+		weights = append(weights, c.Cups)
+		used += c.Cups
+	}
+	unused := int64(totalCups) - used
+	if unused < 0 {
+		unused = 0
+	}
+	weights = append(weights, unused)
+
+	allocation := price.Allocate(weights)
+	shares := make(map[uint]models.Money, len(counts))
+	for i, c := range counts {
+		shares[c.UserID] = allocation[i]
+	}
+	return shares
+}
+
+// boxCupCounts counts the cups each user took from a box, ordered by user ID
+func boxCupCounts(db *gorm.DB, boxID uint) ([]cupCount, error) {
+	var counts []cupCount
+	if err := db.Model(&models.CoffeeLog{}).Scopes(models.NotVoided).
+		Select("user_id, COUNT(*) AS cups").
+		Where("box_id = ?", boxID).
+		Group("user_id").
+		Order("user_id").
+		Scan(&counts).Error; err != nil {
+		return nil, fmt.Errorf("failed to count coffee logs: %w", err)
+	}
+	return counts, nil
+}
+
+// PaymentRules are the rules for the fields of a new payment
+func PaymentRules(userID, boxID uint, amount models.Money) []Rule {
+	return []Rule{ID("user_id", userID), ID("box_id", boxID), PositiveAmount("amount", amount)}
+}
+
+// CreatePayment records that a member of the box's team owes its purchaser
+// amount, which must be in the currency of the box's price
+func (s *PaymentService) CreatePayment(userID, boxID uint, amount models.Money) (*models.Payment, error) {
+	if err := Validate(PaymentRules(userID, boxID, amount)...); err != nil {
+		return nil, err
+	}
 	payment := models.Payment{
 		UserID: userID,
 		BoxID:  boxID,
-		Amount: amount,
+		Amount: models.NewMoney(amount.MinorUnits, amount.Currency),
 		IsPaid: false,
 	}
 
-	if err := s.db.Create(&payment).Error; err != nil {
-		return nil, fmt.Errorf("failed to create payment: %w", err)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkPaymentBox(tx, &payment); err != nil {
+			return err
+		}
+		if err := tx.Create(&payment).Error; err != nil {
+			return fmt.Errorf("failed to create payment: %w", err)
+		}
+		return postCharge(tx, &payment)
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	payment.Status = payment.GetStatus()
 	return &payment, nil
 }
 
-// MarkPaymentAsPaid marks a payment as paid
-func (s *PaymentService) MarkPaymentAsPaid(paymentID uint) error {
-	now := time.Now()
-	return s.db.Model(&models.Payment{}).Where("id = ?", paymentID).Updates(map[string]interface{}{
-		"is_paid": true,
-		"paid_at": &now,
-	}).Error
+// checkPaymentBox checks that the box exists, that the payer is in its team
+// and that the payment is in the box's currency
+func checkPaymentBox(tx *gorm.DB, payment *models.Payment) error {
+	var box models.Box
+	err := tx.First(&box, payment.BoxID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrBoxNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load box: %w", err)
+	}
+
+	member, err := isTeamMember(tx, box.TeamID, payment.UserID)
+	if err != nil {
+		return err
+	}
+	if !member {
+		return ErrMemberRequired.WithMessage("the payer is not a member of the box's team")
+	}
+	return Validate(Check("amount.currency", payment.Amount.Currency == box.Price.Currency,
+		"must be the box's currency, "+box.Price.Currency))
+}
+
+// GetPaymentByID retrieves a payment by ID
+func (s *PaymentService) GetPaymentByID(id uint) (*models.Payment, error) {
+	var payment models.Payment
+	err := s.db.Preload("Box").First(&payment, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPaymentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// MarkPaymentAsPaid marks a pending payment as paid.
+// Marking an already paid payment again is a no-op.
+func (s *PaymentService) MarkPaymentAsPaid(paymentID uint) (*models.Payment, error) {
+	return s.transition(paymentID, models.PaymentStatusPaid, func(tx *gorm.DB, payment *models.Payment, now time.Time) error {
+		if err := tx.Model(payment).Updates(map[string]interface{}{
+			"is_paid": true,
+			"paid_at": &now,
+		}).Error; err != nil {
+			return err
+		}
+		return postPaymentSettled(tx, payment, models.LedgerPayment)
+	})
+}
+
+// CancelPayment cancels a pending payment so it is no longer owed; the box
+// purchaser writes it off. Cancelling an already cancelled payment again is a no-op.
+func (s *PaymentService) CancelPayment(paymentID uint) (*models.Payment, error) {
+	return s.transition(paymentID, models.PaymentStatusCancelled, func(tx *gorm.DB, payment *models.Payment, now time.Time) error {
+		if err := tx.Model(payment).Update("cancelled_at", &now).Error; err != nil {
+			return err
+		}
+		return postPaymentSettled(tx, payment, models.LedgerWriteOff)
+	})
+}
+
+// transition moves a pending payment to the target status under a row lock
+func (s *PaymentService) transition(paymentID uint, target string, apply func(tx *gorm.DB, payment *models.Payment, now time.Time) error) (*models.Payment, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var payment models.Payment
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, paymentID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPaymentNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		switch payment.GetStatus() {
+		case target:
+			return nil
+		case models.PaymentStatusPending:
+			return apply(tx, &payment, time.Now())
+		default:
+			return ErrPaymentNotPending
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetPaymentByID(paymentID)
+}
+
+// OutstandingPayments scopes a query to payments that are neither paid nor cancelled
+func OutstandingPayments(db *gorm.DB) *gorm.DB {
+	return db.Where("is_paid = ? AND cancelled_at IS NULL", false)
 }
 
 // GetUserPayments retrieves payments for a user
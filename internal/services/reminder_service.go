@@ -0,0 +1,111 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"gorm.io/gorm"
+)
+
+// BoxDebt is a user's running share of a box that has not been closed yet
+type BoxDebt struct {
+	Box    models.Box   `json:"box"`
+	Amount models.Money `json:"amount"`
+}
+
+// Reminder is what a user owes: pending payments from closed boxes and
+// their share of the boxes still in use
+type Reminder struct {
+	User      models.User      `json:"user"`
+	Payments  []models.Payment `json:"payments"`
+	OpenBoxes []BoxDebt        `json:"open_boxes"`
+	Totals    []models.Money   `json:"totals"`
+}
+
+// ReminderService finds the users who should be reminded of their debts
+type ReminderService struct {
+	db *gorm.DB
+}
+
+// NewReminderService creates a new ReminderService
+func NewReminderService(db *gorm.DB) *ReminderService {
+	return &ReminderService{db: db}
+}
+
+// GetDueReminders returns a reminder for every active user who has not
+// opted out and owes at least minAmount minor units in some currency.
+// Box purchasers are never reminded of their own boxes.
+func (s *ReminderService) GetDueReminders(minAmount int64) ([]Reminder, error) {
+	var users []models.User
+	if err := s.db.Where("is_active = ? AND reminders_enabled = ?", true, true).
+		Order("id").Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to load users: %w", err)
+	}
+
+	var payments []models.Payment
+	if err := s.db.Scopes(OutstandingPayments).Preload("Box").
+		Order("id").Find(&payments).Error; err != nil {
+		return nil, fmt.Errorf("failed to load pending payments: %w", err)
+	}
+	openDebts, err := s.openBoxDebts()
+	if err != nil {
+		return nil, err
+	}
+
+	var reminders []Reminder
+	for _, user := range users {
+		reminder := Reminder{User: user, OpenBoxes: openDebts[user.ID]}
+		totals := make(map[string]int64)
+		for _, payment := range payments {
+			if payment.UserID == user.ID {
+				reminder.Payments = append(reminder.Payments, payment)
+				totals[payment.Amount.Currency] += payment.Amount.MinorUnits
+			}
+		}
+		for _, debt := range reminder.OpenBoxes {
+			totals[debt.Amount.Currency] += debt.Amount.MinorUnits
+		}
+
+		reminder.Totals = sumByCurrency(totals)
+		if owesAtLeast(reminder.Totals, minAmount) {
+			reminders = append(reminders, reminder)
+		}
+	}
+	return reminders, nil
+}
+
+// openBoxDebts computes every consumer's running share of the active boxes.
+// Prepaid boxes are skipped since their cups are already paid for.
+func (s *ReminderService) openBoxDebts() (map[uint][]BoxDebt, error) {
+	var boxes []models.Box
+	used := s.db.Model(&models.CoffeeLog{}).Scopes(models.NotVoided).Select("box_id")
+	if err := s.db.Where("is_active = ? AND id IN (?) AND billing_mode <> ?", true, used, models.BillingPrepaid).
+		Order("id").Find(&boxes).Error; err != nil {
+		return nil, fmt.Errorf("failed to load open boxes: %w", err)
+	}
+
+	debts := make(map[uint][]BoxDebt)
+	for _, box := range boxes {
+		shares, err := calculateBoxShares(s.db, &box)
+		if err != nil {
+			return nil, err
+		}
+		for userID, share := range shares {
+			if userID == box.CreatedBy || share.IsZero() {
+				continue
+			}
+			debts[userID] = append(debts[userID], BoxDebt{Box: box, Amount: share})
+		}
+	}
+	return debts, nil
+}
+
+// owesAtLeast reports whether any of the totals is positive and reaches minAmount
+func owesAtLeast(totals []models.Money, minAmount int64) bool {
+	for _, total := range totals {
+		if total.MinorUnits > 0 && total.MinorUnits >= minAmount {
+			return true
+		}
+	}
+	return false
+}
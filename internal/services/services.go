@@ -6,18 +6,49 @@ import (
 
 // Services holds all service dependencies
 type Services struct {
-	User    *UserService
-	Coffee  *CoffeeService
-	Box     *BoxService
-	Payment *PaymentService
+	Events     *Events
+	User       *UserService
+	Auth       *AuthService
+	Team       *TeamService
+	Coffee     *CoffeeService
+	Box        *BoxService
+	Payment    *PaymentService
+	Settlement *SettlementService
+	Analytics  *AnalyticsService
+	Reminder   *ReminderService
+	Balance    *BalanceService
+	Wallet     *WalletService
+	Ledger     *LedgerService
+
+	Conversation *ConversationService
 }
 
 // NewServices creates a new Services instance with all dependencies
 func NewServices(db *gorm.DB, logger interface{}) *Services {
+	events := NewEvents()
+
+	coffee := NewCoffeeService(db)
+	coffee.events = events
+	box := NewBoxService(db)
+	box.events = events
+	settlement := NewSettlementService(db)
+	settlement.events = events
+
 	return &Services{
-		User:    NewUserService(db),
-		Coffee:  NewCoffeeService(db),
-		Box:     NewBoxService(db),
-		Payment: NewPaymentService(db),
+		Events:     events,
+		User:       NewUserService(db),
+		Auth:       NewAuthService(db),
+		Team:       NewTeamService(db),
+		Coffee:     coffee,
+		Box:        box,
+		Payment:    NewPaymentService(db),
+		Settlement: settlement,
+		Analytics:  NewAnalyticsService(db),
+		Reminder:   NewReminderService(db),
+		Balance:    NewBalanceService(db),
+		Wallet:     NewWalletService(db),
+		Ledger:     NewLedgerService(db),
+
+		Conversation: NewConversationService(db),
 	}
 }
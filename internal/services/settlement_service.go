@@ -0,0 +1,254 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrNothingToSettle is returned when there are no unpaid payments
+var ErrNothingToSettle = newError(KindConflict, "NOTHING_TO_SETTLE", "there are no outstanding payments to settle")
+
+// ErrSettlementPlanChanged is returned when payments changed since the plan was made
+var ErrSettlementPlanChanged = newError(KindConflict, "SETTLEMENT_PLAN_CHANGED", "outstanding payments changed since the plan was created")
+
+// SettlementService nets outstanding payments into a minimal set of transfers
+type SettlementService struct {
+	db     *gorm.DB
+	events *Events
+}
+
+// NewSettlementService creates a new SettlementService
+func NewSettlementService(db *gorm.DB) *SettlementService {
+	return &SettlementService{db: db}
+}
+
+// Transfer is a single money transfer between two users
+type Transfer struct {
+	FromUserID uint         `json:"from_user_id"`
+	FromName   string       `json:"from_name"`
+	ToUserID   uint         `json:"to_user_id"`
+	ToName     string       `json:"to_name"`
+	Amount     models.Money `json:"amount"`
+}
+
+// SettlementPlan is a proposed set of transfers that clears all unpaid payments.
+// Token identifies the exact set of payments the plan was built from.
+type SettlementPlan struct {
+	Token      string     `json:"token"`
+	Transfers  []Transfer `json:"transfers"`
+	PaymentIDs []uint     `json:"payment_ids"`
+}
+
+// userBalance is a user's net position in one currency
+type userBalance struct {
+	userID uint
+	amount int64
+}
+
+// GetPlan builds a settlement plan from the unpaid payments of a team
+func (s *SettlementService) GetPlan(teamID uint) (*SettlementPlan, error) {
+	payments, err := loadUnpaidPayments(s.db, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := buildSettlementPlan(payments)
+	if err := s.attachNames(plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// AcceptPlan marks every payment covered by the team's plan as paid in one
+// transaction. The token must match the team's current set of unpaid payments.
+func (s *SettlementService) AcceptPlan(teamID uint, token string, acceptedBy uint) (*models.Settlement, error) {
+	if err := Validate(Required("token", token)); err != nil {
+		return nil, err
+	}
+
+	var settlement models.Settlement
+	var plan *SettlementPlan
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		payments, err := loadUnpaidPayments(tx.Clauses(clause.Locking{Strength: "UPDATE"}), teamID)
+		if err != nil {
+			return err
+		}
+		if len(payments) == 0 {
+			return ErrNothingToSettle
+		}
+
+		plan = buildSettlementPlan(payments)
+		if plan.Token != token {
+			return ErrSettlementPlanChanged
+		}
+
+		settlement = models.Settlement{Token: token, AcceptedBy: acceptedBy, TeamID: teamID}
+		if err := tx.Create(&settlement).Error; err != nil {
+			return fmt.Errorf("failed to create settlement: %w", err)
+		}
+
+		now := time.Now()
+		if err := tx.Model(&models.Payment{}).Where("id IN ?", plan.PaymentIDs).Updates(map[string]interface{}{
+			"is_paid":       true,
+			"paid_at":       &now,
+			"settlement_id": settlement.ID,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to mark payments as paid: %w", err)
+		}
+		for i := range payments {
+			if err := postPaymentSettled(tx, &payments[i], models.LedgerPayment); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.attachNames(plan); err != nil {
+		return nil, err
+	}
+	s.events.Publish(SettlementAcceptedEvent{Settlement: &settlement, Plan: plan})
+	return &settlement, nil
+}
+
+// loadUnpaidPayments loads a team's outstanding payments with their box ordered by ID
+func loadUnpaidPayments(db *gorm.DB, teamID uint) ([]models.Payment, error) {
+	var payments []models.Payment
+	if err := db.Scopes(OutstandingPayments).Where("box_id IN (?)", teamBoxIDs(db, teamID)).Preload("Box").Order("id").Find(&payments).Error; err != nil {
+		return nil, fmt.Errorf("failed to load unpaid payments: %w", err)
+	}
+	return payments, nil
+}
+
+// buildSettlementPlan nets payments per currency and matches debtors with creditors
+func buildSettlementPlan(payments []models.Payment) *SettlementPlan {
+	plan := &SettlementPlan{Transfers: []Transfer{}, PaymentIDs: []uint{}}
+	balances := make(map[string]map[uint]int64)
+
+	for _, payment := range payments {
+		plan.PaymentIDs = append(plan.PaymentIDs, payment.ID)
+		payee := payment.Box.CreatedBy
+		if payment.UserID == payee {
+			continue
+		}
+
+		currency := payment.Amount.Currency
+		if balances[currency] == nil {
+			balances[currency] = make(map[uint]int64)
+		}
+		balances[currency][payment.UserID] -= payment.Amount.MinorUnits
+		balances[currency][payee] += payment.Amount.MinorUnits
+	}
+
+	currencies := make([]string, 0, len(balances))
+	for currency := range balances {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	for _, currency := range currencies {
+		plan.Transfers = append(plan.Transfers, matchBalances(balances[currency], currency)...)
+	}
+	plan.Token = settlementToken(plan.PaymentIDs)
+	return plan
+}
+
+// matchBalances greedily pays the largest creditor from the largest debtor.
+// Each step settles at least one user, so n users need at most n-1 transfers.
+func matchBalances(balances map[uint]int64, currency string) []Transfer {
+	var debtors, creditors []userBalance
+	for userID, amount := range balances {
+		if amount < 0 {
+			debtors = append(debtors, userBalance{userID: userID, amount: -amount})
+		} else if amount > 0 {
+			creditors = append(creditors, userBalance{userID: userID, amount: amount})
+		}
+	}
+	sortBalances(debtors)
+	sortBalances(creditors)
+
+	var transfers []Transfer
+	for len(debtors) > 0 && len(creditors) > 0 {
+		debtor, creditor := &debtors[0], &creditors[0]
+		amount := debtor.amount
+		if creditor.amount < amount {
+			amount = creditor.amount
+		}
+
+		transfers = append(transfers, Transfer{
+			FromUserID: debtor.userID,
+			ToUserID:   creditor.userID,
+			Amount:     models.NewMoney(amount, currency),
+		})
+
+		debtor.amount -= amount
+		creditor.amount -= amount
+		if debtor.amount == 0 {
+			debtors = debtors[1:]
+		}
+		if creditor.amount == 0 {
+			creditors = creditors[1:]
+		}
+		sortBalances(debtors)
+		sortBalances(creditors)
+	}
+	return transfers
+}
+
+// sortBalances orders balances by amount descending, then by user ID
+func sortBalances(balances []userBalance) {
+	sort.Slice(balances, func(i, j int) bool {
+		if balances[i].amount != balances[j].amount {
+			return balances[i].amount > balances[j].amount
+		}
+		return balances[i].userID < balances[j].userID
+	})
+}
+
+// settlementToken fingerprints the set of payments a plan covers
+func settlementToken(paymentIDs []uint) string {
+	ids := make([]string, len(paymentIDs))
+	for i, id := range paymentIDs {
+		ids[i] = strconv.FormatUint(uint64(id), 10)
+	}
+	sum := sha256.Sum256([]byte(strings.Join(ids, ",")))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// attachNames fills in the display names of everyone in the plan
+func (s *SettlementService) attachNames(plan *SettlementPlan) error {
+	if len(plan.Transfers) == 0 {
+		return nil
+	}
+
+	var userIDs []uint
+	for _, transfer := range plan.Transfers {
+		userIDs = append(userIDs, transfer.FromUserID, transfer.ToUserID)
+	}
+
+	var users []models.User
+	if err := s.db.Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+		return fmt.Errorf("failed to load users: %w", err)
+	}
+
+	names := make(map[uint]string, len(users))
+	for _, user := range users {
+		names[user.ID] = user.FirstName
+	}
+	for i := range plan.Transfers {
+		plan.Transfers[i].FromName = names[plan.Transfers[i].FromUserID]
+		plan.Transfers[i].ToName = names[plan.Transfers[i].ToUserID]
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrTeamNotFound is returned when a team does not exist
+var ErrTeamNotFound = newError(KindNotFound, "TEAM_NOT_FOUND", "team not found")
+
+// ErrNotTeamMember is returned when a user acts on a team they do not belong to
+var ErrNotTeamMember = newError(KindForbidden, "NOT_TEAM_MEMBER", "user is not a member of this team")
+
+// ErrMemberRequired is returned when an operation names a user, such as the
+// owner of a wallet or the payer of a payment, who is not in the team
+var ErrMemberRequired = newError(KindValidation, "MEMBER_REQUIRED", "user is not a member of this team").
+	WithFields(FieldError{Field: "user_id", Message: "is not a member of this team"})
+
+// ErrInvalidBillingMode is returned for a billing mode other than postpaid or prepaid
+var ErrInvalidBillingMode = newError(KindValidation, "INVALID_BILLING_MODE", "billing mode must be postpaid or prepaid")
+
+// ErrBillingModeInUse is returned when changing the billing mode of a box
+// that cups were logged from and that isn't closed yet
+var ErrBillingModeInUse = newError(KindConflict, "BILLING_MODE_IN_USE", "close the boxes cups were logged from before changing the billing mode")
+
+// TeamService handles teams, their members and their linked group chats
+type TeamService struct {
+	db *gorm.DB
+}
+
+// NewTeamService creates a new TeamService
+func NewTeamService(db *gorm.DB) *TeamService {
+	return &TeamService{db: db}
+}
+
+// TeamRules are the rules for the fields of a new team
+func TeamRules(name string) []Rule {
+	return []Rule{Required("name", name), MaxLength("name", name, MaxNameLength)}
+}
+
+// CreateTeam creates a team that is not linked to a group chat
+func (s *TeamService) CreateTeam(name string) (*models.Team, error) {
+	name = strings.TrimSpace(name)
+	if err := Validate(TeamRules(name)...); err != nil {
+		return nil, err
+	}
+
+	team := models.Team{Name: name, BillingMode: models.BillingPostpaid}
+	if err := s.db.Create(&team).Error; err != nil {
+		return nil, fmt.Errorf("failed to create team: %w", err)
+	}
+	return &team, nil
+}
+
+// LinkChat links a Telegram group chat to a team with the given name.
+// A chat that is already linked keeps its team, which is renamed.
+func (s *TeamService) LinkChat(chatID int64, name string) (*models.Team, error) {
+	team, err := s.GetTeamByChatID(chatID)
+	if errors.Is(err, ErrTeamNotFound) {
+		team = &models.Team{Name: name, ChatID: &chatID, BillingMode: models.BillingPostpaid}
+		if err := s.db.Create(team).Error; err != nil {
+			return nil, fmt.Errorf("failed to create team: %w", err)
+		}
+		return team, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Model(team).Update("name", name).Error; err != nil {
+		return nil, fmt.Errorf("failed to rename team: %w", err)
+	}
+	return team, nil
+}
+
+// SetBillingMode switches a team between postpaid and prepaid billing.
+// Every box keeps the mode its cups were logged under: the switch is refused
+// while a box that isn't closed yet has cups logged, and the team's other
+// unclosed boxes switch with it.
+func (s *TeamService) SetBillingMode(teamID uint, mode string) (*models.Team, error) {
+	if err := validateAs(ErrInvalidBillingMode, OneOf("billing_mode", mode, models.BillingPostpaid, models.BillingPrepaid)); err != nil {
+		return nil, err
+	}
+	team, err := s.GetTeamByID(teamID)
+	if err != nil || team.BillingMode == mode {
+		return team, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var inUse int64
+		used := tx.Model(&models.CoffeeLog{}).Scopes(models.NotVoided).Select("box_id")
+		if err := tx.Model(&models.Box{}).
+			Where("team_id = ? AND closed_at IS NULL AND id IN (?)", teamID, used).
+			Count(&inUse).Error; err != nil {
+			return fmt.Errorf("failed to check boxes in use: %w", err)
+		}
+		if inUse > 0 {
+			return ErrBillingModeInUse
+		}
+		if err := tx.Model(team).Update("billing_mode", mode).Error; err != nil {
+			return fmt.Errorf("failed to set billing mode: %w", err)
+		}
+		if err := tx.Model(&models.Box{}).Where("team_id = ? AND closed_at IS NULL", teamID).
+			Update("billing_mode", mode).Error; err != nil {
+			return fmt.Errorf("failed to set billing mode of boxes: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	team.BillingMode = mode
+	return team, nil
+}
+
+// GetTeamByID retrieves a team by ID
+func (s *TeamService) GetTeamByID(id uint) (*models.Team, error) {
+	return s.findTeam(s.db.Where("id = ?", id))
+}
+
+// GetTeamByChatID retrieves the team linked to a Telegram chat
+func (s *TeamService) GetTeamByChatID(chatID int64) (*models.Team, error) {
+	return s.findTeam(s.db.Where("chat_id = ?", chatID))
+}
+
+// GetAllTeams retrieves every team ordered by name
+func (s *TeamService) GetAllTeams() ([]models.Team, error) {
+	var teams []models.Team
+	err := s.db.Order("name, id").Find(&teams).Error
+	return teams, err
+}
+
+// GetUserTeams retrieves the teams a user is a member of
+func (s *TeamService) GetUserTeams(userID uint) ([]models.Team, error) {
+	var teams []models.Team
+	err := s.db.Where("id IN (?)", memberTeamIDs(s.db, userID)).Order("name, id").Find(&teams).Error
+	return teams, err
+}
+
+// GetLinkedTeams retrieves every team that has a group chat
+func (s *TeamService) GetLinkedTeams() ([]models.Team, error) {
+	var teams []models.Team
+	err := s.db.Where("chat_id IS NOT NULL").Order("id").Find(&teams).Error
+	return teams, err
+}
+
+// findTeam loads the first team matching query
+func (s *TeamService) findTeam(query *gorm.DB) (*models.Team, error) {
+	var team models.Team
+	err := query.First(&team).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTeamNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load team: %w", err)
+	}
+	return &team, nil
+}
+
+// AddMember adds a user to a team. Adding an existing member is a no-op.
+func (s *TeamService) AddMember(teamID, userID uint) error {
+	if err := requireRecord(s.db, &models.User{}, "user_id", userID); err != nil {
+		return err
+	}
+
+	member := models.TeamMember{TeamID: teamID, UserID: userID}
+	err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&member).Error
+	if err != nil {
+		return fmt.Errorf("failed to add team member: %w", err)
+	}
+	return nil
+}
+
+// IsMember reports whether a user belongs to a team
+func (s *TeamService) IsMember(teamID, userID uint) (bool, error) {
+	return isTeamMember(s.db, teamID, userID)
+}
+
+// GetMembers retrieves the active users of a team
+func (s *TeamService) GetMembers(teamID uint) ([]models.User, error) {
+	var users []models.User
+	members := s.db.Model(&models.TeamMember{}).Select("user_id").Where("team_id = ?", teamID)
+	err := s.db.Where("is_active = ? AND id IN (?)", true, members).Order("id").Find(&users).Error
+	return users, err
+}
+
+// memberTeamIDs is a subquery selecting the IDs of the teams a user belongs to
+func memberTeamIDs(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).Model(&models.TeamMember{}).Select("team_id").Where("user_id = ?", userID)
+}
+
+// teamBoxIDs is a subquery selecting the IDs of a team's boxes
+func teamBoxIDs(db *gorm.DB, teamID uint) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).Model(&models.Box{}).Select("id").Where("team_id = ?", teamID)
+}
+
+// isTeamMember reports whether a user belongs to a team within tx
+func isTeamMember(tx *gorm.DB, teamID, userID uint) (bool, error) {
+	var count int64
+	err := tx.Model(&models.TeamMember{}).Where("team_id = ? AND user_id = ?", teamID, userID).Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check team membership: %w", err)
+	}
+	return count > 0, nil
+}
@@ -1,12 +1,16 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/your-username/coffee-cups-system/internal/models"
 	"gorm.io/gorm"
 )
 
+// ErrUserNotFound is returned when a user does not exist
+var ErrUserNotFound = newError(KindNotFound, "USER_NOT_FOUND", "user not found")
+
 // UserService handles user-related operations
 type UserService struct {
 	db *gorm.DB
@@ -30,6 +34,9 @@ func (s *UserService) CreateOrUpdateUser(telegramID int64, username, firstName,
 			FirstName:  firstName,
 			LastName:   lastName,
 			IsActive:   true,
+			Role:       models.RoleMember,
+
+			RemindersEnabled: true,
 		}
 		if err := s.db.Create(&user).Error; err != nil {
 			return nil, fmt.Errorf("failed to create user: %w", err)
@@ -51,17 +58,96 @@ func (s *UserService) CreateOrUpdateUser(telegramID int64, username, firstName,
 
 // GetUserByTelegramID retrieves a user by their Telegram ID
 func (s *UserService) GetUserByTelegramID(telegramID int64) (*models.User, error) {
+	return s.findUser(s.db.Where("telegram_id = ?", telegramID))
+}
+
+// GetUserByID retrieves a user by ID
+func (s *UserService) GetUserByID(id uint) (*models.User, error) {
+	return s.findUser(s.db.Where("id = ?", id))
+}
+
+// findUser loads the first user matching query
+func (s *UserService) findUser(query *gorm.DB) (*models.User, error) {
 	var user models.User
-	err := s.db.Where("telegram_id = ?", telegramID).First(&user).Error
+	err := query.First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	}
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to load user: %w", err)
 	}
 	return &user, nil
 }
 
-// GetAllActiveUsers retrieves all active users
-func (s *UserService) GetAllActiveUsers() ([]models.User, error) {
+// GetTeammates retrieves the active users who share at least one team with the user
+func (s *UserService) GetTeammates(userID uint) ([]models.User, error) {
 	var users []models.User
-	err := s.db.Where("is_active = ?", true).Find(&users).Error
+	teammates := s.db.Model(&models.TeamMember{}).Select("user_id").Where("team_id IN (?)", memberTeamIDs(s.db, userID))
+	err := s.db.Where("is_active = ? AND id IN (?)", true, teammates).Order("id").Find(&users).Error
 	return users, err
 }
+
+// SharesTeam reports whether two users belong to at least one common team
+func (s *UserService) SharesTeam(userID, otherID uint) (bool, error) {
+	var count int64
+	err := s.db.Model(&models.TeamMember{}).
+		Where("user_id = ? AND team_id IN (?)", otherID, memberTeamIDs(s.db, userID)).Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check shared teams: %w", err)
+	}
+	return count > 0, nil
+}
+
+// UserFilter narrows down the users returned by ListTeammates.
+// Active defaults to active users only.
+type UserFilter struct {
+	Active *bool
+	Page
+}
+
+// userListing sorts users by ID, first name or sign-up time
+var userListing = listing[models.User]{
+	table: "users",
+	keys: map[string]sortKey[models.User]{
+		"id":         {"users.id", func(u *models.User) interface{} { return u.ID }},
+		"first_name": {"users.first_name", func(u *models.User) interface{} { return u.FirstName }},
+		"created_at": {"users.created_at", func(u *models.User) interface{} { return u.CreatedAt }},
+	},
+	defaultSort: "id",
+	id:          func(u *models.User) uint { return u.ID },
+}
+
+// ListTeammates retrieves one page of the users who share at least one team
+// with the user, and the cursor of the next page
+func (s *UserService) ListTeammates(userID uint, filter UserFilter) ([]models.User, string, error) {
+	active := true
+	if filter.Active != nil {
+		active = *filter.Active
+	}
+	teammates := s.db.Model(&models.TeamMember{}).Select("user_id").Where("team_id IN (?)", memberTeamIDs(s.db, userID))
+	query := s.db.Where("is_active = ? AND id IN (?)", active, teammates)
+	return userListing.find(query, filter.Page)
+}
+
+// SetRole changes the role of a user
+func (s *UserService) SetRole(userID uint, role string) error {
+	if role != models.RoleMember && role != models.RoleAdmin {
+		return fmt.Errorf("invalid role %q", role)
+	}
+	return s.db.Model(&models.User{}).Where("id = ?", userID).Update("role", role).Error
+}
+
+// SetRemindersEnabled turns payment reminders on or off for a user
+func (s *UserService) SetRemindersEnabled(userID uint, enabled bool) error {
+	return s.db.Model(&models.User{}).Where("id = ?", userID).Update("reminders_enabled", enabled).Error
+}
+
+// SetQuietHours sets the period in which a user gets no reminders; nil clears it
+func (s *UserService) SetQuietHours(userID uint, quiet *models.QuietHours) error {
+	updates := map[string]interface{}{"quiet_hours_start": nil, "quiet_hours_end": nil}
+	if quiet != nil {
+		updates["quiet_hours_start"] = quiet.Start
+		updates["quiet_hours_end"] = quiet.End
+	}
+	return s.db.Model(&models.User{}).Where("id = ?", userID).Updates(updates).Error
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"gorm.io/gorm"
+)
+
+// MaxNameLength is the longest name a team or box may have
+const MaxNameLength = 100
+
+// MaxNoteLength is the longest free-text note, such as a void reason
+const MaxNoteLength = 500
+
+// MaxBoxCups is the largest number of cups a box may hold
+const MaxBoxCups = 10000
+
+// ErrValidation is returned when input fails one or more field rules.
+// The returned error lists every failing field in its Fields.
+var ErrValidation = newError(KindValidation, "VALIDATION_FAILED", "some fields are invalid")
+
+// FieldError explains why one input field was rejected. Field uses the
+// JSON name of the field, with a dot for nested fields such as price.currency.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Rule checks one field and returns nil when it is valid
+type Rule func() *FieldError
+
+// Validate runs every rule and returns ErrValidation listing all failures,
+// or nil when every rule passes
+func Validate(rules ...Rule) error {
+	return validateAs(ErrValidation, rules...)
+}
+
+// validateAs runs every rule and returns a copy of base listing all failures,
+// so that a specific error such as ErrInvalidTopUp carries field details too
+func validateAs(base *Error, rules ...Rule) error {
+	var fields []FieldError
+	for _, rule := range rules {
+		if failure := rule(); failure != nil {
+			fields = append(fields, *failure)
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return base.WithFields(fields...)
+}
+
+// Check is a rule that fails with message unless ok holds
+func Check(field string, ok bool, message string) Rule {
+	return func() *FieldError {
+		if ok {
+			return nil
+		}
+		return &FieldError{Field: field, Message: message}
+	}
+}
+
+// Required is a rule that the string is not blank
+func Required(field, value string) Rule {
+	return Check(field, strings.TrimSpace(value) != "", "is required")
+}
+
+// MaxLength is a rule that the string has at most max characters
+func MaxLength(field, value string, max int) Rule {
+	return Check(field, utf8.RuneCountInString(value) <= max, fmt.Sprintf("must be at most %d characters", max))
+}
+
+// Between is a rule that the number lies in [min, max]
+func Between(field string, value, min, max int) Rule {
+	return Check(field, value >= min && value <= max, fmt.Sprintf("must be between %d and %d", min, max))
+}
+
+// ID is a rule that a referenced record ID is set
+func ID(field string, value uint) Rule {
+	return Check(field, value > 0, "is required")
+}
+
+// OneOf is a rule that the string is one of the allowed values
+func OneOf(field, value string, allowed ...string) Rule {
+	for _, a := range allowed {
+		if value == a {
+			return Check(field, true, "")
+		}
+	}
+	return Check(field, false, "must be one of "+strings.Join(allowed, ", "))
+}
+
+// OptionalOneOf is a rule that the string is empty or one of the allowed values
+func OptionalOneOf(field, value string, allowed ...string) Rule {
+	if value == "" {
+		return Check(field, true, "")
+	}
+	return OneOf(field, value, allowed...)
+}
+
+// PositiveAmount is a rule that the amount is above zero in a valid currency
+func PositiveAmount(field string, amount models.Money) Rule {
+	return amountRule(field, amount, amount.MinorUnits > 0, "must be positive")
+}
+
+// NonNegativeAmount is a rule that the amount is not below zero and in a valid currency
+func NonNegativeAmount(field string, amount models.Money) Rule {
+	return amountRule(field, amount, amount.MinorUnits >= 0, "must not be negative")
+}
+
+// amountRule checks an amount's currency first, then its value
+func amountRule(field string, amount models.Money, ok bool, message string) Rule {
+	return func() *FieldError {
+		if !validCurrency(amount.Currency) {
+			return &FieldError{Field: field + ".currency", Message: "must be a three-letter currency code"}
+		}
+		return Check(field+".minor_units", ok, message)()
+	}
+}
+
+// requireRecord returns a validation error for field unless a row of
+// model's table has the given ID
+func requireRecord(db *gorm.DB, model interface{}, field string, id uint) error {
+	var count int64
+	if err := db.Model(model).Where("id = ?", id).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check %s: %w", field, err)
+	}
+	return Validate(Check(field, count > 0, "does not exist"))
+}
+
+// validCurrency reports whether code is empty, meaning the default
+// currency, or three ASCII letters
+func validCurrency(code string) bool {
+	if code == "" {
+		return true
+	}
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range strings.ToUpper(code) {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInsufficientFunds is returned when a prepaid wallet does not cover a cup
+var ErrInsufficientFunds = newError(KindInsufficientFunds, "INSUFFICIENT_FUNDS", "your wallet balance does not cover this cup")
+
+// ErrInvalidTopUp is returned for a top-up that is not a positive amount
+var ErrInvalidTopUp = newError(KindValidation, "INVALID_TOP_UP", "top-up amount must be positive")
+
+// Wallet is a user's prepaid balance in a team with its most recent entries
+type Wallet struct {
+	TeamID  uint                 `json:"team_id"`
+	UserID  uint                 `json:"user_id"`
+	Balance []models.Money       `json:"balance"`
+	Entries []models.WalletEntry `json:"entries"`
+}
+
+// WalletService handles the prepaid wallets of teams in prepaid billing mode
+type WalletService struct {
+	db *gorm.DB
+}
+
+// NewWalletService creates a new WalletService
+func NewWalletService(db *gorm.DB) *WalletService {
+	return &WalletService{db: db}
+}
+
+// TopUp credits money a team member paid into their wallet
+func (s *WalletService) TopUp(teamID, userID uint, amount models.Money, note string, createdBy uint) (*models.WalletEntry, error) {
+	if err := validateAs(ErrInvalidTopUp, PositiveAmount("amount", amount)); err != nil {
+		return nil, err
+	}
+	if err := Validate(MaxLength("note", note, MaxNoteLength)); err != nil {
+		return nil, err
+	}
+	member, err := isTeamMember(s.db, teamID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !member {
+		return nil, ErrMemberRequired
+	}
+
+	entry := models.WalletEntry{
+		TeamID:    teamID,
+		UserID:    userID,
+		Kind:      models.WalletTopUp,
+		Amount:    models.NewMoney(amount.MinorUnits, amount.Currency),
+		Note:      note,
+		CreatedBy: createdBy,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&entry).Error; err != nil {
+			return fmt.Errorf("failed to top up wallet: %w", err)
+		}
+		return postLedger(tx, &models.LedgerTransaction{Kind: models.LedgerTopUp, WalletEntryID: &entry.ID},
+			debit(teamCashAccount(teamID), entry.Amount),
+			credit(walletAccount(teamID, userID), entry.Amount))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// GetWallet retrieves a user's balance in a team and their latest entries, newest first
+func (s *WalletService) GetWallet(teamID, userID uint, limit int) (*Wallet, error) {
+	wallet := &Wallet{TeamID: teamID, UserID: userID, Balance: []models.Money{}}
+	err := walletEntries(s.db, teamID, userID).
+		Select("amount_currency AS currency, SUM(amount_minor_units) AS minor_units").
+		Group("amount_currency").Order("amount_currency").
+		Scan(&wallet.Balance).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum wallet: %w", err)
+	}
+
+	query := walletEntries(s.db, teamID, userID).Order("created_at DESC, id DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&wallet.Entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to load wallet entries: %w", err)
+	}
+	return wallet, nil
+}
+
+// GetBalance returns a user's wallet balance in a team in one currency
+func (s *WalletService) GetBalance(teamID, userID uint, currency string) (models.Money, error) {
+	return walletBalance(s.db, teamID, userID, currency)
+}
+
+// walletEntries scopes a query to a user's wallet in a team
+func walletEntries(db *gorm.DB, teamID, userID uint) *gorm.DB {
+	return db.Model(&models.WalletEntry{}).Where("team_id = ? AND user_id = ?", teamID, userID)
+}
+
+// walletBalance sums a user's wallet in a team in one currency
+func walletBalance(db *gorm.DB, teamID, userID uint, currency string) (models.Money, error) {
+	balance := models.NewMoney(0, currency)
+	err := walletEntries(db, teamID, userID).
+		Where("amount_currency = ?", balance.Currency).
+		Select("COALESCE(SUM(amount_minor_units), 0)").
+		Scan(&balance.MinorUnits).Error
+	if err != nil {
+		return models.Money{}, fmt.Errorf("failed to sum wallet: %w", err)
+	}
+	return balance, nil
+}
+
+// chargeCup deducts a newly logged cup from the consumer's wallet when the
+// box's team is prepaid. Each cup is charged what brings the box's charges
+// up to the cost of the cups taken so far, so a full box is charged exactly
+// its price, even when cups were voided and taken again. The membership row
+// is locked so that concurrent cups from different boxes cannot overdraw the
+// wallet.
+func chargeCup(tx *gorm.DB, box *models.Box, coffeeLog *models.CoffeeLog) error {
+	if !box.IsPrepaid() {
+		return nil
+	}
+
+	var member models.TeamMember
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("team_id = ? AND user_id = ?", box.TeamID, coffeeLog.UserID).
+		First(&member).Error; err != nil {
+		return fmt.Errorf("failed to lock wallet: %w", err)
+	}
+
+	price, err := cupCharge(tx, box)
+	if err != nil {
+		return err
+	}
+	balance, err := walletBalance(tx, box.TeamID, coffeeLog.UserID, price.Currency)
+	if err != nil {
+		return err
+	}
+	if balance.MinorUnits < price.MinorUnits {
+		return ErrInsufficientFunds
+	}
+
+	entry := models.WalletEntry{
+		TeamID:      box.TeamID,
+		UserID:      coffeeLog.UserID,
+		Kind:        models.WalletDeduction,
+		Amount:      models.NewMoney(-price.MinorUnits, price.Currency),
+		CoffeeLogID: &coffeeLog.ID,
+		Note:        box.Name,
+		CreatedBy:   coffeeLog.UserID,
+	}
+	if err := tx.Create(&entry).Error; err != nil {
+		return fmt.Errorf("failed to charge wallet: %w", err)
+	}
+	return nil
+}
+
+// cupCharge works out what the cup just logged from box costs its consumer
+func cupCharge(tx *gorm.DB, box *models.Box) (models.Money, error) {
+	used, err := box.GetUsedCups(tx)
+	if err != nil {
+		return models.Money{}, fmt.Errorf("failed to count used cups: %w", err)
+	}
+	charged, err := boxWalletCharges(tx, box.ID, box.Price.Currency)
+	if err != nil {
+		return models.Money{}, err
+	}
+	charge := box.CostOfCups(used).Sub(charged)
+	if charge.MinorUnits < 0 {
+		// Only a price correction can leave the box charged ahead of its cups
+		return models.NewMoney(0, charge.Currency), nil
+	}
+	return charge, nil
+}
+
+// boxWalletCharges sums what wallets were charged for the cups of a box
+// that have not been voided
+func boxWalletCharges(db *gorm.DB, boxID uint, currency string) (models.Money, error) {
+	charged := models.NewMoney(0, currency)
+	err := db.Model(&models.WalletEntry{}).
+		Joins("JOIN coffee_logs ON coffee_logs.id = wallet_entries.coffee_log_id").
+		Scopes(models.NotVoided).
+		Where("coffee_logs.box_id = ? AND wallet_entries.kind = ? AND wallet_entries.amount_currency = ?",
+			boxID, models.WalletDeduction, charged.Currency).
+		Select("COALESCE(-SUM(wallet_entries.amount_minor_units), 0)").
+		Scan(&charged.MinorUnits).Error
+	if err != nil {
+		return models.Money{}, fmt.Errorf("failed to sum wallet charges: %w", err)
+	}
+	return charged, nil
+}
+
+// cupDeduction returns the wallet deduction of a coffee log, or nil if its
+// cup was not paid from a wallet
+func cupDeduction(tx *gorm.DB, coffeeLogID uint) (*models.WalletEntry, error) {
+	var deduction models.WalletEntry
+	err := tx.Where("coffee_log_id = ? AND kind = ?", coffeeLogID, models.WalletDeduction).First(&deduction).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find wallet deduction: %w", err)
+	}
+	return &deduction, nil
+}
+
+// refundCup gives back what a voided cup was charged, if anything
+func refundCup(tx *gorm.DB, coffeeLog *models.CoffeeLog, actorID uint) error {
+	deduction, err := cupDeduction(tx, coffeeLog.ID)
+	if err != nil || deduction == nil {
+		return err
+	}
+
+	refund := models.WalletEntry{
+		TeamID:      deduction.TeamID,
+		UserID:      deduction.UserID,
+		Kind:        models.WalletRefund,
+		Amount:      models.NewMoney(-deduction.Amount.MinorUnits, deduction.Amount.Currency),
+		CoffeeLogID: &coffeeLog.ID,
+		Note:        coffeeLog.VoidReason,
+		CreatedBy:   actorID,
+	}
+	if err := tx.Create(&refund).Error; err != nil {
+		return fmt.Errorf("failed to refund wallet: %w", err)
+	}
+	return nil
+}
+
+// teamBillingMode returns the billing mode of a team
+func teamBillingMode(db *gorm.DB, teamID uint) (string, error) {
+	var team models.Team
+	err := db.Select("id", "billing_mode").First(&team, teamID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", ErrTeamNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to check billing mode: %w", err)
+	}
+	return team.BillingMode, nil
+}
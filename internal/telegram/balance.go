@@ -0,0 +1,57 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"github.com/your-username/coffee-cups-system/internal/services"
+)
+
+// handleBalance handles the /balance command
+func (b *Bot) handleBalance(chatID int64, user *models.User) {
+	balance, err := b.services.Balance.GetUserBalance(user.ID)
+	if err != nil {
+		b.sendMessage(chatID, "Failed to get your balance.")
+		return
+	}
+	if len(balance.Boxes) == 0 {
+		b.sendMessage(chatID, "You haven't bought or drunk from any box yet.")
+		return
+	}
+
+	b.sendMessage(chatID, balanceSummary(balance))
+}
+
+// balanceSummary lists a user's position on each box and the totals
+func balanceSummary(balance *services.UserBalance) string {
+	var msg strings.Builder
+	msg.WriteString("💰 Your balance\n\n")
+	for _, box := range balance.Boxes {
+		status := "open"
+		if box.IsClosed {
+			status = "closed"
+		}
+		fmt.Fprintf(&msg, "☕ %s (%s): %d cups, %s, paid %s", box.BoxName, status, box.Cups, box.Cost, box.Paid)
+		if !box.Outstanding.IsZero() {
+			fmt.Fprintf(&msg, ", you owe %s", box.Outstanding)
+		}
+		if !box.OwedToUser.IsZero() {
+			fmt.Fprintf(&msg, ", owed to you %s", box.OwedToUser)
+		}
+		msg.WriteString("\n")
+	}
+
+	fmt.Fprintf(&msg, "\nYou owe: %s\nOwed to you: %s\nNet: %s",
+		joinAmounts(balance.Outstanding), joinAmounts(balance.OwedToUser), joinAmounts(balance.Net))
+	return msg.String()
+}
+
+// joinAmounts formats amounts in several currencies on one line
+func joinAmounts(amounts []models.Money) string {
+	parts := make([]string, 0, len(amounts))
+	for _, amount := range amounts {
+		parts = append(parts, amount.String())
+	}
+	return strings.Join(parts, ", ")
+}
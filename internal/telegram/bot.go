@@ -7,20 +7,34 @@ import (
 
 	telegram "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/your-username/coffee-cups-system/internal/config"
+	"github.com/your-username/coffee-cups-system/internal/logger"
 	"github.com/your-username/coffee-cups-system/internal/models"
 	"github.com/your-username/coffee-cups-system/internal/services"
 )
 
+// API is the part of the Telegram Bot API the bot depends on.
+// *tgbotapi.BotAPI implements it; tests use telegramtest.FakeAPI.
+type API interface {
+	Send(c telegram.Chattable) (telegram.Message, error)
+	Request(c telegram.Chattable) (*telegram.APIResponse, error)
+	MakeRequest(endpoint string, params telegram.Params) (*telegram.APIResponse, error)
+	GetUpdatesChan(config telegram.UpdateConfig) telegram.UpdatesChannel
+	StopReceivingUpdates()
+	GetMe() (telegram.User, error)
+}
+
 // Bot represents the Telegram bot
 type Bot struct {
-	api      *telegram.BotAPI
-	services *services.Services
-	config   config.TelegramConfig
-	logger   interface{}
+	api            API
+	services       *services.Services
+	config         config.TelegramConfig
+	logger         *logger.Logger
+	username       string
+	webhookUpdates chan telegram.Update
 }
 
-// New creates a new Telegram bot instance
-func New(cfg config.TelegramConfig, services *services.Services, logger interface{}) (*Bot, error) {
+// New creates a new Telegram bot instance connected to the Telegram API
+func New(cfg config.TelegramConfig, services *services.Services, log *logger.Logger) (*Bot, error) {
 	bot, err := telegram.NewBotAPI(cfg.Token)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create bot: %w", err)
@@ -28,21 +42,62 @@ func New(cfg config.TelegramConfig, services *services.Services, logger interfac
 
 	bot.Debug = cfg.Debug
 
-	return &Bot{
-		api:      bot,
-		services: services,
-		config:   cfg,
-		logger:   logger,
-	}, nil
+	return NewWithAPI(bot, cfg, services, log)
+}
+
+// NewWithAPI creates a new Telegram bot instance on top of the given API
+func NewWithAPI(api API, cfg config.TelegramConfig, services *services.Services, log *logger.Logger) (*Bot, error) {
+	if cfg.Mode == "" {
+		cfg.Mode = ModePolling
+	}
+	if err := validateMode(cfg.Mode, cfg.WebhookURL, cfg.WebhookSecret); err != nil {
+		return nil, err
+	}
+
+	// The username is needed to recognise commands addressed to us in groups
+	me, err := api.GetMe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bot identity: %w", err)
+	}
+
+	bot := &Bot{
+		api:            api,
+		services:       services,
+		config:         cfg,
+		logger:         log,
+		username:       me.UserName,
+		webhookUpdates: make(chan telegram.Update, webhookQueueSize),
+	}
+	services.Events.Subscribe(bot.handleEvent)
+	return bot, nil
 }
 
-// Start starts the bot and begins listening for updates
+// Start starts the bot and processes updates until ctx is cancelled.
+// In webhook mode updates arrive through WebhookHandler, otherwise they are
+// fetched by long polling. Each mode takes over from the other on startup.
 func (b *Bot) Start(ctx context.Context) error {
+	if b.config.Mode == ModeWebhook {
+		// The webhook stays registered on shutdown so other replicas keep receiving updates
+		if err := b.setWebhook(); err != nil {
+			return err
+		}
+		return b.dispatch(ctx, b.webhookUpdates)
+	}
+
+	if err := b.deleteWebhook(); err != nil {
+		return err
+	}
+
 	u := telegram.NewUpdate(0)
 	u.Timeout = 60
 
 	updates := b.api.GetUpdatesChan(u)
+	defer b.api.StopReceivingUpdates()
+	return b.dispatch(ctx, updates)
+}
 
+// dispatch routes updates to their handlers until ctx is cancelled
+func (b *Bot) dispatch(ctx context.Context, updates <-chan telegram.Update) error {
 	for {
 		select {
 		case <-ctx.Done():
@@ -51,6 +106,9 @@ func (b *Bot) Start(ctx context.Context) error {
 			if update.Message != nil {
 				b.handleMessage(update.Message)
 			}
+			if update.CallbackQuery != nil {
+				b.handleCallback(update.CallbackQuery)
+			}
 		}
 	}
 }
@@ -58,7 +116,10 @@ func (b *Bot) Start(ctx context.Context) error {
 // handleMessage handles incoming messages
 func (b *Bot) handleMessage(message *telegram.Message) {
 	chatID := message.Chat.ID
-	text := message.Text
+	text, addressed, ok := b.commandText(message)
+	if !ok {
+		return
+	}
 
 	// Create or update user
 	user, err := b.services.User.CreateOrUpdateUser(
@@ -71,6 +132,11 @@ func (b *Bot) handleMessage(message *telegram.Message) {
 		b.sendMessage(chatID, "Sorry, there was an error processing your request.")
 		return
 	}
+	b.syncRole(user)
+	b.joinChatTeam(message.Chat, user)
+	if b.continueConversation(message, user, text) {
+		return
+	}
 
 	// Handle commands
 	switch {
@@ -78,15 +144,71 @@ func (b *Bot) handleMessage(message *telegram.Message) {
 		b.handleStart(chatID, user)
 	case strings.HasPrefix(text, "/coffee"):
 		b.handleCoffee(chatID, user, text)
+	case strings.HasPrefix(text, "/undo"):
+		b.handleUndo(chatID, user)
 	case strings.HasPrefix(text, "/status"):
 		b.handleStatus(chatID, user)
+	case strings.HasPrefix(text, "/balance"):
+		b.handleBalance(chatID, user)
+	case strings.HasPrefix(text, "/wallet"):
+		b.handleWallet(message.Chat, user)
 	case strings.HasPrefix(text, "/boxes"):
-		b.handleBoxes(chatID, user)
+		b.handleBoxes(message.Chat, user)
+	case strings.HasPrefix(text, "/newbox"):
+		b.handleNewBox(message.Chat, user)
+	case strings.HasPrefix(text, "/closebox"):
+		b.handleCloseBox(chatID, user, text)
+	case strings.HasPrefix(text, "/finishbox"):
+		b.handleFinishBox(chatID, user, text)
+	case strings.HasPrefix(text, "/reopenbox"):
+		b.handleReopenBox(chatID, user, text)
+	case strings.HasPrefix(text, "/archivebox"):
+		b.handleArchiveBox(chatID, user, text)
+	case strings.HasPrefix(text, "/editbox"):
+		b.handleEditBox(chatID, user, text)
+	case strings.HasPrefix(text, "/deletebox"):
+		b.handleDeleteBox(chatID, user, text)
+	case strings.HasPrefix(text, "/lowstock"):
+		b.handleLowStock(chatID, user, text)
+	case strings.HasPrefix(text, "/settle"):
+		b.handleSettle(message.Chat, user, text)
+	case strings.HasPrefix(text, "/reminders"):
+		b.handleReminders(chatID, user, text)
+	case strings.HasPrefix(text, "/token"):
+		b.handleToken(message, user)
+	case strings.HasPrefix(text, "/linkteam"):
+		b.handleLinkTeam(message.Chat, user, text)
+	case strings.HasPrefix(text, "/addbox"):
+		b.handleAddBox(message.Chat, user, text)
 	case strings.HasPrefix(text, "/help"):
 		b.handleHelp(chatID)
 	default:
-		b.sendMessage(chatID, "I don't understand that command. Use /help to see available commands.")
+		// Stay quiet in groups unless the message was clearly meant for us
+		if addressed {
+			b.sendMessage(chatID, "I don't understand that command. Use /help to see available commands.")
+		}
+	}
+}
+
+// syncRole gives users the role the configured admin IDs call for:
+// listed users are promoted and users no longer listed are demoted
+func (b *Bot) syncRole(user *models.User) {
+	role := models.RoleMember
+	for _, adminID := range b.config.AdminIDs {
+		if adminID == user.TelegramID {
+			role = models.RoleAdmin
+			break
+		}
+	}
+	if user.Role == role {
+		return
+	}
+
+	if err := b.services.User.SetRole(user.ID, role); err != nil {
+		b.logger.WithError(err).WithField("role", role).Error("Failed to change role")
+		return
 	}
+	user.Role = role
 }
 
 // handleStart handles the /start command
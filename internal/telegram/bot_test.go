@@ -0,0 +1,394 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-username/coffee-cups-system/internal/config"
+	"github.com/your-username/coffee-cups-system/internal/database"
+	"github.com/your-username/coffee-cups-system/internal/logger"
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"github.com/your-username/coffee-cups-system/internal/services"
+	"github.com/your-username/coffee-cups-system/internal/telegram/telegramtest"
+)
+
+// newTestBot creates a bot on a fake API and a fresh in-memory database
+func newTestBot(t *testing.T, cfg config.TelegramConfig) (*Bot, *telegramtest.FakeAPI, *services.Services) {
+	db, err := database.New(config.DatabaseConfig{Driver: database.DriverSQLite, Path: ":memory:"})
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	_, err = db.EnsureSchema(true)
+	require.NoError(t, err)
+
+	svc := services.NewServices(db.DB, nil)
+	api := telegramtest.NewFakeAPI()
+	bot, err := NewWithAPI(api, cfg, svc, logger.New("error"))
+	require.NoError(t, err)
+	return bot, api, svc
+}
+
+// newTeam creates a team whose members are the given users
+func newTeam(t *testing.T, svc *services.Services, name string, members ...*models.User) *models.Team {
+	team, err := svc.Team.CreateTeam(name)
+	require.NoError(t, err)
+	for _, member := range members {
+		require.NoError(t, svc.Team.AddMember(team.ID, member.ID))
+	}
+	return team
+}
+
+// message builds an incoming text message from a Telegram user
+func message(fromID int64, chatType, text string) *tgbotapi.Message {
+	return &tgbotapi.Message{
+		From: &tgbotapi.User{ID: fromID, FirstName: "Anna"},
+		Chat: &tgbotapi.Chat{ID: fromID, Type: chatType},
+		Text: text,
+	}
+}
+
+// TestHandleMessage feeds a scripted conversation through the bot and checks every reply
+func TestHandleMessage(t *testing.T) {
+	bot, api, svc := newTestBot(t, config.TelegramConfig{UndoWindow: time.Minute})
+
+	owner, err := svc.User.CreateOrUpdateUser(99, "", "Owner", "")
+	require.NoError(t, err)
+	anna, err := svc.User.CreateOrUpdateUser(1, "", "Anna", "")
+	require.NoError(t, err)
+	team := newTeam(t, svc, "Office", owner, anna)
+	_, err = svc.Box.CreateBox("Espresso", 10, models.NewMoney(500, "EUR"), owner.ID, team.ID)
+	require.NoError(t, err)
+	_, err = svc.Box.CreateBox("Elsewhere", 10, models.NewMoney(500, "EUR"), owner.ID, newTeam(t, svc, "Other").ID)
+	require.NoError(t, err)
+
+	steps := []struct {
+		name     string
+		chatType string
+		text     string
+		want     []string
+	}{
+		{"start", "private", "/start", []string{"Welcome Anna"}},
+		{"help", "private", "/help", []string{"Available commands", "/undo"}},
+		{"status before logging", "private", "/status", []string{"haven't logged any coffee"}},
+		{"boxes", "private", "/boxes", []string{"Espresso", "Remaining: 10/10 cups"}},
+		{"coffee without box", "private", "/coffee", []string{"Usage: /coffee <box_id>"}},
+		{"coffee with bad box", "private", "/coffee abc", []string{"Invalid box ID"}},
+		{"coffee with unknown box", "private", "/coffee 42", []string{"Failed to log coffee: box not found."}},
+		{"coffee from another team", "private", "/coffee 2", []string{"not a member of this team"}},
+		{"coffee", "private", "/coffee 1", []string{"Coffee logged successfully", "Remaining cups: 9"}},
+		{"status", "private", "/status", []string{"Your recent coffee logs", "Espresso"}},
+		{"balance", "private", "/balance", []string{"Espresso (open): 1 cups, 0.50 EUR", "You owe: 0.50 EUR", "Net: -0.50 EUR"}},
+		{"undo", "private", "/undo", []string{"Removed your coffee from Espresso"}},
+		{"undo again", "private", "/undo", []string{"Nothing to undo", "within 1m0s"}},
+		{"close box as non-owner", "private", "/closebox 1", []string{"Only the person who bought this box"}},
+		{"settle", "private", "/settle", []string{"Nobody owes anything"}},
+		{"settle accept as member", "private", "/settle accept abc", []string{"Only admins"}},
+		{"reminders", "private", "/reminders", []string{"Payment reminders are on", "Usage: /reminders"}},
+		{"reminders quiet", "private", "/reminders quiet 22:00-07:30", []string{"except between 22:00-07:30"}},
+		{"reminders bad quiet", "private", "/reminders quiet late", []string{"Usage: /reminders"}},
+		{"reminders off", "private", "/reminders off", []string{"Payment reminders are off"}},
+		{"token in group", "group", "/token", []string{"private chat"}},
+		{"token", "private", "/token", []string{"Your API token", "ccs-"}},
+		{"unknown", "private", "/espresso", []string{"I don't understand"}},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			sent := len(api.Messages())
+			bot.handleMessage(message(1, step.chatType, step.text))
+
+			require.Len(t, api.Messages(), sent+1, "expected exactly one reply")
+			reply := api.LastText()
+			for _, want := range step.want {
+				assert.Contains(t, reply, want)
+			}
+		})
+	}
+}
+
+// TestBoxesKeyboard tests logging a cup by tapping a box and the repeat button
+func TestBoxesKeyboard(t *testing.T) {
+	bot, api, svc := newTestBot(t, config.TelegramConfig{})
+
+	owner, err := svc.User.CreateOrUpdateUser(99, "", "Owner", "")
+	require.NoError(t, err)
+	anna, err := svc.User.CreateOrUpdateUser(1, "", "Anna", "")
+	require.NoError(t, err)
+	team := newTeam(t, svc, "Office", owner, anna)
+	// Box names are sent as plain text, so an underscore can't break the message
+	box, err := svc.Box.CreateBox("Lungo_Forte", 3, models.NewMoney(300, "EUR"), owner.ID, team.ID)
+	require.NoError(t, err)
+
+	bot.handleMessage(message(1, "private", "/boxes"))
+	assert.Contains(t, api.Messages()[0].Text, "ID: 1 - Lungo_Forte")
+	assert.Empty(t, api.Messages()[0].ParseMode)
+	keyboard, ok := api.Messages()[0].ReplyMarkup.(*tgbotapi.InlineKeyboardMarkup)
+	require.True(t, ok, "expected an inline keyboard")
+	require.Len(t, keyboard.InlineKeyboard, 1, "no repeat button before the first coffee")
+	assert.Equal(t, "coffee:1", *keyboard.InlineKeyboard[0][0].CallbackData)
+
+	boxesMessage := &tgbotapi.Message{MessageID: 7, Chat: &tgbotapi.Chat{ID: 1, Type: "private"}}
+	taps := []struct {
+		data       string
+		wantAnswer string
+		wantUsed   int
+	}{
+		{"coffee:last", "haven't logged any coffee", 0},
+		{"coffee:1", "Coffee logged", 1},
+		{"coffee:last", "Coffee logged", 2},
+		{"coffee:abc", "Invalid box", 2},
+		{"settle", "no longer supported", 2},
+	}
+
+	for _, tap := range taps {
+		bot.handleCallback(&tgbotapi.CallbackQuery{
+			ID:      tap.data,
+			From:    &tgbotapi.User{ID: 1, FirstName: "Anna"},
+			Message: boxesMessage,
+			Data:    tap.data,
+		})
+
+		answers := api.CallbackAnswers()
+		assert.Contains(t, answers[len(answers)-1], tap.wantAnswer, tap.data)
+		used, err := box.GetUsedCups(svc.Coffee.GetDB())
+		require.NoError(t, err)
+		assert.Equal(t, tap.wantUsed, used, tap.data)
+	}
+
+	// Every successful tap edits the /boxes message in place
+	edits := api.Edits()
+	require.Len(t, edits, 2)
+	assert.Equal(t, 7, edits[1].MessageID)
+	assert.Contains(t, edits[1].Text, "Remaining: 1/3 cups")
+	assert.Empty(t, edits[1].ParseMode)
+	keyboard = edits[1].ReplyMarkup
+	require.NotNil(t, keyboard, "expected an inline keyboard")
+	require.Len(t, keyboard.InlineKeyboard, 2)
+	assert.Equal(t, "coffee:last", *keyboard.InlineKeyboard[1][0].CallbackData)
+}
+
+// TestWebhookHandler tests secret verification and queueing of webhook updates
+func TestWebhookHandler(t *testing.T) {
+	cfg := config.TelegramConfig{Mode: ModeWebhook, WebhookURL: "https://example.com/hook", WebhookSecret: "s3cret"}
+	bot, _, _ := newTestBot(t, cfg)
+
+	tests := []struct {
+		name   string
+		secret string
+		body   string
+		want   int
+	}{
+		{"missing secret", "", `{"update_id": 1}`, http.StatusUnauthorized},
+		{"wrong secret", "guess", `{"update_id": 1}`, http.StatusUnauthorized},
+		{"invalid body", "s3cret", `{`, http.StatusBadRequest},
+		{"valid update", "s3cret", `{"update_id": 1, "message": {"text": "/help"}}`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/telegram/webhook", bytes.NewBufferString(tt.body))
+			if tt.secret != "" {
+				req.Header.Set(secretTokenHeader, tt.secret)
+			}
+			rr := httptest.NewRecorder()
+			bot.WebhookHandler().ServeHTTP(rr, req)
+			assert.Equal(t, tt.want, rr.Code)
+		})
+	}
+
+	require.Len(t, bot.webhookUpdates, 1)
+	update := <-bot.webhookUpdates
+	assert.Equal(t, "/help", update.Message.Text)
+
+	_, err := NewWithAPI(telegramtest.NewFakeAPI(), config.TelegramConfig{Mode: ModeWebhook}, nil, nil)
+	assert.Error(t, err)
+	_, err = NewWithAPI(telegramtest.NewFakeAPI(), config.TelegramConfig{Mode: "carrier-pigeon"}, nil, nil)
+	assert.True(t, err != nil && strings.Contains(err.Error(), "unsupported"))
+}
+
+// TestStartPolling tests that polling removes any webhook and dispatches updates
+func TestStartPolling(t *testing.T) {
+	bot, api, _ := newTestBot(t, config.TelegramConfig{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- bot.Start(ctx) }()
+
+	api.Updates <- tgbotapi.Update{Message: message(1, "private", "/start")}
+	require.Eventually(t, func() bool { return api.LastText() != "" }, time.Second, 10*time.Millisecond)
+	cancel()
+
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.Contains(t, api.LastText(), "Welcome Anna")
+	_, deleted := api.Requests()[0].(tgbotapi.DeleteWebhookConfig)
+	assert.True(t, deleted, "expected the webhook to be deleted before polling")
+}
+
+// TestGroupChat tests command addressing, team linking and group announcements
+func TestGroupChat(t *testing.T) {
+	bot, api, svc := newTestBot(t, config.TelegramConfig{AdminIDs: []int64{5}})
+
+	owner, err := svc.User.CreateOrUpdateUser(1, "", "Anna", "")
+	require.NoError(t, err)
+	lobby := newTeam(t, svc, "Lobby", owner)
+	_, err = svc.Box.CreateBox("Espresso", 2, models.NewMoney(400, "EUR"), owner.ID, lobby.ID)
+	require.NoError(t, err)
+	_, err = svc.Box.CreateBox("Other Floor", 10, models.NewMoney(500, "EUR"), owner.ID, lobby.ID)
+	require.NoError(t, err)
+
+	group := func(fromID int64, text string) *tgbotapi.Message {
+		return &tgbotapi.Message{
+			From: &tgbotapi.User{ID: fromID, FirstName: "Anna"},
+			Chat: &tgbotapi.Chat{ID: -100, Type: "supergroup", Title: "Floor 3"},
+			Text: text,
+		}
+	}
+
+	steps := []struct {
+		name   string
+		fromID int64
+		text   string
+		want   string // "" means the bot stays quiet
+		alert  string // stock alert sent to the box owner first, if any
+	}{
+		{"chatter", 1, "who wants coffee?", "", ""},
+		{"unknown command", 1, "/espresso", "", ""},
+		{"command for another bot", 1, "/boxes@other_bot", "", ""},
+		{"unknown command addressed to us", 1, "/espresso@coffee_bot", "I don't understand", ""},
+		{"boxes before linking", 1, "/boxes", "not linked to a team yet", ""},
+		{"link as member", 1, "/linkteam", "Only admins", ""},
+		{"link as admin", 5, "/linkteam@coffee_bot", "This group is now team Floor 3", ""},
+		{"no team boxes yet", 1, "/boxes@coffee_bot", "No active boxes", ""},
+		{"add box", 1, "/addbox@coffee_bot 1", "Box Espresso now belongs to team Floor 3", ""},
+		{"team boxes", 1, "/boxes", "Espresso", ""},
+		{"coffee", 1, "/coffee@Coffee_Bot 1", "Coffee logged successfully", "Only 1 cups left in Espresso"},
+		{"newcomer joins the team", 7, "/coffee 1", "Coffee logged successfully", "Espresso (box #1) is empty"},
+		{"settle team", 1, "/settle", "Nobody owes anything", ""},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			sent := len(api.Messages())
+			bot.handleMessage(group(step.fromID, step.text))
+
+			if step.want == "" {
+				assert.Len(t, api.Messages(), sent)
+				return
+			}
+			if step.alert != "" {
+				require.Len(t, api.Messages(), sent+2)
+				alert := api.Messages()[sent]
+				assert.Equal(t, owner.TelegramID, alert.ChatID)
+				assert.Contains(t, alert.Text, step.alert)
+			} else {
+				require.Len(t, api.Messages(), sent+1)
+			}
+			assert.Contains(t, api.LastText(), step.want)
+		})
+	}
+	assert.NotContains(t, api.LastText(), "Other Floor")
+
+	// A box is finished before it is closed
+	bot.handleMessage(message(1, "private", "/closebox 1"))
+	assert.Contains(t, api.LastText(), "finish it before closing it")
+	bot.handleMessage(message(1, "private", "/finishbox 1"))
+	assert.Contains(t, api.LastText(), "Espresso (box #1) is finished")
+
+	// Closing the box from a private chat announces it in the team group
+	bot.handleMessage(message(1, "private", "/closebox 1"))
+
+	messages := api.Messages()
+	require.GreaterOrEqual(t, len(messages), 2)
+	announcement := messages[len(messages)-2]
+	assert.Equal(t, int64(-100), announcement.ChatID)
+	assert.Contains(t, announcement.Text, "Box Espresso is closed")
+	assert.Equal(t, int64(1), messages[len(messages)-1].ChatID)
+
+	// The settlement plan lists the newcomer's debt and is announced in the group once accepted
+	bot.handleMessage(group(7, "/settle"))
+	assert.Contains(t, api.LastText(), "Settlement plan for Floor 3")
+	floor, err := svc.Team.GetTeamByChatID(-100)
+	require.NoError(t, err)
+	plan, err := svc.Settlement.GetPlan(floor.ID)
+	require.NoError(t, err)
+	bot.handleMessage(message(5, "private", "/settle accept "+plan.Token))
+	messages = api.Messages()
+	assert.Equal(t, int64(-100), messages[len(messages)-2].ChatID)
+	assert.Contains(t, messages[len(messages)-1].Text, "Settlement #1 recorded for Floor 3")
+}
+
+// TestSendFailureLogged tests that a message Telegram refuses is logged
+func TestSendFailureLogged(t *testing.T) {
+	bot, api, _ := newTestBot(t, config.TelegramConfig{})
+	var logs bytes.Buffer
+	bot.logger.SetOutput(&logs)
+
+	api.SendErr = errors.New("Bad Request: can't parse entities")
+	bot.handleMessage(message(1, "private", "/help"))
+	assert.Empty(t, api.Messages())
+	assert.Contains(t, logs.String(), "Failed to send message")
+	assert.Contains(t, logs.String(), "can't parse entities")
+	assert.Contains(t, logs.String(), `"chat_id":1`)
+}
+
+// TestGroupMessagesPlain tests that group messages naming teams, boxes and
+// users are sent as plain text, so an underscore can't break their Markdown
+func TestGroupMessagesPlain(t *testing.T) {
+	bot, api, svc := newTestBot(t, config.TelegramConfig{AdminIDs: []int64{5}})
+
+	owner, err := svc.User.CreateOrUpdateUser(1, "", "Anna_B", "")
+	require.NoError(t, err)
+	ben, err := svc.User.CreateOrUpdateUser(2, "", "Ben_C", "")
+	require.NoError(t, err)
+	lobby := newTeam(t, svc, "Lobby", owner)
+	box, err := svc.Box.CreateBox("Lavazza_Crema", 10, models.NewMoney(1000, "EUR"), owner.ID, lobby.ID)
+	require.NoError(t, err)
+
+	group := &tgbotapi.Chat{ID: -100, Type: "group", Title: "Floor_3"}
+	owner.Role = models.RoleAdmin
+	bot.handleLinkTeam(group, owner, "/linkteam")
+	bot.handleAddBox(group, owner, "/addbox 1")
+	floor, err := svc.Team.GetTeamByChatID(group.ID)
+	require.NoError(t, err)
+	require.NoError(t, svc.Team.AddMember(floor.ID, ben.ID))
+	_, err = svc.Coffee.LogCoffee(ben.ID, box.ID)
+	require.NoError(t, err)
+	_, err = svc.Box.FinishBox(box.ID)
+	require.NoError(t, err)
+	_, err = svc.Box.CloseBox(box.ID)
+	require.NoError(t, err)
+
+	messages := api.Messages()
+	require.Len(t, messages, 3)
+	assert.Contains(t, messages[0].Text, "team Floor_3")
+	assert.Contains(t, messages[1].Text, "Box Lavazza_Crema now belongs to team Floor_3")
+	assert.Contains(t, messages[2].Text, "Ben_C owes 1.00 EUR")
+	for _, msg := range messages {
+		assert.Equal(t, int64(-100), msg.ChatID)
+		assert.Empty(t, msg.ParseMode, msg.Text)
+	}
+}
+
+// TestSyncRole tests that the admin role follows the configured admin IDs
+func TestSyncRole(t *testing.T) {
+	bot, _, svc := newTestBot(t, config.TelegramConfig{AdminIDs: []int64{5}})
+
+	bot.handleMessage(message(5, "private", "/start"))
+	user, err := svc.User.GetUserByTelegramID(5)
+	require.NoError(t, err)
+	assert.True(t, user.IsAdmin())
+
+	bot.config.AdminIDs = nil
+	bot.handleMessage(message(5, "private", "/start"))
+	user, err = svc.User.GetUserByTelegramID(5)
+	require.NoError(t, err)
+	assert.False(t, user.IsAdmin())
+}
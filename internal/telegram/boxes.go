@@ -0,0 +1,143 @@
+package telegram
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"github.com/your-username/coffee-cups-system/internal/services"
+)
+
+// ownedBox loads a box by the ID given in a command, replying and returning
+// false unless it exists and the user bought it. action completes
+// "Only the person who bought this box can ...".
+func (b *Bot) ownedBox(chatID int64, user *models.User, boxID, action string) (*models.Box, bool) {
+	id, err := strconv.ParseUint(boxID, 10, 32)
+	if err != nil {
+		b.sendMessage(chatID, "Invalid box ID. Please provide a valid number.")
+		return nil, false
+	}
+
+	box, err := b.services.Box.GetBoxByID(uint(id))
+	if err != nil {
+		b.sendMessage(chatID, "Box not found.")
+		return nil, false
+	}
+	if box.CreatedBy != user.ID {
+		b.sendMessage(chatID, fmt.Sprintf("Only the person who bought this box can %s.", action))
+		return nil, false
+	}
+	return box, true
+}
+
+// handleFinishBox handles the /finishbox command: /finishbox <box_id>
+func (b *Bot) handleFinishBox(chatID int64, user *models.User, text string) {
+	b.changeBoxStatus(chatID, user, text, "finish", b.services.Box.FinishBox, func(box *models.Box) string {
+		return fmt.Sprintf("🏁 %s (box #%d) is finished, no more cups can be logged from it. "+
+			"Close it with /closebox %d to split the cost.", box.Name, box.ID, box.ID)
+	})
+}
+
+// handleReopenBox handles the /reopenbox command: /reopenbox <box_id>
+func (b *Bot) handleReopenBox(chatID int64, user *models.User, text string) {
+	b.changeBoxStatus(chatID, user, text, "reopen", b.services.Box.ReopenBox, func(box *models.Box) string {
+		if box.Status == models.BoxOpen {
+			return fmt.Sprintf("☕ %s (box #%d) is open again.", box.Name, box.ID)
+		}
+		return fmt.Sprintf("📦 %s (box #%d) is no longer archived.", box.Name, box.ID)
+	})
+}
+
+// handleArchiveBox handles the /archivebox command: /archivebox <box_id>
+func (b *Bot) handleArchiveBox(chatID int64, user *models.User, text string) {
+	b.changeBoxStatus(chatID, user, text, "archive", b.services.Box.ArchiveBox, func(box *models.Box) string {
+		return fmt.Sprintf("🗄 %s (box #%d) is archived.", box.Name, box.ID)
+	})
+}
+
+// changeBoxStatus moves the user's box named in a "/<command> <box_id>"
+// message to another status and replies with done's description of the result
+func (b *Bot) changeBoxStatus(chatID int64, user *models.User, text, action string,
+	transition func(uint) (*models.Box, error), done func(*models.Box) string) {
+	parts := strings.Fields(text)
+	if len(parts) != 2 {
+		b.sendMessage(chatID, fmt.Sprintf("Usage: %s <box_id>", parts[0]))
+		return
+	}
+	box, ok := b.ownedBox(chatID, user, parts[1], action+" it")
+	if !ok {
+		return
+	}
+
+	box, err := transition(box.ID)
+	if err != nil {
+		b.sendMessage(chatID, b.failureMessage(action+" box", err))
+		return
+	}
+	b.sendMessage(chatID, done(box))
+}
+
+// handleEditBox handles the /editbox command to correct a box:
+// /editbox <box_id> name|cups|price <value>
+func (b *Bot) handleEditBox(chatID int64, user *models.User, text string) {
+	parts := strings.Fields(text)
+	if len(parts) < 4 {
+		b.sendMessage(chatID, "Usage: /editbox <box_id> name|cups|price <value>")
+		return
+	}
+	box, ok := b.ownedBox(chatID, user, parts[1], "change it")
+	if !ok {
+		return
+	}
+
+	var changes services.BoxChanges
+	value := strings.Join(parts[3:], " ")
+	switch parts[2] {
+	case "name":
+		changes.Name = &value
+	case "cups":
+		cups, err := strconv.Atoi(value)
+		if err != nil {
+			b.sendMessage(chatID, "Invalid number of cups.")
+			return
+		}
+		changes.TotalCups = &cups
+	case "price":
+		price, err := models.ParseMoney(value, box.Price.Currency)
+		if err != nil {
+			b.sendMessage(chatID, "Invalid price. Use a number like 15.99.")
+			return
+		}
+		changes.Price = &price
+	default:
+		b.sendMessage(chatID, "Usage: /editbox <box_id> name|cups|price <value>")
+		return
+	}
+
+	box, err := b.services.Box.UpdateBox(box.ID, changes)
+	if err != nil {
+		b.sendMessage(chatID, b.failureMessage("update box", err))
+		return
+	}
+	b.sendMessage(chatID, fmt.Sprintf("✏️ Box #%d is now %s: %d cups for %s.", box.ID, box.Name, box.TotalCups, box.Price))
+}
+
+// handleDeleteBox handles the /deletebox command: /deletebox <box_id>
+func (b *Bot) handleDeleteBox(chatID int64, user *models.User, text string) {
+	parts := strings.Fields(text)
+	if len(parts) != 2 {
+		b.sendMessage(chatID, "Usage: /deletebox <box_id>")
+		return
+	}
+	box, ok := b.ownedBox(chatID, user, parts[1], "delete it")
+	if !ok {
+		return
+	}
+
+	if err := b.services.Box.DeleteBox(box.ID); err != nil {
+		b.sendMessage(chatID, b.failureMessage("delete box", err))
+		return
+	}
+	b.sendMessage(chatID, fmt.Sprintf("🗑 Deleted %s (box #%d).", box.Name, box.ID))
+}
@@ -0,0 +1,48 @@
+package telegram
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-username/coffee-cups-system/internal/config"
+	"github.com/your-username/coffee-cups-system/internal/models"
+)
+
+// TestBoxOwnerCommands tests correcting, finishing, archiving and deleting boxes from the bot
+func TestBoxOwnerCommands(t *testing.T) {
+	bot, api, svc := newTestBot(t, config.TelegramConfig{})
+
+	owner, err := svc.User.CreateOrUpdateUser(99, "", "Owner", "")
+	require.NoError(t, err)
+	anna, err := svc.User.CreateOrUpdateUser(1, "", "Anna", "")
+	require.NoError(t, err)
+	team := newTeam(t, svc, "Office", owner, anna)
+	box, err := svc.Box.CreateBox("Ristretto", 10, models.NewMoney(1000, "EUR"), owner.ID, team.ID)
+	require.NoError(t, err)
+	_, err = svc.Box.CreateBox("Mistake", 10, models.NewMoney(1000, "EUR"), owner.ID, team.ID)
+	require.NoError(t, err)
+	_, err = svc.Coffee.LogCoffee(anna.ID, box.ID)
+	require.NoError(t, err)
+
+	steps := []struct {
+		from  int64
+		text  string
+		reply string
+	}{
+		{1, "/finishbox 1", "Only the person who bought this box can finish it."},
+		{99, "/editbox 1 cups 0", "Failed to update box: total_cups must be between 1 and 10000, total_cups must be at least 1, the number of cups already taken"},
+		{99, "/editbox 1 price 12.50", "✏️ Box #1 is now Ristretto: 10 cups for 12.50 EUR."},
+		{99, "/editbox 1 name Double Ristretto", "✏️ Box #1 is now Double Ristretto: 10 cups for 12.50 EUR."},
+		{99, "/archivebox 1", "Failed to archive box: this box is open and can't be archived."},
+		{99, "/finishbox 1", "🏁 Double Ristretto (box #1) is finished"},
+		{99, "/reopenbox 1", "☕ Double Ristretto (box #1) is open again."},
+		{99, "/deletebox 1", "Failed to delete box: a box with cups or payments can't be deleted"},
+		{99, "/deletebox 2", "🗑 Deleted Mistake (box #2)."},
+		{99, "/finishbox", "Usage: /finishbox <box_id>"},
+	}
+	for _, step := range steps {
+		bot.handleMessage(message(step.from, "private", step.text))
+		assert.Contains(t, api.LastText(), step.reply, step.text)
+	}
+}
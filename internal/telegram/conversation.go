@@ -0,0 +1,104 @@
+package telegram
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/your-username/coffee-cups-system/internal/models"
+)
+
+// conversationTimeout is how long a conversation waits for its next answer
+// before it is dropped
+const conversationTimeout = time.Hour
+
+// Commands that steer the conversation in progress instead of starting something new
+const (
+	commandBack    = "/back"
+	commandCancel  = "/cancel"
+	commandSkip    = "/skip"
+	commandConfirm = "/confirm"
+)
+
+// conversationCommands are the commands handed to a conversation in progress
+var conversationCommands = map[string]bool{
+	commandBack:    true,
+	commandCancel:  true,
+	commandSkip:    true,
+	commandConfirm: true,
+}
+
+// continueConversation hands a private message to the chat's conversation in
+// progress and reports whether it did. Answers, photos and the conversation
+// commands belong to the conversation; other commands are handled as usual.
+func (b *Bot) continueConversation(message *tgbotapi.Message, user *models.User, text string) bool {
+	command := firstWord(text)
+	if !message.Chat.IsPrivate() || (strings.HasPrefix(command, "/") && !conversationCommands[command]) {
+		return false
+	}
+
+	conversation, err := b.loadConversation(message.Chat.ID)
+	if err != nil {
+		b.logFailure(message.Chat.ID, "load conversation", err)
+		b.sendMessage(message.Chat.ID, "Sorry, there was an error processing your request.")
+		return true
+	}
+	if conversation == nil {
+		if conversationCommands[command] {
+			b.sendMessage(message.Chat.ID, "There is nothing in progress. Use /newbox to add a box.")
+			return true
+		}
+		return false
+	}
+
+	switch conversation.Command {
+	case commandNewBox:
+		b.continueNewBox(message, user, conversation, text)
+	default:
+		b.endConversation(message.Chat.ID)
+		b.sendMessage(message.Chat.ID, "Sorry, I lost track of what we were doing. Please start again.")
+	}
+	return true
+}
+
+// loadConversation returns the chat's conversation, dropping it once it
+// has waited longer than conversationTimeout
+func (b *Bot) loadConversation(chatID int64) (*models.Conversation, error) {
+	conversation, err := b.services.Conversation.GetConversation(chatID)
+	if err != nil || conversation == nil {
+		return nil, err
+	}
+	if time.Since(conversation.UpdatedAt) > conversationTimeout {
+		return nil, b.services.Conversation.EndConversation(chatID)
+	}
+	return conversation, nil
+}
+
+// saveConversation moves a conversation to step with the answers given so far
+func (b *Bot) saveConversation(conversation *models.Conversation, step string, data interface{}) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode conversation data: %w", err)
+	}
+	conversation.Step = step
+	conversation.Data = string(encoded)
+	return b.services.Conversation.SaveConversation(conversation)
+}
+
+// endConversation forgets the chat's conversation
+func (b *Bot) endConversation(chatID int64) {
+	if err := b.services.Conversation.EndConversation(chatID); err != nil {
+		b.logFailure(chatID, "end conversation", err)
+	}
+}
+
+// firstWord returns the first word of text, or "" if there is none
+func firstWord(text string) string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
@@ -0,0 +1,187 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"github.com/your-username/coffee-cups-system/internal/services"
+)
+
+// unlinkedGroupMessage is the reply to team commands in a group without a team
+const unlinkedGroupMessage = "This chat is not linked to a team yet. An admin can link it with /linkteam <name>."
+
+// commandText returns the message text with any "@botname" suffix removed from
+// the command. In group chats only commands are handled and commands for other
+// bots are ignored; addressed reports whether the message was clearly for us.
+func (b *Bot) commandText(message *tgbotapi.Message) (text string, addressed bool, ok bool) {
+	private := message.Chat.IsPrivate()
+	fields := strings.Fields(message.Text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return message.Text, private, private
+	}
+
+	command, mention, found := strings.Cut(fields[0], "@")
+	if !found {
+		return message.Text, private, true
+	}
+	if !strings.EqualFold(mention, b.username) {
+		return "", false, false
+	}
+	return command + strings.TrimPrefix(message.Text, fields[0]), true, true
+}
+
+// handleLinkTeam handles the /linkteam command, linking a group chat to a team
+func (b *Bot) handleLinkTeam(chat *tgbotapi.Chat, user *models.User, text string) {
+	if chat.IsPrivate() {
+		b.sendMessage(chat.ID, "Use /linkteam in the group chat of your team.")
+		return
+	}
+	if !user.IsAdmin() {
+		b.sendMessage(chat.ID, "Only admins can link a group to a team.")
+		return
+	}
+
+	name := strings.TrimSpace(strings.TrimPrefix(text, "/linkteam"))
+	if name == "" {
+		name = chat.Title
+	}
+	if name == "" {
+		b.sendMessage(chat.ID, "Usage: /linkteam <team name>")
+		return
+	}
+
+	team, err := b.services.Team.LinkChat(chat.ID, name)
+	if err != nil {
+		b.sendMessage(chat.ID, "Failed to link this group to a team.")
+		return
+	}
+	if err := b.services.Team.AddMember(team.ID, user.ID); err != nil {
+		b.sendMessage(chat.ID, "Failed to add you to the team.")
+		return
+	}
+
+	b.sendText(chat.ID, fmt.Sprintf("👥 This group is now team %s. Everyone who talks to me here joins it. "+
+		"Use /addbox <box_id> to move a box here.", team.Name))
+}
+
+// handleAddBox handles the /addbox command, moving a box to the group's team
+func (b *Bot) handleAddBox(chat *tgbotapi.Chat, user *models.User, text string) {
+	parts := strings.Fields(text)
+	if len(parts) != 2 {
+		b.sendMessage(chat.ID, "Usage: /addbox <box_id>")
+		return
+	}
+
+	boxID, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		b.sendMessage(chat.ID, "Invalid box ID. Please provide a valid number.")
+		return
+	}
+
+	team, err := b.services.Team.GetTeamByChatID(chat.ID)
+	if errors.Is(err, services.ErrTeamNotFound) {
+		b.sendMessage(chat.ID, unlinkedGroupMessage)
+		return
+	}
+	if err != nil {
+		b.sendMessage(chat.ID, "Failed to look up this group's team.")
+		return
+	}
+
+	box, err := b.services.Box.GetBoxByID(uint(boxID))
+	if err != nil {
+		b.sendMessage(chat.ID, "Box not found.")
+		return
+	}
+	if box.CreatedBy != user.ID && !user.IsAdmin() {
+		b.sendMessage(chat.ID, "Only the person who bought this box can add it to a team.")
+		return
+	}
+
+	if err := b.services.Box.AssignTeam(box.ID, team.ID); err != nil {
+		b.sendMessage(chat.ID, "Failed to add the box to this team.")
+		return
+	}
+
+	b.sendText(chat.ID, fmt.Sprintf("📦 Box %s now belongs to team %s.", box.Name, team.Name))
+}
+
+// joinChatTeam makes anyone who talks to the bot in a linked group a member of its team
+func (b *Bot) joinChatTeam(chat *tgbotapi.Chat, user *models.User) {
+	if chat == nil || chat.IsPrivate() {
+		return
+	}
+	team, err := b.services.Team.GetTeamByChatID(chat.ID)
+	if err != nil {
+		return
+	}
+	if err := b.services.Team.AddMember(team.ID, user.ID); err != nil {
+		b.logFailure(chat.ID, "add team member", err)
+	}
+}
+
+// chatBoxes returns the active boxes shown in a chat: a group sees its
+// team's boxes, a private chat the boxes of every team the user is in.
+// An unlinked group returns services.ErrTeamNotFound.
+func (b *Bot) chatBoxes(chat *tgbotapi.Chat, user *models.User) ([]models.Box, error) {
+	if chat.IsPrivate() {
+		return b.services.Box.GetActiveUserBoxes(user.ID)
+	}
+	team, err := b.services.Team.GetTeamByChatID(chat.ID)
+	if err != nil {
+		return nil, err
+	}
+	return b.services.Box.GetActiveTeamBoxes(team.ID)
+}
+
+// teamChatID returns the group chat of a team, if it has one
+func (b *Bot) teamChatID(teamID uint) (int64, bool) {
+	team, err := b.services.Team.GetTeamByID(teamID)
+	if err != nil || team.ChatID == nil {
+		return 0, false
+	}
+	return *team.ChatID, true
+}
+
+// handleEvent announces service events to the chats they concern
+func (b *Bot) handleEvent(event services.Event) {
+	switch e := event.(type) {
+	case services.BoxClosedEvent:
+		if chatID, ok := b.teamChatID(e.Settlement.Box.TeamID); ok {
+			b.sendText(chatID, boxSettlementSummary(e.Settlement))
+		}
+	case services.BoxLowStockEvent:
+		b.announceStock(&e.Box, lowStockMessage(&e.Box, e.Remaining))
+	case services.BoxEmptyEvent:
+		b.announceStock(&e.Box, emptyBoxMessage(&e.Box))
+	case services.SettlementAcceptedEvent:
+		if chatID, ok := b.teamChatID(e.Settlement.TeamID); ok {
+			b.sendText(chatID, settlementSummary(e.Settlement, e.Plan))
+		}
+	}
+}
+
+// boxSettlementSummary describes who owes what for a closed box
+func boxSettlementSummary(settlement *services.BoxSettlement) string {
+	msg := fmt.Sprintf("📦 Box %s is closed.\n\n", settlement.Box.Name)
+	if len(settlement.Payments) == 0 {
+		msg += "Nobody owes anything for this box."
+	}
+	for _, payment := range settlement.Payments {
+		msg += fmt.Sprintf("%s owes %s\n", payment.User.FirstName, payment.Amount)
+	}
+	return msg
+}
+
+// settlementSummary lists the transfers of an accepted settlement plan
+func settlementSummary(settlement *models.Settlement, plan *services.SettlementPlan) string {
+	msg := fmt.Sprintf("✅ Settlement #%d recorded, all %d covered payments are paid.\n\n", settlement.ID, len(plan.PaymentIDs))
+	for _, transfer := range plan.Transfers {
+		msg += fmt.Sprintf("%s paid %s %s\n", transfer.FromName, transfer.ToName, transfer.Amount)
+	}
+	return msg
+}
@@ -1,12 +1,14 @@
 package telegram
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/your-username/coffee-cups-system/internal/models"
+	"github.com/your-username/coffee-cups-system/internal/services"
 )
 
 // handleCoffee handles the /coffee command
@@ -27,7 +29,7 @@ func (b *Bot) handleCoffee(chatID int64, user *models.User, text string) {
 	// Log the coffee
 	_, err = b.services.Coffee.LogCoffee(user.ID, uint(boxID))
 	if err != nil {
-		b.sendMessage(chatID, "Failed to log coffee: "+err.Error())
+		b.sendMessage(chatID, b.failureMessage("log coffee", err))
 		return
 	}
 
@@ -46,6 +48,29 @@ func (b *Bot) handleCoffee(chatID int64, user *models.User, text string) {
 	}
 
 	msg := fmt.Sprintf("☕ Coffee logged successfully!\n\nBox: %s\nRemaining cups: %d", box.Name, remaining)
+	if note := b.walletNote(user, box); note != "" {
+		msg += "\n" + note
+	}
+	b.sendMessage(chatID, msg)
+}
+
+// handleUndo handles the /undo command by voiding the user's last coffee
+func (b *Bot) handleUndo(chatID int64, user *models.User) {
+	coffeeLog, err := b.services.Coffee.UndoLastCoffee(user.ID, b.config.UndoWindow)
+	switch {
+	case errors.Is(err, services.ErrNothingToUndo):
+		b.sendMessage(chatID, fmt.Sprintf("Nothing to undo. Only the coffee you logged last can be undone, within %s.", b.config.UndoWindow))
+		return
+	case errors.Is(err, services.ErrCoffeeLogSettled):
+		b.sendMessage(chatID, "That coffee's box is already closed, so it can no longer be undone.")
+		return
+	case err != nil:
+		b.sendMessage(chatID, b.failureMessage("undo coffee", err))
+		return
+	}
+
+	msg := fmt.Sprintf("↩️ Removed your coffee from %s logged at %s.",
+		coffeeLog.Box.Name, coffeeLog.LoggedAt.Format("15:04"))
 	b.sendMessage(chatID, msg)
 }
 
@@ -72,27 +97,60 @@ func (b *Bot) handleStatus(chatID int64, user *models.User) {
 }
 
 // handleBoxes handles the /boxes command
-func (b *Bot) handleBoxes(chatID int64, _ *models.User) {
-	boxes, err := b.services.Box.GetActiveBoxes()
+func (b *Bot) handleBoxes(chat *tgbotapi.Chat, user *models.User) {
+	text, keyboard, err := b.boxesMessage(chat, user)
 	if err != nil {
-		b.sendMessage(chatID, "Failed to get available boxes.")
+		b.sendMessage(chat.ID, "Failed to get available boxes.")
+		return
+	}
+
+	b.sendKeyboard(chat.ID, text, keyboard)
+}
+
+// handleCloseBox handles the /closebox command
+func (b *Bot) handleCloseBox(chatID int64, user *models.User, text string) {
+	// Parse box ID from command: /closebox <box_id>
+	parts := strings.Fields(text)
+	if len(parts) != 2 {
+		b.sendMessage(chatID, "Usage: /closebox <box_id>")
 		return
 	}
 
-	if len(boxes) == 0 {
-		b.sendMessage(chatID, "No active boxes available.")
+	box, ok := b.ownedBox(chatID, user, parts[1], "close it")
+	if !ok {
 		return
 	}
 
-	msg := "📦 Available coffee boxes:\n\n"
-	for _, box := range boxes {
-		remaining, _ := box.GetRemainingCups(b.services.Coffee.GetDB())
-		msg += fmt.Sprintf("ID: %d - %s\nPrice: $%.2f\nRemaining: %d/%d cups\n\n",
-			box.ID, box.Name, box.Price, remaining, box.TotalCups)
+	settlement, err := b.services.Box.CloseBox(box.ID)
+	if err != nil {
+		b.sendMessage(chatID, b.failureMessage("close box", err))
+		return
 	}
 
-	msg += "Use /coffee <box_id> to log a coffee."
-	b.sendMessage(chatID, msg)
+	// Closing the box has already been announced in its team's group
+	if teamChat, ok := b.teamChatID(box.TeamID); ok && teamChat == chatID && !box.IsClosed() {
+		return
+	}
+	b.sendText(chatID, boxSettlementSummary(settlement))
+}
+
+// handleToken handles the /token command by issuing a new API token
+func (b *Bot) handleToken(message *tgbotapi.Message, user *models.User) {
+	// Never post a token into a group chat
+	if !message.Chat.IsPrivate() {
+		b.sendMessage(message.Chat.ID, "Please ask me for a token in a private chat.")
+		return
+	}
+
+	token, err := b.services.Auth.IssueToken(user.ID)
+	if err != nil {
+		b.sendMessage(message.Chat.ID, "Failed to issue a token.")
+		return
+	}
+
+	msg := fmt.Sprintf("🔑 Your API token:\n\n`%s`\n\nUse it as `Authorization: Bearer <token>`. "+
+		"Any token issued before is now revoked.", token)
+	b.sendMessage(message.Chat.ID, msg)
 }
 
 // handleHelp handles the /help command
@@ -102,28 +160,80 @@ func (b *Bot) handleHelp(chatID int64) {
 Available commands:
 /start - Start using the bot
 /coffee <box_id> - Log a coffee consumption
+/undo - Remove the coffee you logged last, shortly after logging it
 /status - View your recent coffee logs
-/boxes - View available coffee boxes
+/balance - See what you owe and are owed across all boxes
+/wallet - Show your prepaid wallet balance and history
+/boxes - View available coffee boxes and tap one to log a coffee
+/newbox - Add a box you bought, step by step (private chat only)
+/finishbox <box_id> - Mark a box you bought as used up
+/closebox <box_id> - Close a finished box you bought and split its cost
+/archivebox <box_id> - Put away a closed box you bought
+/reopenbox <box_id> - Undo finishing or archiving a box you bought
+/editbox <box_id> name|cups|price <value> - Correct a box you bought
+/deletebox <box_id> - Delete a box you bought before anyone used it
+/lowstock <box_id> <cups> - Get warned when a box you bought is down to this many cups
+/settle - Show who should pay whom to clear your teams' debts
+/reminders on|off - Turn payment reminders on or off
+/reminders quiet HH:MM-HH:MM - Get no reminders during these hours
+/token - Get a personal API token (private chat only)
+
+In a group chat:
+/linkteam <name> - Link this group to a team (admins only)
+/addbox <box_id> - Move one of your boxes to this group's team
+Sending a command in a linked group makes you a member of its team.
 /help - Show this help message
 
 How it works:
-1. Use /boxes to see available coffee boxes
-2. Use /coffee <box_id> to log when you take a coffee
+1. Whoever buys a box adds it with /newbox; use /boxes to see available boxes
+2. Tap a box (or use /coffee <box_id>) when you take a coffee
 3. The system automatically calculates your share of the cost
 4. Use /status to see your consumption history
+5. When a box is empty, its buyer uses /finishbox and then /closebox to create the payments
 
 Happy coffee drinking! ☕`
 
 	b.sendMessage(chatID, msg)
 }
 
-// sendMessage sends a message to a chat
+// failureMessage tells the user why an action failed. Domain errors explain
+// themselves; anything else is logged and reported without its details.
+func (b *Bot) failureMessage(action string, err error) string {
+	var domainErr *services.Error
+	if errors.As(err, &domainErr) && len(domainErr.Fields) > 0 {
+		reasons := make([]string, 0, len(domainErr.Fields))
+		for _, field := range domainErr.Fields {
+			reasons = append(reasons, field.Field+" "+field.Message)
+		}
+		return fmt.Sprintf("Failed to %s: %s.", action, strings.Join(reasons, ", "))
+	}
+	if errors.As(err, &domainErr) {
+		return fmt.Sprintf("Failed to %s: %s.", action, domainErr.Message)
+	}
+	b.logger.WithError(err).Error("Failed to " + action)
+	return fmt.Sprintf("Failed to %s. Please try again later.", action)
+}
+
+// sendMessage sends a message to a chat, logging it if Telegram refuses it
 func (b *Bot) sendMessage(chatID int64, text string) {
 	msg := tgbotapi.NewMessage(chatID, text)
 	msg.ParseMode = "Markdown"
 
 	if _, err := b.api.Send(msg); err != nil {
-		// Log error but don't crash
-		fmt.Printf("Failed to send message: %v\n", err)
+		b.logFailure(chatID, "send message", err)
 	}
 }
+
+// sendText sends a message as plain text, so names users chose can't break
+// the Markdown of sendMessage, logging it if Telegram refuses it
+func (b *Bot) sendText(chatID int64, text string) {
+	if err := b.sendPlain(chatID, text); err != nil {
+		b.logFailure(chatID, "send message", err)
+	}
+}
+
+// logFailure logs that an action in a chat failed; the user has been told
+// or there is nobody to tell
+func (b *Bot) logFailure(chatID int64, action string, err error) {
+	b.logger.WithError(err).WithField("chat_id", chatID).Error("Failed to " + action)
+}
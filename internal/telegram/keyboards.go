@@ -0,0 +1,172 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"github.com/your-username/coffee-cups-system/internal/services"
+)
+
+// Callback data for inline keyboard buttons. A box button carries
+// "coffee:<box_id>", the repeat button "coffee:last".
+const (
+	callbackLogCoffee = "coffee:"
+	callbackLastBox   = "last"
+)
+
+// boxesMessage renders the chat's active boxes with one button per box that
+// still has cups. The repeat button is only offered when the box of the
+// user's last coffee is one of them.
+func (b *Bot) boxesMessage(chat *tgbotapi.Chat, user *models.User) (string, *tgbotapi.InlineKeyboardMarkup, error) {
+	boxes, err := b.chatBoxes(chat, user)
+	if errors.Is(err, services.ErrTeamNotFound) {
+		return unlinkedGroupMessage, nil, nil
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	lastBoxID, err := b.lastBoxID(user)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(boxes) == 0 {
+		return "No active boxes available.", nil, nil
+	}
+
+	msg := "📦 Available coffee boxes:\n\n"
+	var rows [][]tgbotapi.InlineKeyboardButton
+	repeat := false
+	for _, box := range boxes {
+		remaining, _ := box.GetRemainingCups(b.services.Coffee.GetDB())
+		msg += fmt.Sprintf("ID: %d - %s\nPrice: %s (%s per cup)\nRemaining: %d/%d cups\n\n",
+			box.ID, box.Name, box.Price, box.GetCostPerCup(), remaining, box.TotalCups)
+		if remaining > 0 {
+			label := fmt.Sprintf("☕ %s (%d left)", box.Name, remaining)
+			data := fmt.Sprintf("%s%d", callbackLogCoffee, box.ID)
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(label, data)))
+			repeat = repeat || box.ID == lastBoxID
+		}
+	}
+	if repeat {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔁 Same as last time", callbackLogCoffee+callbackLastBox)))
+	}
+
+	msg += "Tap a box to log a coffee, or use /coffee <box_id>."
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return msg, &keyboard, nil
+}
+
+// handleCallback handles a tap on an inline keyboard button
+func (b *Bot) handleCallback(query *tgbotapi.CallbackQuery) {
+	user, err := b.services.User.CreateOrUpdateUser(
+		query.From.ID,
+		query.From.UserName,
+		query.From.FirstName,
+		query.From.LastName,
+	)
+	if err != nil {
+		b.answerCallback(query.ID, "Sorry, there was an error processing your request.")
+		return
+	}
+	b.syncRole(user)
+	if query.Message != nil {
+		b.joinChatTeam(query.Message.Chat, user)
+	}
+
+	if !strings.HasPrefix(query.Data, callbackLogCoffee) {
+		b.answerCallback(query.ID, "This button is no longer supported.")
+		return
+	}
+
+	boxID, problem := b.callbackBoxID(user, strings.TrimPrefix(query.Data, callbackLogCoffee))
+	if problem != "" {
+		b.answerCallback(query.ID, problem)
+		return
+	}
+
+	if _, err := b.services.Coffee.LogCoffee(user.ID, boxID); err != nil {
+		b.answerCallback(query.ID, b.failureMessage("log coffee", err))
+		return
+	}
+
+	reply := "☕ Coffee logged!"
+	if box, err := b.services.Box.GetBoxByID(boxID); err == nil {
+		if note := b.walletNote(user, box); note != "" {
+			reply += "\n" + note
+		}
+	}
+	b.answerCallback(query.ID, reply)
+	if query.Message != nil {
+		b.refreshBoxesMessage(query.Message, user, fmt.Sprintf("☕ %s logged a coffee.\n\n", user.FirstName))
+	}
+}
+
+// callbackBoxID resolves the box a button refers to, or explains why it can't.
+// "last" is the box of the user's most recent coffee.
+func (b *Bot) callbackBoxID(user *models.User, value string) (uint, string) {
+	if value != callbackLastBox {
+		boxID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return 0, "Invalid box."
+		}
+		return uint(boxID), ""
+	}
+
+	boxID, err := b.lastBoxID(user)
+	if err != nil {
+		return 0, "Failed to find your last coffee."
+	}
+	if boxID == 0 {
+		return 0, "You haven't logged any coffee yet. Tap a box instead."
+	}
+	return boxID, ""
+}
+
+// lastBoxID returns the box of the user's most recent coffee, or 0 if they have none
+func (b *Bot) lastBoxID(user *models.User) (uint, error) {
+	logs, err := b.services.Coffee.GetUserCoffeeLogs(user.ID, 1)
+	if err != nil || len(logs) == 0 {
+		return 0, err
+	}
+	return logs[0].BoxID, nil
+}
+
+// refreshBoxesMessage edits a /boxes message in place with the current remaining cups
+func (b *Bot) refreshBoxesMessage(message *tgbotapi.Message, user *models.User, prefix string) {
+	text, keyboard, err := b.boxesMessage(message.Chat, user)
+	if err != nil {
+		return
+	}
+
+	edit := tgbotapi.NewEditMessageText(message.Chat.ID, message.MessageID, prefix+text)
+	edit.ReplyMarkup = keyboard
+	if _, err := b.api.Send(edit); err != nil {
+		b.logFailure(message.Chat.ID, "edit message", err)
+	}
+}
+
+// answerCallback acknowledges a button tap with a short notification
+func (b *Bot) answerCallback(queryID, text string) {
+	if _, err := b.api.Request(tgbotapi.NewCallback(queryID, text)); err != nil {
+		b.logger.WithError(err).Error("Failed to answer callback")
+	}
+}
+
+// sendKeyboard sends a message with an inline keyboard attached. Like its
+// edits in refreshBoxesMessage, it is plain text since it lists box names.
+func (b *Bot) sendKeyboard(chatID int64, text string, keyboard *tgbotapi.InlineKeyboardMarkup) {
+	msg := tgbotapi.NewMessage(chatID, text)
+	if keyboard != nil {
+		msg.ReplyMarkup = keyboard
+	}
+
+	if _, err := b.api.Send(msg); err != nil {
+		b.logFailure(chatID, "send message", err)
+	}
+}
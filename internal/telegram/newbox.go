@@ -0,0 +1,272 @@
+package telegram
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"github.com/your-username/coffee-cups-system/internal/services"
+)
+
+// commandNewBox is the conversation that creates a box step by step
+const commandNewBox = "newbox"
+
+// Steps of the /newbox conversation in the order they are asked. The team
+// is only asked for when the user is in more than one team.
+const (
+	newBoxTeam    = "team"
+	newBoxName    = "name"
+	newBoxCups    = "cups"
+	newBoxPrice   = "price"
+	newBoxReceipt = "receipt"
+	newBoxConfirm = "confirm"
+)
+
+var newBoxSteps = []string{newBoxTeam, newBoxName, newBoxCups, newBoxPrice, newBoxReceipt, newBoxConfirm}
+
+// newBoxDraft is the box described so far in a /newbox conversation. Teams
+// are the teams the user was offered, so the answer picks from the list they saw.
+type newBoxDraft struct {
+	AskTeam       bool               `json:"ask_team"`
+	Teams         []newBoxTeamOption `json:"teams,omitempty"`
+	TeamID        uint               `json:"team_id"`
+	TeamName      string             `json:"team_name"`
+	Name          string             `json:"name"`
+	TotalCups     int                `json:"total_cups"`
+	Price         models.Money       `json:"price"`
+	ReceiptFileID string             `json:"receipt_file_id"`
+}
+
+// newBoxTeamOption is a team offered in the /newbox team question
+type newBoxTeamOption struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// handleNewBox handles the /newbox command, starting a conversation that
+// asks for the box's team, name, cups, price and receipt
+func (b *Bot) handleNewBox(chat *tgbotapi.Chat, user *models.User) {
+	if !chat.IsPrivate() {
+		b.sendMessage(chat.ID, "Use /newbox in a private chat with me.")
+		return
+	}
+	teams, err := b.services.Team.GetUserTeams(user.ID)
+	if err != nil {
+		b.sendMessage(chat.ID, "Failed to look up your teams.")
+		return
+	}
+	if len(teams) == 0 {
+		b.sendMessage(chat.ID, "You aren't in a team yet. Send a command in your team's group chat to join it first.")
+		return
+	}
+
+	draft := newBoxDraft{AskTeam: len(teams) > 1}
+	step := newBoxTeam
+	if draft.AskTeam {
+		for _, team := range teams {
+			draft.Teams = append(draft.Teams, newBoxTeamOption{ID: team.ID, Name: team.Name})
+		}
+	} else {
+		draft.TeamID, draft.TeamName = teams[0].ID, teams[0].Name
+		step = newBoxName
+	}
+	conversation := &models.Conversation{ChatID: chat.ID, UserID: user.ID, Command: commandNewBox}
+	b.askNewBox(conversation, draft, step,
+		"📦 Let's add a box. Use /back to change your last answer and /cancel to stop at any time.\n\n")
+}
+
+// continueNewBox takes the next message of a /newbox conversation
+func (b *Bot) continueNewBox(message *tgbotapi.Message, user *models.User, conversation *models.Conversation, text string) {
+	chatID := message.Chat.ID
+	var draft newBoxDraft
+	if err := json.Unmarshal([]byte(conversation.Data), &draft); err != nil {
+		b.endConversation(chatID)
+		b.sendMessage(chatID, "Sorry, I lost track of your box. Please start again with /newbox.")
+		return
+	}
+
+	step := conversation.Step
+	switch command := firstWord(text); {
+	case command == commandCancel:
+		b.endConversation(chatID)
+		b.sendMessage(chatID, "Cancelled, no box was created.")
+	case command == commandBack:
+		previous := draft.previousStep(step)
+		if previous == "" {
+			b.sendMessage(chatID, "This is the first question. Use /cancel to stop.")
+			return
+		}
+		b.askNewBox(conversation, draft, previous, "")
+	case step == newBoxConfirm && command == commandConfirm:
+		b.createNewBox(chatID, user, draft)
+	case step == newBoxConfirm:
+		b.sendMessage(chatID, "Send /confirm to create the box, /back to change the receipt or /cancel to stop.")
+	case command == commandConfirm || (command == commandSkip && step != newBoxReceipt):
+		b.sendMessage(chatID, "Please answer the question first, or use /back or /cancel.")
+	default:
+		if problem := answerNewBox(step, &draft, message, strings.TrimSpace(text)); problem != "" {
+			b.sendMessage(chatID, problem)
+			return
+		}
+		b.askNewBox(conversation, draft, draft.nextStep(step), "")
+	}
+}
+
+// answerNewBox records the answer to step in draft and returns what is wrong
+// with it, if anything
+func answerNewBox(step string, draft *newBoxDraft, message *tgbotapi.Message, text string) string {
+	switch step {
+	case newBoxTeam:
+		return answerNewBoxTeam(draft, text)
+	case newBoxName:
+		if err := services.Validate(services.Required("name", text), services.MaxLength("name", text, services.MaxNameLength)); err != nil {
+			return retryMessage(err)
+		}
+		draft.Name = text
+	case newBoxCups:
+		cups, err := strconv.Atoi(text)
+		if err != nil {
+			return "Please send the number of cups as a whole number, like 20."
+		}
+		if err := services.Validate(services.Between("total_cups", cups, 1, services.MaxBoxCups)); err != nil {
+			return retryMessage(err)
+		}
+		draft.TotalCups = cups
+	case newBoxPrice:
+		amount, currency, _ := strings.Cut(text, " ")
+		price, err := models.ParseMoney(amount, strings.TrimSpace(currency))
+		if err != nil {
+			return "Please send the price as a number like 15.99, optionally followed by a currency like USD."
+		}
+		if err := services.Validate(services.NonNegativeAmount("price", price)); err != nil {
+			return retryMessage(err)
+		}
+		draft.Price = price
+	case newBoxReceipt:
+		draft.ReceiptFileID = ""
+		if len(message.Photo) > 0 {
+			// Telegram lists the sizes of a photo from smallest to largest
+			draft.ReceiptFileID = message.Photo[len(message.Photo)-1].FileID
+		} else if firstWord(text) != commandSkip {
+			return "Please send a photo of the receipt, or /skip if you don't have one."
+		}
+	}
+	return ""
+}
+
+// answerNewBoxTeam picks the team by its number in the list the user was shown
+func answerNewBoxTeam(draft *newBoxDraft, text string) string {
+	choice, err := strconv.Atoi(text)
+	if err != nil || choice < 1 || choice > len(draft.Teams) {
+		return fmt.Sprintf("Please reply with a number from 1 to %d.", len(draft.Teams))
+	}
+	draft.TeamID, draft.TeamName = draft.Teams[choice-1].ID, draft.Teams[choice-1].Name
+	return ""
+}
+
+// askNewBox saves the conversation at step and asks its question, after intro
+func (b *Bot) askNewBox(conversation *models.Conversation, draft newBoxDraft, step, intro string) {
+	question, err := newBoxQuestion(draft, step)
+	if err == nil {
+		err = b.saveConversation(conversation, step, draft)
+	}
+	if err != nil {
+		b.logFailure(conversation.ChatID, "continue /newbox", err)
+		b.sendMessage(conversation.ChatID, "Sorry, there was an error processing your request.")
+		return
+	}
+	// The questions repeat the team and box names, which may contain Markdown
+	b.sendText(conversation.ChatID, intro+question)
+}
+
+// newBoxQuestion is the question asked at step
+func newBoxQuestion(draft newBoxDraft, step string) (string, error) {
+	switch step {
+	case newBoxTeam:
+		question := "Which team is the box for?\n\n"
+		for i, team := range draft.Teams {
+			question += fmt.Sprintf("%d. %s\n", i+1, team.Name)
+		}
+		return question + "\nReply with the number.", nil
+	case newBoxName:
+		return fmt.Sprintf("What is the box for team %s called?", draft.TeamName), nil
+	case newBoxCups:
+		return "How many cups are in it?", nil
+	case newBoxPrice:
+		return fmt.Sprintf("What did it cost? Send an amount like 15.99, in %s unless you add a currency like USD.",
+			models.DefaultCurrency), nil
+	case newBoxReceipt:
+		return "Send a photo of the receipt, or /skip if you don't have one.", nil
+	case newBoxConfirm:
+		receipt := "none"
+		if draft.ReceiptFileID != "" {
+			receipt = "photo attached"
+		}
+		box := models.Box{TotalCups: draft.TotalCups, Price: draft.Price}
+		return fmt.Sprintf("Please check the box:\n\nTeam: %s\nName: %s\nCups: %d\nPrice: %s (%s per cup)\nReceipt: %s\n\n"+
+			"Send /confirm to create it, /back to change something or /cancel to stop.",
+			draft.TeamName, draft.Name, box.TotalCups, box.Price, box.GetCostPerCup(), receipt), nil
+	}
+	return "", fmt.Errorf("unknown /newbox step %q", step)
+}
+
+// createNewBox creates the confirmed box and its receipt with the user as its buyer
+func (b *Bot) createNewBox(chatID int64, user *models.User, draft newBoxDraft) {
+	box, err := b.services.Box.AddBox(services.NewBox{
+		Name:          draft.Name,
+		TotalCups:     draft.TotalCups,
+		Price:         draft.Price,
+		CreatedBy:     user.ID,
+		TeamID:        draft.TeamID,
+		ReceiptFileID: draft.ReceiptFileID,
+	})
+	if err != nil {
+		b.sendMessage(chatID, b.failureMessage("create box", err)+" Use /back to change your answers or /cancel to stop.")
+		return
+	}
+	b.endConversation(chatID)
+
+	b.sendText(chatID, fmt.Sprintf("📦 Created %s (box #%d) for team %s: %d cups for %s.\nLog a coffee with /coffee %d or under /boxes.",
+		box.Name, box.ID, draft.TeamName, box.TotalCups, box.Price, box.ID))
+}
+
+// previousStep is the step asked before step, or "" for the first one
+func (d newBoxDraft) previousStep(step string) string {
+	for i := len(newBoxSteps) - 1; i > 0; i-- {
+		if newBoxSteps[i] == step {
+			previous := newBoxSteps[i-1]
+			if previous == newBoxTeam && !d.AskTeam {
+				return ""
+			}
+			return previous
+		}
+	}
+	return ""
+}
+
+// nextStep is the step asked after step
+func (d newBoxDraft) nextStep(step string) string {
+	for i, s := range newBoxSteps[:len(newBoxSteps)-1] {
+		if s == step {
+			return newBoxSteps[i+1]
+		}
+	}
+	return newBoxConfirm
+}
+
+// retryMessage explains why an answer was rejected and asks for another one
+func retryMessage(err error) string {
+	var domainErr *services.Error
+	if !errors.As(err, &domainErr) || len(domainErr.Fields) == 0 {
+		return "That doesn't work, please try again."
+	}
+	reasons := make([]string, 0, len(domainErr.Fields))
+	for _, field := range domainErr.Fields {
+		reasons = append(reasons, field.Field+" "+field.Message)
+	}
+	return fmt.Sprintf("That doesn't work: %s. Please try again.", strings.Join(reasons, ", "))
+}
@@ -0,0 +1,141 @@
+package telegram
+
+import (
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-username/coffee-cups-system/internal/config"
+	"github.com/your-username/coffee-cups-system/internal/models"
+)
+
+// TestNewBoxConversation walks through /newbox with a wrong answer, a step
+// back, a receipt photo and the confirmation
+func TestNewBoxConversation(t *testing.T) {
+	bot, api, svc := newTestBot(t, config.TelegramConfig{})
+
+	anna, err := svc.User.CreateOrUpdateUser(1, "", "Anna", "")
+	require.NoError(t, err)
+	newTeam(t, svc, "Office", anna)
+	newTeam(t, svc, "Lab", anna)
+
+	receipt := message(1, "private", "")
+	receipt.Photo = []tgbotapi.PhotoSize{{FileID: "small"}, {FileID: "large"}}
+
+	steps := []struct {
+		message *tgbotapi.Message
+		reply   string
+	}{
+		{message(1, "group", "/newbox"), "Use /newbox in a private chat"},
+		{message(1, "private", "/back"), "There is nothing in progress"},
+		{message(1, "private", "/newbox"), "Which team is the box for?\n\n1. Lab\n2. Office"},
+		{message(1, "private", "/back"), "This is the first question"},
+		{message(1, "private", "3"), "Please reply with a number from 1 to 2."},
+		{message(1, "private", "1"), "What is the box for team Lab called?"},
+		{message(1, "private", "/skip"), "Please answer the question first"},
+		{message(1, "private", "Lungo"), "How many cups are in it?"},
+		{message(1, "private", "0"), "That doesn't work: total_cups must be between 1 and 10000."},
+		{message(1, "private", "/status"), "haven't logged any coffee"},
+		{message(1, "private", "20"), "What did it cost?"},
+		{message(1, "private", "cheap"), "Please send the price as a number"},
+		{message(1, "private", "/back"), "How many cups are in it?"},
+		{message(1, "private", "10"), "What did it cost?"},
+		{message(1, "private", "15,50 usd"), "Send a photo of the receipt"},
+		{message(1, "private", "/confirm"), "Please answer the question first"},
+		{receipt, "Team: Lab\nName: Lungo\nCups: 10\nPrice: 15.50 USD (1.55 USD per cup)\nReceipt: photo attached"},
+		{message(1, "private", "sure"), "Send /confirm to create the box"},
+		{message(1, "private", "/confirm"), "📦 Created Lungo (box #1) for team Lab: 10 cups for 15.50 USD."},
+		{message(1, "private", "/confirm"), "There is nothing in progress"},
+	}
+	for _, step := range steps {
+		bot.handleMessage(step.message)
+		assert.Contains(t, api.LastText(), step.reply, step.message.Text)
+	}
+
+	box, err := svc.Box.GetBoxByID(1)
+	require.NoError(t, err)
+	assert.Equal(t, anna.ID, box.CreatedBy)
+	assert.Equal(t, uint(2), box.TeamID)
+	assert.Equal(t, "large", box.ReceiptFileID)
+	conversation, err := svc.Conversation.GetConversation(1)
+	require.NoError(t, err)
+	assert.Nil(t, conversation)
+}
+
+// TestNewBoxTeamChanges tests that the team is picked from the list the user
+// was shown even if they join another team before answering
+func TestNewBoxTeamChanges(t *testing.T) {
+	bot, api, svc := newTestBot(t, config.TelegramConfig{})
+
+	anna, err := svc.User.CreateOrUpdateUser(1, "", "Anna", "")
+	require.NoError(t, err)
+	newTeam(t, svc, "Office", anna)
+	lab := newTeam(t, svc, "Lab", anna)
+
+	bot.handleMessage(message(1, "private", "/newbox"))
+	assert.Contains(t, api.LastText(), "1. Lab\n2. Office")
+	newTeam(t, svc, "Attic", anna)
+	bot.handleMessage(message(1, "private", "1"))
+	assert.Contains(t, api.LastText(), "What is the box for team Lab called?")
+	bot.handleMessage(message(1, "private", "/back"))
+	assert.Contains(t, api.LastText(), "1. Lab\n2. Office\n")
+
+	for _, text := range []string{"1", "Lungo", "10", "15", "/skip", "/confirm"} {
+		bot.handleMessage(message(1, "private", text))
+	}
+	assert.Contains(t, api.LastText(), "Created Lungo (box #1) for team Lab")
+	box, err := svc.Box.GetBoxByID(1)
+	require.NoError(t, err)
+	assert.Equal(t, lab.ID, box.TeamID)
+	assert.Empty(t, box.ReceiptFileID)
+}
+
+// TestNewBoxPlainNames tests that questions repeating names with Markdown
+// characters are sent as plain text
+func TestNewBoxPlainNames(t *testing.T) {
+	bot, api, svc := newTestBot(t, config.TelegramConfig{})
+
+	anna, err := svc.User.CreateOrUpdateUser(1, "", "Anna", "")
+	require.NoError(t, err)
+	newTeam(t, svc, "R_and_D", anna)
+
+	for _, text := range []string{"/newbox", "Lavazza_Crema", "10", "15", "/skip", "/confirm"} {
+		bot.handleMessage(message(1, "private", text))
+	}
+	messages := api.Messages()
+	require.Len(t, messages, 6)
+	assert.Contains(t, messages[0].Text, "What is the box for team R_and_D called?")
+	assert.Contains(t, messages[4].Text, "Team: R_and_D\nName: Lavazza_Crema")
+	assert.Contains(t, messages[5].Text, "Created Lavazza_Crema (box #1) for team R_and_D")
+	for _, msg := range messages {
+		assert.Empty(t, msg.ParseMode, msg.Text)
+	}
+}
+
+// TestNewBoxCancel tests cancelling /newbox and dropping an abandoned one
+func TestNewBoxCancel(t *testing.T) {
+	bot, api, svc := newTestBot(t, config.TelegramConfig{})
+
+	anna, err := svc.User.CreateOrUpdateUser(1, "", "Anna", "")
+	require.NoError(t, err)
+	bot.handleMessage(message(1, "private", "/newbox"))
+	assert.Contains(t, api.LastText(), "You aren't in a team yet")
+	newTeam(t, svc, "Office", anna)
+
+	bot.handleMessage(message(1, "private", "/newbox"))
+	assert.Contains(t, api.LastText(), "What is the box for team Office called?")
+	bot.handleMessage(message(1, "private", "/cancel"))
+	assert.Contains(t, api.LastText(), "Cancelled, no box was created.")
+
+	bot.handleMessage(message(1, "private", "/newbox"))
+	stale := time.Now().Add(-2 * conversationTimeout)
+	require.NoError(t, svc.Coffee.GetDB().Model(&models.Conversation{}).Where("chat_id = ?", 1).Update("updated_at", stale).Error)
+	bot.handleMessage(message(1, "private", "Lungo"))
+	assert.Contains(t, api.LastText(), "I don't understand")
+
+	boxes, err := svc.Box.GetActiveUserBoxes(anna.ID)
+	require.NoError(t, err)
+	assert.Empty(t, boxes)
+}
@@ -0,0 +1,72 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/your-username/coffee-cups-system/internal/models"
+)
+
+// remindersUsage explains the /reminders command
+const remindersUsage = "Usage: /reminders on|off, /reminders quiet HH:MM-HH:MM or /reminders quiet off"
+
+// NotifyUser sends a direct message to a user, whose private chat has
+// their Telegram ID
+func (b *Bot) NotifyUser(telegramID int64, text string) error {
+	return b.sendPlain(telegramID, text)
+}
+
+// sendPlain sends text without Markdown, so box and user names in it can't
+// break the message
+func (b *Bot) sendPlain(chatID int64, text string) error {
+	if _, err := b.api.Send(tgbotapi.NewMessage(chatID, text)); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return nil
+}
+
+// handleReminders handles the /reminders command for payment reminder preferences
+func (b *Bot) handleReminders(chatID int64, user *models.User, text string) {
+	parts := strings.Fields(text)
+	var err error
+	switch {
+	case len(parts) == 1:
+		b.sendMessage(chatID, remindersStatus(user)+"\n\n"+remindersUsage)
+		return
+	case len(parts) == 2 && (parts[1] == "on" || parts[1] == "off"):
+		user.RemindersEnabled = parts[1] == "on"
+		err = b.services.User.SetRemindersEnabled(user.ID, user.RemindersEnabled)
+	case len(parts) == 3 && parts[1] == "quiet" && parts[2] == "off":
+		user.QuietHoursStart, user.QuietHoursEnd = nil, nil
+		err = b.services.User.SetQuietHours(user.ID, nil)
+	case len(parts) == 3 && parts[1] == "quiet":
+		quiet, parseErr := models.ParseQuietHours(parts[2])
+		if parseErr != nil {
+			b.sendMessage(chatID, remindersUsage)
+			return
+		}
+		user.QuietHoursStart, user.QuietHoursEnd = &quiet.Start, &quiet.End
+		err = b.services.User.SetQuietHours(user.ID, &quiet)
+	default:
+		b.sendMessage(chatID, remindersUsage)
+		return
+	}
+
+	if err != nil {
+		b.sendMessage(chatID, "Failed to update your reminder settings.")
+		return
+	}
+	b.sendMessage(chatID, "✅ "+remindersStatus(user))
+}
+
+// remindersStatus describes a user's reminder settings
+func remindersStatus(user *models.User) string {
+	if !user.RemindersEnabled {
+		return "Payment reminders are off."
+	}
+	if quiet, ok := user.QuietHours(); ok {
+		return fmt.Sprintf("Payment reminders are on, except between %s.", quiet)
+	}
+	return "Payment reminders are on."
+}
@@ -0,0 +1,123 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"github.com/your-username/coffee-cups-system/internal/services"
+)
+
+// handleSettle handles the /settle command.
+// "/settle" shows the current plan, "/settle accept <code>" accepts it.
+// A group settles its own team, a private chat every team of the user.
+func (b *Bot) handleSettle(chat *tgbotapi.Chat, user *models.User, text string) {
+	teams, err := b.settlementTeams(chat, user)
+	if errors.Is(err, services.ErrTeamNotFound) {
+		b.sendMessage(chat.ID, unlinkedGroupMessage)
+		return
+	}
+	if err != nil {
+		b.sendMessage(chat.ID, "Failed to look up your teams.")
+		return
+	}
+
+	parts := strings.Fields(text)
+	switch {
+	case len(parts) == 1:
+		b.showSettlementPlans(chat.ID, teams)
+	case len(parts) == 3 && parts[1] == "accept":
+		b.acceptSettlementPlan(chat.ID, user, teams, parts[2])
+	default:
+		b.sendMessage(chat.ID, "Usage: /settle or /settle accept <code>")
+	}
+}
+
+// settlementTeams returns the teams /settle covers in a chat
+func (b *Bot) settlementTeams(chat *tgbotapi.Chat, user *models.User) ([]models.Team, error) {
+	if !chat.IsPrivate() {
+		team, err := b.services.Team.GetTeamByChatID(chat.ID)
+		if err != nil {
+			return nil, err
+		}
+		return []models.Team{*team}, nil
+	}
+	if user.IsAdmin() {
+		return b.services.Team.GetAllTeams()
+	}
+	return b.services.Team.GetUserTeams(user.ID)
+}
+
+// showSettlementPlans sends the minimal set of transfers that clears each team's debts
+func (b *Bot) showSettlementPlans(chatID int64, teams []models.Team) {
+	msg := ""
+	for _, team := range teams {
+		plan, err := b.services.Settlement.GetPlan(team.ID)
+		if err != nil {
+			b.sendMessage(chatID, "Failed to build the settlement plan.")
+			return
+		}
+		if len(plan.PaymentIDs) == 0 {
+			continue
+		}
+
+		msg += fmt.Sprintf("💸 Settlement plan for %s:\n\n", team.Name)
+		for _, transfer := range plan.Transfers {
+			msg += fmt.Sprintf("%s pays %s %s\n", transfer.FromName, transfer.ToName, transfer.Amount)
+		}
+		msg += fmt.Sprintf("\nOnce the money has changed hands, send /settle accept %s to mark all %d payments as paid.\n\n",
+			plan.Token, len(plan.PaymentIDs))
+	}
+
+	if msg == "" {
+		msg = "🎉 Nobody owes anything right now."
+	}
+	b.sendMessage(chatID, strings.TrimSpace(msg))
+}
+
+// acceptSettlementPlan marks the payments of the plan identified by token as paid
+func (b *Bot) acceptSettlementPlan(chatID int64, user *models.User, teams []models.Team, token string) {
+	if !user.IsAdmin() {
+		b.sendMessage(chatID, "Only admins can accept a settlement plan.")
+		return
+	}
+
+	team, ok := b.planTeam(teams, token)
+	if !ok {
+		b.sendMessage(chatID, "Payments have changed since this plan was made. Send /settle to get a fresh plan.")
+		return
+	}
+
+	settlement, err := b.services.Settlement.AcceptPlan(team.ID, token, user.ID)
+	switch {
+	case errors.Is(err, services.ErrSettlementPlanChanged):
+		b.sendMessage(chatID, "Payments have changed since this plan was made. Send /settle to get a fresh plan.")
+		return
+	case errors.Is(err, services.ErrNothingToSettle):
+		b.sendMessage(chatID, "There is nothing to settle.")
+		return
+	case err != nil:
+		b.sendMessage(chatID, "Failed to accept the settlement plan.")
+		return
+	}
+
+	// The team's group has already been sent the summary
+	if team.ChatID != nil && *team.ChatID == chatID {
+		return
+	}
+	b.sendMessage(chatID, fmt.Sprintf("✅ Settlement #%d recorded for %s. All covered payments are marked as paid.",
+		settlement.ID, team.Name))
+}
+
+// planTeam finds the team whose current settlement plan has the given token
+func (b *Bot) planTeam(teams []models.Team, token string) (models.Team, bool) {
+	for _, team := range teams {
+		plan, err := b.services.Settlement.GetPlan(team.ID)
+		if err == nil && len(plan.PaymentIDs) > 0 && plan.Token == token {
+			return team, true
+		}
+	}
+	return models.Team{}, false
+}
@@ -0,0 +1,71 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"github.com/your-username/coffee-cups-system/internal/services"
+)
+
+// handleLowStock handles the /lowstock command: /lowstock <box_id> <cups>
+func (b *Bot) handleLowStock(chatID int64, user *models.User, text string) {
+	parts := strings.Fields(text)
+	if len(parts) != 3 {
+		b.sendMessage(chatID, "Usage: /lowstock <box_id> <cups> (0 turns the warning off)")
+		return
+	}
+
+	threshold, err := strconv.Atoi(parts[2])
+	if err != nil {
+		b.sendMessage(chatID, "Invalid number of cups.")
+		return
+	}
+	box, ok := b.ownedBox(chatID, user, parts[1], "change its low-stock warning")
+	if !ok {
+		return
+	}
+
+	_, err = b.services.Box.SetLowStockThreshold(box.ID, threshold)
+	switch {
+	case errors.Is(err, services.ErrInvalidLowStockThreshold):
+		b.sendMessage(chatID, fmt.Sprintf("The number of cups must be between 0 and %d.", box.TotalCups-1))
+	case err != nil:
+		b.sendMessage(chatID, "Failed to update the low-stock warning.")
+	case threshold == 0:
+		b.sendMessage(chatID, fmt.Sprintf("🔕 No more low-stock warnings for %s.", box.Name))
+	default:
+		b.sendMessage(chatID, fmt.Sprintf("🔔 You'll be warned when %s is down to %d cups.", box.Name, threshold))
+	}
+}
+
+// announceStock tells the box owner, and the team's group if configured,
+// that a box is running low or empty
+func (b *Bot) announceStock(box *models.Box, text string) {
+	if box.Creator.TelegramID != 0 {
+		if err := b.NotifyUser(box.Creator.TelegramID, text); err != nil {
+			b.logFailure(box.Creator.TelegramID, "notify box owner", err)
+		}
+	}
+	if !b.config.StockAlertsInGroup {
+		return
+	}
+	if chatID, ok := b.teamChatID(box.TeamID); ok {
+		if err := b.sendPlain(chatID, text); err != nil {
+			b.logFailure(chatID, "announce stock in group", err)
+		}
+	}
+}
+
+// lowStockMessage warns that a box is nearly empty
+func lowStockMessage(box *models.Box, remaining int) string {
+	return fmt.Sprintf("⚠️ Only %d cups left in %s (box #%d). Time to buy a new box!", remaining, box.Name, box.ID)
+}
+
+// emptyBoxMessage says a box is used up and suggests what to do next
+func emptyBoxMessage(box *models.Box) string {
+	return fmt.Sprintf("📭 %s (box #%d) is empty. Finish it with /finishbox %d and close it with /closebox %d "+
+		"to split the cost, and start a new box so nobody goes without coffee.", box.Name, box.ID, box.ID, box.ID)
+}
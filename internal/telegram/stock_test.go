@@ -0,0 +1,65 @@
+package telegram
+
+import (
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-username/coffee-cups-system/internal/config"
+	"github.com/your-username/coffee-cups-system/internal/models"
+)
+
+// TestStockAlerts tests warning the box owner and the team group as a box runs out
+func TestStockAlerts(t *testing.T) {
+	bot, api, svc := newTestBot(t, config.TelegramConfig{StockAlertsInGroup: true})
+
+	owner, err := svc.User.CreateOrUpdateUser(99, "", "Owner", "")
+	require.NoError(t, err)
+	anna, err := svc.User.CreateOrUpdateUser(1, "", "Anna", "")
+	require.NoError(t, err)
+	team, err := svc.Team.LinkChat(-100, "Office")
+	require.NoError(t, err)
+	require.NoError(t, svc.Team.AddMember(team.ID, owner.ID))
+	require.NoError(t, svc.Team.AddMember(team.ID, anna.ID))
+	box, err := svc.Box.CreateBox("Ristretto", 4, models.NewMoney(400, "EUR"), owner.ID, team.ID)
+	require.NoError(t, err)
+
+	bot.handleMessage(message(1, "private", "/lowstock 1 2"))
+	assert.Contains(t, api.LastText(), "Only the person who bought this box")
+	bot.handleMessage(message(99, "private", "/lowstock 1 4"))
+	assert.Contains(t, api.LastText(), "between 0 and 3")
+	bot.handleMessage(message(99, "private", "/lowstock 1 2"))
+	assert.Contains(t, api.LastText(), "down to 2 cups")
+
+	alerts := func() []tgbotapi.MessageConfig {
+		var alerts []tgbotapi.MessageConfig
+		for _, msg := range api.Messages() {
+			if strings.Contains(msg.Text, box.Name+" (box #1)") {
+				alerts = append(alerts, msg)
+			}
+		}
+		return alerts
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := svc.Coffee.LogCoffee(anna.ID, box.ID)
+		require.NoError(t, err)
+	}
+	require.Len(t, alerts(), 2)
+	assert.Equal(t, int64(99), alerts()[0].ChatID)
+	assert.Contains(t, alerts()[0].Text, "Only 2 cups left")
+	assert.Equal(t, int64(-100), alerts()[1].ChatID)
+	for _, alert := range alerts() {
+		assert.Empty(t, alert.ParseMode, "names must not be parsed as Markdown")
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := svc.Coffee.LogCoffee(anna.ID, box.ID)
+		require.NoError(t, err)
+	}
+	require.Len(t, alerts(), 4)
+	assert.Contains(t, alerts()[2].Text, "is empty")
+	assert.Contains(t, alerts()[2].Text, "/finishbox 1 and close it with /closebox 1")
+}
@@ -0,0 +1,145 @@
+// Package telegramtest provides an in-memory Telegram Bot API for tests
+package telegramtest
+
+import (
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// FakeAPI records everything the bot sends instead of calling Telegram.
+// Updates pushed to Updates are delivered to GetUpdatesChan. While SendErr
+// is set, Send fails with it as Telegram does when it refuses a message.
+type FakeAPI struct {
+	Updates  chan tgbotapi.Update
+	Username string
+	SendErr  error
+
+	mu            sync.Mutex
+	sent          []tgbotapi.Chattable
+	requests      []tgbotapi.Chattable
+	madeRequests  []string
+	nextMessageID int
+}
+
+// NewFakeAPI creates an empty FakeAPI
+func NewFakeAPI() *FakeAPI {
+	return &FakeAPI{Updates: make(chan tgbotapi.Update, 100), Username: "coffee_bot"}
+}
+
+// GetMe returns the bot user named Username
+func (f *FakeAPI) GetMe() (tgbotapi.User, error) {
+	return tgbotapi.User{ID: 1, IsBot: true, UserName: f.Username}, nil
+}
+
+// Send records a message or edit and returns it as delivered
+func (f *FakeAPI) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.SendErr != nil {
+		return tgbotapi.Message{}, f.SendErr
+	}
+	f.sent = append(f.sent, c)
+	f.nextMessageID++
+
+	message := tgbotapi.Message{MessageID: f.nextMessageID}
+	if msg, ok := c.(tgbotapi.MessageConfig); ok {
+		message.Chat = &tgbotapi.Chat{ID: msg.ChatID}
+		message.Text = msg.Text
+	}
+	return message, nil
+}
+
+// Request records a request such as a callback answer
+func (f *FakeAPI) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, c)
+	return &tgbotapi.APIResponse{Ok: true}, nil
+}
+
+// MakeRequest records the endpoint of a raw API call
+func (f *FakeAPI) MakeRequest(endpoint string, _ tgbotapi.Params) (*tgbotapi.APIResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.madeRequests = append(f.madeRequests, endpoint)
+	return &tgbotapi.APIResponse{Ok: true}, nil
+}
+
+// GetUpdatesChan returns the Updates channel
+func (f *FakeAPI) GetUpdatesChan(tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel {
+	return f.Updates
+}
+
+// StopReceivingUpdates does nothing; the fake never polls
+func (f *FakeAPI) StopReceivingUpdates() {}
+
+// Messages returns the new messages sent so far
+func (f *FakeAPI) Messages() []tgbotapi.MessageConfig {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var messages []tgbotapi.MessageConfig
+	for _, c := range f.sent {
+		if msg, ok := c.(tgbotapi.MessageConfig); ok {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+// Edits returns the message edits sent so far
+func (f *FakeAPI) Edits() []tgbotapi.EditMessageTextConfig {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var edits []tgbotapi.EditMessageTextConfig
+	for _, c := range f.sent {
+		if edit, ok := c.(tgbotapi.EditMessageTextConfig); ok {
+			edits = append(edits, edit)
+		}
+	}
+	return edits
+}
+
+// CallbackAnswers returns the texts of the callback queries answered so far
+func (f *FakeAPI) CallbackAnswers() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var answers []string
+	for _, c := range f.requests {
+		if answer, ok := c.(tgbotapi.CallbackConfig); ok {
+			answers = append(answers, answer.Text)
+		}
+	}
+	return answers
+}
+
+// Requests returns every request made so far
+func (f *FakeAPI) Requests() []tgbotapi.Chattable {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]tgbotapi.Chattable(nil), f.requests...)
+}
+
+// MadeRequests returns the endpoints of the raw API calls made so far
+func (f *FakeAPI) MadeRequests() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]string(nil), f.madeRequests...)
+}
+
+// LastText returns the text of the most recent new message, or "" if none was sent
+func (f *FakeAPI) LastText() string {
+	messages := f.Messages()
+	if len(messages) == 0 {
+		return ""
+	}
+	return messages[len(messages)-1].Text
+}
@@ -0,0 +1,111 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/your-username/coffee-cups-system/internal/models"
+)
+
+// walletHistorySize is how many entries /wallet lists per team
+const walletHistorySize = 10
+
+// handleWallet handles the /wallet command.
+// In a group it shows the wallet in the group's team, in a private chat the
+// wallets in all of the user's prepaid teams.
+func (b *Bot) handleWallet(chat *tgbotapi.Chat, user *models.User) {
+	teams, err := b.walletTeams(chat, user)
+	if err != nil {
+		b.sendMessage(chat.ID, "Failed to get your teams.")
+		return
+	}
+	if len(teams) == 0 {
+		b.sendMessage(chat.ID, "None of your teams is prepaid, so you have no wallet.")
+		return
+	}
+
+	var msg strings.Builder
+	for _, team := range teams {
+		wallet, err := b.services.Wallet.GetWallet(team.ID, user.ID, walletHistorySize)
+		if err != nil {
+			b.sendMessage(chat.ID, "Failed to get your wallet.")
+			return
+		}
+		balance := "nothing yet"
+		if len(wallet.Balance) > 0 {
+			balance = joinAmounts(wallet.Balance)
+		}
+		fmt.Fprintf(&msg, "👛 *%s*: %s\n", team.Name, balance)
+		for _, entry := range wallet.Entries {
+			fmt.Fprintf(&msg, "%s %s %s", entry.CreatedAt.Format("02 Jan"), walletEntryLabel(entry.Kind), entry.Amount)
+			if entry.Note != "" {
+				fmt.Fprintf(&msg, " (%s)", entry.Note)
+			}
+			msg.WriteString("\n")
+		}
+		msg.WriteString("\n")
+	}
+	b.sendMessage(chat.ID, strings.TrimSpace(msg.String()))
+}
+
+// walletTeams returns the prepaid teams /wallet covers in a chat
+func (b *Bot) walletTeams(chat *tgbotapi.Chat, user *models.User) ([]models.Team, error) {
+	var teams []models.Team
+	if chat.IsPrivate() {
+		var err error
+		if teams, err = b.services.Team.GetUserTeams(user.ID); err != nil {
+			return nil, err
+		}
+	} else {
+		team, err := b.services.Team.GetTeamByChatID(chat.ID)
+		if err != nil {
+			return nil, err
+		}
+		teams = []models.Team{*team}
+	}
+
+	prepaid := teams[:0]
+	for _, team := range teams {
+		if team.IsPrepaid() {
+			prepaid = append(prepaid, team)
+		}
+	}
+	return prepaid, nil
+}
+
+// walletEntryLabel describes the kind of a wallet entry
+func walletEntryLabel(kind string) string {
+	switch kind {
+	case models.WalletTopUp:
+		return "top-up"
+	case models.WalletDeduction:
+		return "cup"
+	case models.WalletRefund:
+		return "refund"
+	default:
+		return kind
+	}
+}
+
+// walletNote tells a user of a prepaid team what is left in their wallet
+// after logging a cup from box, and warns when it won't cover the next one.
+// It is empty for postpaid teams or when the wallet can't be read.
+func (b *Bot) walletNote(user *models.User, box *models.Box) string {
+	team, err := b.services.Team.GetTeamByID(box.TeamID)
+	if err != nil || !team.IsPrepaid() {
+		return ""
+	}
+	price := box.GetCostPerCup()
+	balance, err := b.services.Wallet.GetBalance(team.ID, user.ID, price.Currency)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to get wallet balance")
+		return ""
+	}
+
+	note := fmt.Sprintf("Wallet: %s", balance)
+	if balance.MinorUnits < price.MinorUnits {
+		note += "\n⚠️ That won't cover your next cup. Ask an admin to top up your wallet."
+	}
+	return note
+}
@@ -0,0 +1,43 @@
+package telegram
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-username/coffee-cups-system/internal/config"
+	"github.com/your-username/coffee-cups-system/internal/models"
+)
+
+// TestWallet tests the wallet commands and notes of a prepaid team
+func TestWallet(t *testing.T) {
+	bot, api, svc := newTestBot(t, config.TelegramConfig{})
+
+	owner, err := svc.User.CreateOrUpdateUser(99, "", "Owner", "")
+	require.NoError(t, err)
+	anna, err := svc.User.CreateOrUpdateUser(1, "", "Anna", "")
+	require.NoError(t, err)
+	team := newTeam(t, svc, "Office", owner, anna)
+	_, err = svc.Box.CreateBox("Espresso", 10, models.NewMoney(500, "EUR"), owner.ID, team.ID)
+	require.NoError(t, err)
+
+	bot.handleMessage(message(1, "private", "/wallet"))
+	assert.Contains(t, api.LastText(), "None of your teams is prepaid")
+
+	_, err = svc.Team.SetBillingMode(team.ID, models.BillingPrepaid)
+	require.NoError(t, err)
+	bot.handleMessage(message(1, "private", "/coffee 1"))
+	assert.Contains(t, api.LastText(), "does not cover this cup")
+
+	_, err = svc.Wallet.TopUp(team.ID, anna.ID, models.NewMoney(75, "EUR"), "cash", owner.ID)
+	require.NoError(t, err)
+	bot.handleMessage(message(1, "private", "/coffee 1"))
+	assert.Contains(t, api.LastText(), "Wallet: 0.25 EUR")
+	assert.Contains(t, api.LastText(), "won't cover your next cup")
+
+	bot.handleMessage(message(1, "private", "/wallet"))
+	reply := api.LastText()
+	assert.Contains(t, reply, "*Office*: 0.25 EUR")
+	assert.Contains(t, reply, "cup -0.50 EUR (Espresso)")
+	assert.Contains(t, reply, "top-up 0.75 EUR (cash)")
+}
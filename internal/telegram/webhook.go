@@ -0,0 +1,91 @@
+package telegram
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Update delivery modes
+const (
+	ModePolling = "polling"
+	ModeWebhook = "webhook"
+)
+
+// secretTokenHeader carries the webhook secret on every update Telegram posts
+const secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// webhookQueueSize is how many received updates may wait for processing
+const webhookQueueSize = 100
+
+// validSecret matches the characters Telegram allows in a webhook secret
+var validSecret = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
+// validateMode checks that the configuration is complete for its update mode
+func validateMode(mode, webhookURL, webhookSecret string) error {
+	switch mode {
+	case ModePolling:
+		return nil
+	case ModeWebhook:
+		if webhookURL == "" {
+			return fmt.Errorf("webhook mode requires telegram.webhook_url")
+		}
+		if !validSecret.MatchString(webhookSecret) {
+			return fmt.Errorf("webhook mode requires telegram.webhook_secret of 1-256 letters, digits, _ or -")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported telegram mode %q", mode)
+	}
+}
+
+// WebhookHandler receives updates posted by Telegram in webhook mode.
+// Requests without the configured secret token are rejected.
+func (b *Bot) WebhookHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret := r.Header.Get(secretTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(secret), []byte(b.config.WebhookSecret)) != 1 {
+			http.Error(w, "Invalid secret token", http.StatusUnauthorized)
+			return
+		}
+
+		var update tgbotapi.Update
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			http.Error(w, "Invalid update", http.StatusBadRequest)
+			return
+		}
+
+		// Ask Telegram to retry later rather than block its delivery
+		select {
+		case b.webhookUpdates <- update:
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.Error(w, "Too many pending updates", http.StatusServiceUnavailable)
+		}
+	})
+}
+
+// setWebhook registers the webhook URL and secret with Telegram.
+// The bundled client has no secret_token field, so the request is built by hand.
+func (b *Bot) setWebhook() error {
+	params := tgbotapi.Params{}
+	params["url"] = b.config.WebhookURL
+	params["secret_token"] = b.config.WebhookSecret
+
+	if _, err := b.api.MakeRequest("setWebhook", params); err != nil {
+		return fmt.Errorf("failed to set webhook: %w", err)
+	}
+	return nil
+}
+
+// deleteWebhook removes any registered webhook, which Telegram requires before polling
+func (b *Bot) deleteWebhook() error {
+	if _, err := b.api.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return nil
+}
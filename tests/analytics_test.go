@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"github.com/your-username/coffee-cups-system/internal/services"
+)
+
+// TestUsageAnalytics tests aggregating coffee logs over a period
+func (suite *IntegrationTestSuite) TestUsageAnalytics() {
+	owner := suite.newUser("Owner")
+	anna := suite.newUser("Anna")
+
+	box, err := suite.services.Box.CreateBox("Analytics Box", 10, models.NewMoney(1000, "EUR"), owner.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	for _, user := range []*models.User{anna, anna, anna, owner} {
+		_, err := suite.services.Coffee.LogCoffee(user.ID, box.ID)
+		suite.Require().NoError(err)
+	}
+
+	// A cup from two months ago falls outside the month window
+	old, err := suite.services.Coffee.LogCoffee(owner.ID, box.ID)
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.db.Model(old).Update("logged_at", time.Now().AddDate(0, -2, 0)).Error)
+
+	usage, err := suite.services.Analytics.GetUsage(suite.team.ID, "", time.Now().Add(time.Minute))
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "month", usage.Period)
+	assert.Equal(suite.T(), int64(2), usage.TotalUsers)
+	assert.Equal(suite.T(), int64(4), usage.TotalCoffeeLogs)
+	assert.Equal(suite.T(), int64(2), usage.ActiveUsers)
+	assert.Equal(suite.T(), 2.0, usage.AverageCoffeePerUser)
+
+	suite.Require().Len(usage.Spend, 1)
+	assert.Equal(suite.T(), models.NewMoney(400, "EUR"), usage.Spend[0])
+	suite.Require().Len(usage.BoxUtilization, 1)
+	assert.Equal(suite.T(), int64(4), usage.BoxUtilization[0].Cups)
+	assert.InDelta(suite.T(), 0.4, usage.BoxUtilization[0].Utilization, 1e-9)
+
+	suite.Require().Len(usage.TopConsumers, 2)
+	assert.Equal(suite.T(), "Anna", usage.TopConsumers[0].Name)
+	assert.Equal(suite.T(), int64(3), usage.TopConsumers[0].Cups)
+
+	suite.Require().Len(usage.Timeline, 1)
+	assert.Equal(suite.T(), int64(4), usage.Timeline[0].Cups)
+
+	yearly, err := suite.services.Analytics.GetUsage(suite.team.ID, "year", time.Now().Add(time.Minute))
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), int64(5), yearly.TotalCoffeeLogs)
+	assert.Len(suite.T(), yearly.Timeline, 2)
+
+	_, err = suite.services.Analytics.GetUsage(suite.team.ID, "decade", time.Now())
+	assert.ErrorIs(suite.T(), err, services.ErrInvalidPeriod)
+
+	rr := suite.serve(anna, "GET", "/api/v1/teams/1/analytics/usage?period=decade", nil, suite.handlers.GetUsageAnalytics)
+	assert.Equal(suite.T(), http.StatusUnprocessableEntity, rr.Code)
+	rr = suite.serve(anna, "GET", "/api/v1/teams/1/analytics/usage?period=week", nil, suite.handlers.GetUsageAnalytics)
+	assert.Equal(suite.T(), http.StatusOK, rr.Code)
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/your-username/coffee-cups-system/internal/handlers"
+	"github.com/your-username/coffee-cups-system/internal/models"
+)
+
+// authenticatedRequest sends a GET through the authentication middleware
+func (suite *IntegrationTestSuite) authenticatedRequest(header string) int {
+	protected := suite.handlers.Authenticate(http.HandlerFunc(suite.handlers.GetUsers))
+
+	req := httptest.NewRequest("GET", "/api/v1/users", nil)
+	if header != "" {
+		req.Header.Set("Authorization", header)
+	}
+	rr := httptest.NewRecorder()
+	protected.ServeHTTP(rr, req)
+	return rr.Code
+}
+
+// TestAuthentication tests bearer token authentication
+func (suite *IntegrationTestSuite) TestAuthentication() {
+	user := suite.newUser("Anna")
+
+	token, err := suite.services.Auth.IssueToken(user.ID)
+	suite.Require().NoError(err)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, suite.authenticatedRequest(""))
+	assert.Equal(suite.T(), http.StatusUnauthorized, suite.authenticatedRequest("Bearer ccs-unknown"))
+	assert.Equal(suite.T(), http.StatusOK, suite.authenticatedRequest("Bearer "+token))
+
+	// Issuing a new token revokes the old one
+	newToken, err := suite.services.Auth.IssueToken(user.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), http.StatusUnauthorized, suite.authenticatedRequest("Bearer "+token))
+	assert.Equal(suite.T(), http.StatusOK, suite.authenticatedRequest("Bearer "+newToken))
+}
+
+// TestCloseBoxRequiresOwner tests that only the purchaser or an admin can close a box
+func (suite *IntegrationTestSuite) TestCloseBoxRequiresOwner() {
+	owner := suite.newUser("Owner")
+	other := suite.newUser("Other")
+	admin := suite.newUser("Admin")
+	suite.Require().NoError(suite.services.User.SetRole(admin.ID, models.RoleAdmin))
+	admin.Role = models.RoleAdmin
+
+	box, err := suite.services.Box.CreateBox("Owned Box", 10, models.NewMoney(1000, "EUR"), owner.ID, suite.team.ID)
+	suite.Require().NoError(err)
+
+	closeAs := func(user *models.User) int {
+		req := httptest.NewRequest("POST", "/api/v1/boxes/1/close", nil)
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+		req = req.WithContext(handlers.ContextWithUser(req.Context(), user))
+		rr := httptest.NewRecorder()
+		suite.handlers.CloseBox(rr, req)
+		return rr.Code
+	}
+
+	suite.Require().Equal(uint(1), box.ID)
+	assert.Equal(suite.T(), http.StatusForbidden, closeAs(other))
+	assert.Equal(suite.T(), http.StatusConflict, closeAs(admin))
+	_, err = suite.services.Box.FinishBox(box.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), http.StatusOK, closeAs(admin))
+}
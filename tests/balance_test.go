@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"github.com/your-username/coffee-cups-system/internal/services"
+)
+
+// TestUserBalance tests balances over open and closed boxes, bought and consumed
+func (suite *IntegrationTestSuite) TestUserBalance() {
+	owner := suite.newUser("Owner")
+	anna := suite.newUser("Anna")
+
+	// Anna drinks half of the owner's open box
+	open, err := suite.services.Box.CreateBox("Open Box", 4, models.NewMoney(800, "EUR"), owner.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	for _, user := range []*models.User{anna, anna, owner} {
+		_, err := suite.services.Coffee.LogCoffee(user.ID, open.ID)
+		suite.Require().NoError(err)
+	}
+
+	// The owner paid Anna for a cup from her closed box
+	closed, err := suite.services.Box.CreateBox("Closed Box", 2, models.NewMoney(400, "EUR"), anna.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	for _, user := range []*models.User{owner, anna} {
+		_, err := suite.services.Coffee.LogCoffee(user.ID, closed.ID)
+		suite.Require().NoError(err)
+	}
+	settlement := suite.closeBox(closed.ID)
+	suite.Require().Len(settlement.Payments, 1)
+	_, err = suite.services.Payment.MarkPaymentAsPaid(settlement.Payments[0].ID)
+	suite.Require().NoError(err)
+
+	balance, err := suite.services.Balance.GetUserBalance(owner.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(balance.Boxes, 2)
+	assert.Equal(suite.T(), services.BoxBalance{
+		BoxID: open.ID, BoxName: "Open Box", IsOwner: true, Cups: 1,
+		Cost: models.NewMoney(200, "EUR"), Paid: models.NewMoney(0, "EUR"),
+		Outstanding: models.NewMoney(0, "EUR"), OwedToUser: models.NewMoney(400, "EUR"),
+	}, balance.Boxes[0])
+	assert.Equal(suite.T(), models.NewMoney(200, "EUR"), balance.Boxes[1].Paid)
+	assert.True(suite.T(), balance.Boxes[1].Outstanding.IsZero())
+	assert.Equal(suite.T(), []models.Money{models.NewMoney(400, "EUR")}, balance.Cost)
+	assert.Equal(suite.T(), []models.Money{models.NewMoney(400, "EUR")}, balance.Net)
+
+	balance, err = suite.services.Balance.GetUserBalance(anna.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), []models.Money{models.NewMoney(400, "EUR")}, balance.Outstanding)
+	assert.Equal(suite.T(), []models.Money{models.NewMoney(0, "EUR")}, balance.OwedToUser)
+	assert.Equal(suite.T(), []models.Money{models.NewMoney(-400, "EUR")}, balance.Net)
+
+	// Users only see their own balance, admins everyone's
+	path := fmt.Sprintf("/api/v1/users/%d/balance", owner.TelegramID)
+	vars := map[string]string{"id": fmt.Sprint(owner.TelegramID)}
+	rr := suite.serve(anna, "GET", path, vars, suite.handlers.GetUserBalance)
+	assert.Equal(suite.T(), http.StatusForbidden, rr.Code)
+	rr = suite.serve(owner, "GET", path, vars, suite.handlers.GetUserBalance)
+	suite.Require().Equal(http.StatusOK, rr.Code)
+	var body services.UserBalance
+	suite.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Len(suite.T(), body.Boxes, 2)
+
+	suite.Require().NoError(suite.services.User.SetRole(anna.ID, models.RoleAdmin))
+	anna.Role = models.RoleAdmin
+	rr = suite.serve(anna, "GET", path, vars, suite.handlers.GetUserBalance)
+	assert.Equal(suite.T(), http.StatusOK, rr.Code)
+	rr = suite.serve(anna, "GET", "/api/v1/users/404/balance", map[string]string{"id": "404"}, suite.handlers.GetUserBalance)
+	assert.Equal(suite.T(), http.StatusNotFound, rr.Code)
+}
+
+// TestBalanceMatchesSettlement tests that the cost shown for an open box is
+// the payment closing it creates, down to the rounded minor unit
+func (suite *IntegrationTestSuite) TestBalanceMatchesSettlement() {
+	owner := suite.newUser("Owner")
+	anna := suite.newUser("Anna")
+	ben := suite.newUser("Ben")
+
+	// 1.00 EUR over 3 cups doesn't divide evenly, and one cup is left over
+	box, err := suite.services.Box.CreateBox("Odd Box", 3, models.NewMoney(100, "EUR"), owner.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	for _, user := range []*models.User{anna, ben} {
+		_, err := suite.services.Coffee.LogCoffee(user.ID, box.ID)
+		suite.Require().NoError(err)
+	}
+
+	costs := make(map[uint]models.Money)
+	for _, user := range []*models.User{anna, ben} {
+		balance, err := suite.services.Balance.GetUserBalance(user.ID)
+		suite.Require().NoError(err)
+		suite.Require().Len(balance.Boxes, 1)
+		costs[user.ID] = balance.Boxes[0].Cost
+	}
+	ownerBalance, err := suite.services.Balance.GetUserBalance(owner.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(ownerBalance.Boxes, 1)
+
+	settlement := suite.closeBox(box.ID)
+	suite.Require().Len(settlement.Payments, 2)
+	owed := models.NewMoney(0, "EUR")
+	for _, payment := range settlement.Payments {
+		assert.Equal(suite.T(), payment.Amount, costs[payment.UserID])
+		owed = owed.Add(payment.Amount)
+	}
+	assert.Equal(suite.T(), owed, ownerBalance.Boxes[0].OwedToUser)
+}
+
+// TestPaymentBeforeClose tests that closing a box only asks for what is left
+// of a share after payments made while the box was open
+func (suite *IntegrationTestSuite) TestPaymentBeforeClose() {
+	owner := suite.newUser("Owner")
+	anna := suite.newUser("Anna")
+	ben := suite.newUser("Ben")
+
+	// 10.00 EUR for 10 cups: anna takes 5, ben 2, owner 1
+	box, err := suite.services.Box.CreateBox("Shared Box", 10, models.NewMoney(1000, "EUR"), owner.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	for _, user := range []*models.User{anna, anna, anna, anna, anna, ben, ben, owner} {
+		_, err := suite.services.Coffee.LogCoffee(user.ID, box.ID)
+		suite.Require().NoError(err)
+	}
+	upfront, err := suite.services.Payment.CreatePayment(anna.ID, box.ID, models.NewMoney(300, "EUR"))
+	suite.Require().NoError(err)
+	_, err = suite.services.Payment.MarkPaymentAsPaid(upfront.ID)
+	suite.Require().NoError(err)
+	_, err = suite.services.Payment.CreatePayment(ben.ID, box.ID, models.NewMoney(500, "EUR"))
+	suite.Require().NoError(err)
+	suite.assertLedgerConsistent()
+
+	settlement := suite.closeBox(box.ID)
+	pending := map[uint]models.Money{anna.ID: models.NewMoney(0, "EUR"), ben.ID: models.NewMoney(0, "EUR")}
+	for _, payment := range settlement.Payments {
+		if !payment.IsPaid {
+			pending[payment.UserID] = pending[payment.UserID].Add(payment.Amount)
+		}
+	}
+	// ben's payment already covers more than his 2.00 EUR share
+	assert.Equal(suite.T(), map[uint]models.Money{anna.ID: models.NewMoney(200, "EUR"), ben.ID: models.NewMoney(500, "EUR")}, pending)
+	suite.assertLedgerConsistent()
+
+	balance, err := suite.services.Balance.GetUserBalance(anna.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(balance.Boxes, 1)
+	assert.Equal(suite.T(), models.NewMoney(500, "EUR"), balance.Boxes[0].Cost)
+	assert.Equal(suite.T(), []models.Money{models.NewMoney(200, "EUR")}, balance.Outstanding)
+	balances := suite.ledgerBalances(nil)
+	assert.Equal(suite.T(), models.NewMoney(200, "EUR"), balances["user:2"])
+	assert.Equal(suite.T(), models.NewMoney(500, "EUR"), balances["user:3"])
+	assert.Equal(suite.T(), models.NewMoney(-700, "EUR"), balances["purchaser:1"])
+}
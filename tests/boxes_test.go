@@ -0,0 +1,196 @@
+package tests
+
+import (
+	"net/http"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"github.com/your-username/coffee-cups-system/internal/services"
+)
+
+// TestBoxLifecycle tests moving a box from open through archived and back
+func (suite *IntegrationTestSuite) TestBoxLifecycle() {
+	owner := suite.newUser("Owner")
+	anna := suite.newUser("Anna")
+	box, err := suite.services.Box.CreateBox("Lifecycle Box", 10, models.NewMoney(1000, "EUR"), owner.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), models.BoxOpen, box.Status)
+	_, err = suite.services.Coffee.LogCoffee(anna.ID, box.ID)
+	suite.Require().NoError(err)
+
+	_, err = suite.services.Box.ArchiveBox(box.ID)
+	assert.ErrorIs(suite.T(), err, services.ErrInvalidBoxTransition)
+
+	finished, err := suite.services.Box.FinishBox(box.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), models.BoxFinished, finished.Status)
+	assert.False(suite.T(), finished.IsActive)
+	_, err = suite.services.Coffee.LogCoffee(anna.ID, box.ID)
+	assert.ErrorIs(suite.T(), err, services.ErrBoxInactive)
+
+	reopened, err := suite.services.Box.ReopenBox(box.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), models.BoxOpen, reopened.Status)
+	assert.True(suite.T(), reopened.IsActive)
+	_, err = suite.services.Box.FinishBox(box.ID)
+	suite.Require().NoError(err)
+
+	settlement, err := suite.services.Box.CloseBox(box.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), models.BoxSettled, settlement.Box.Status)
+	_, err = suite.services.Box.ReopenBox(box.ID)
+	assert.ErrorIs(suite.T(), err, services.ErrInvalidBoxTransition)
+	name := "Renamed"
+	_, err = suite.services.Box.UpdateBox(box.ID, services.BoxChanges{Name: &name})
+	assert.ErrorIs(suite.T(), err, services.ErrBoxSettled)
+
+	archived, err := suite.services.Box.ArchiveBox(box.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), models.BoxArchived, archived.Status)
+	boxes, _, err := suite.services.Box.ListBoxes(services.BoxFilter{TeamID: suite.team.ID, Status: models.BoxArchived})
+	suite.Require().NoError(err)
+	suite.Require().Len(boxes, 1)
+
+	unarchived, err := suite.services.Box.ReopenBox(box.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), models.BoxSettled, unarchived.Status)
+	suite.assertLedgerConsistent()
+}
+
+// TestUpdateBox tests correcting a box after cups were taken from it
+func (suite *IntegrationTestSuite) TestUpdateBox() {
+	owner := suite.newUser("Owner")
+	anna := suite.newUser("Anna")
+	box, err := suite.services.Box.CreateBox("Typo Box", 10, models.NewMoney(1000, "EUR"), owner.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	for i := 0; i < 5; i++ {
+		_, err := suite.services.Coffee.LogCoffee(anna.ID, box.ID)
+		suite.Require().NoError(err)
+	}
+
+	cups := 4
+	_, err = suite.services.Box.UpdateBox(box.ID, services.BoxChanges{TotalCups: &cups})
+	suite.assertFields(err, "total_cups")
+	usd := models.NewMoney(1000, "USD")
+	_, err = suite.services.Box.UpdateBox(box.ID, services.BoxChanges{Price: &usd})
+	suite.assertFields(err, "price.currency")
+
+	// 20 cups for 30.00 make every cup 1.50, also the five already taken
+	name, cups, price := " Big Box ", 20, models.NewMoney(3000, "EUR")
+	updated, err := suite.services.Box.UpdateBox(box.ID, services.BoxChanges{Name: &name, TotalCups: &cups, Price: &price})
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "Big Box", updated.Name)
+	assert.Equal(suite.T(), models.NewMoney(150, "EUR"), updated.GetCostPerCup())
+	suite.assertLedgerConsistent()
+	balances := suite.ledgerBalances(nil)
+	assert.Equal(suite.T(), models.NewMoney(750, "EUR"), balances["user:2"])
+	assert.Equal(suite.T(), models.NewMoney(-3000, "EUR"), balances["purchaser:1"])
+
+	// Voiding a cup reverses it at the new cost
+	_, err = suite.services.Coffee.VoidCoffeeLog(1, owner.ID, "logged twice")
+	suite.Require().NoError(err)
+	suite.assertLedgerConsistent()
+}
+
+// TestUpdateBoxThreshold tests that updates only check the low-stock
+// threshold when they set it and lower it when the box shrinks below it
+func (suite *IntegrationTestSuite) TestUpdateBoxThreshold() {
+	owner := suite.newUser("Owner")
+	box, err := suite.services.Box.CreateBox("Big Box", 10, models.NewMoney(1000, "EUR"), owner.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(3, box.LowStockThreshold)
+
+	cups := 3
+	updated, err := suite.services.Box.UpdateBox(box.ID, services.BoxChanges{TotalCups: &cups})
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 2, updated.LowStockThreshold)
+
+	// A box whose threshold no longer fits can still be renamed
+	suite.Require().NoError(suite.services.Coffee.GetDB().Model(&models.Box{}).
+		Where("id = ?", box.ID).Update("low_stock_threshold", 3).Error)
+	vars := map[string]string{"id": "1"}
+	rr := suite.serveJSON(owner, "PATCH", "/api/v1/boxes/1", vars, map[string]interface{}{"name": "Small Box"}, suite.handlers.UpdateBox)
+	suite.Require().Equal(http.StatusOK, rr.Code)
+	rr = suite.serveJSON(owner, "PATCH", "/api/v1/boxes/1", vars, map[string]interface{}{"low_stock_threshold": 3}, suite.handlers.UpdateBox)
+	assert.Equal(suite.T(), http.StatusUnprocessableEntity, rr.Code)
+
+	threshold := 1
+	updated, err = suite.services.Box.UpdateBox(box.ID, services.BoxChanges{TotalCups: &cups, LowStockThreshold: &threshold})
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 1, updated.LowStockThreshold)
+}
+
+// TestUpdatePrepaidBox tests that prepaid cups keep the cost they were paid at
+func (suite *IntegrationTestSuite) TestUpdatePrepaidBox() {
+	owner := suite.newUser("Owner")
+	anna := suite.newUser("Anna")
+	_, err := suite.services.Team.SetBillingMode(suite.team.ID, models.BillingPrepaid)
+	suite.Require().NoError(err)
+	box, err := suite.services.Box.CreateBox("Prepaid Box", 4, models.NewMoney(800, "EUR"), owner.ID, suite.team.ID)
+	suite.Require().NoError(err)
+
+	price := models.NewMoney(400, "EUR")
+	_, err = suite.services.Box.UpdateBox(box.ID, services.BoxChanges{Price: &price})
+	suite.Require().NoError(err)
+	_, err = suite.services.Wallet.TopUp(suite.team.ID, anna.ID, models.NewMoney(500, "EUR"), "", owner.ID)
+	suite.Require().NoError(err)
+	_, err = suite.services.Coffee.LogCoffee(anna.ID, box.ID)
+	suite.Require().NoError(err)
+
+	price = models.NewMoney(800, "EUR")
+	_, err = suite.services.Box.UpdateBox(box.ID, services.BoxChanges{Price: &price})
+	assert.ErrorIs(suite.T(), err, services.ErrBoxPriceLocked)
+	name := "Renamed"
+	_, err = suite.services.Box.UpdateBox(box.ID, services.BoxChanges{Name: &name})
+	suite.Require().NoError(err)
+	suite.assertLedgerConsistent()
+}
+
+// TestDeleteBox tests that only unused boxes can be deleted
+func (suite *IntegrationTestSuite) TestDeleteBox() {
+	owner := suite.newUser("Owner")
+	anna := suite.newUser("Anna")
+	price := models.NewMoney(1000, "EUR")
+	mistake, err := suite.services.Box.CreateBox("Mistake", 10, price, owner.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	used, err := suite.services.Box.CreateBox("Used", 10, price, owner.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	_, err = suite.services.Coffee.LogCoffee(anna.ID, used.ID)
+	suite.Require().NoError(err)
+
+	assert.ErrorIs(suite.T(), suite.services.Box.DeleteBox(used.ID), services.ErrBoxInUse)
+	suite.Require().NoError(suite.services.Box.DeleteBox(mistake.ID))
+	_, err = suite.services.Box.GetBoxByID(mistake.ID)
+	assert.ErrorIs(suite.T(), err, services.ErrBoxNotFound)
+	suite.assertLedgerConsistent()
+}
+
+// TestBoxLifecycleHandlers tests the box owner endpoints
+func (suite *IntegrationTestSuite) TestBoxLifecycleHandlers() {
+	owner := suite.newUser("Owner")
+	anna := suite.newUser("Anna")
+	_, err := suite.services.Box.CreateBox("Office Box", 10, models.NewMoney(1000, "EUR"), owner.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	vars := map[string]string{"id": "1"}
+
+	rr := suite.serveJSON(anna, "PATCH", "/api/v1/boxes/1", vars, map[string]interface{}{"name": "Mine"}, suite.handlers.UpdateBox)
+	assert.Equal(suite.T(), http.StatusForbidden, rr.Code)
+	rr = suite.serveJSON(owner, "PATCH", "/api/v1/boxes/1", vars, map[string]interface{}{"total_cups": 0}, suite.handlers.UpdateBox)
+	assert.Equal(suite.T(), http.StatusUnprocessableEntity, rr.Code)
+	rr = suite.serveJSON(owner, "PATCH", "/api/v1/boxes/1", vars, map[string]interface{}{"total_cups": 12}, suite.handlers.UpdateBox)
+	suite.Require().Equal(http.StatusOK, rr.Code)
+
+	rr = suite.serve(owner, "POST", "/api/v1/boxes/1/archive", vars, suite.handlers.ArchiveBox)
+	assert.Equal(suite.T(), http.StatusConflict, rr.Code)
+	assert.Equal(suite.T(), services.ErrInvalidBoxTransition.Code, suite.decodeError(rr).Error)
+	rr = suite.serve(owner, "POST", "/api/v1/boxes/1/finish", vars, suite.handlers.FinishBox)
+	assert.Equal(suite.T(), http.StatusOK, rr.Code)
+	rr = suite.serve(owner, "POST", "/api/v1/boxes/1/reopen", vars, suite.handlers.ReopenBox)
+	assert.Equal(suite.T(), http.StatusOK, rr.Code)
+
+	rr = suite.serve(owner, "DELETE", "/api/v1/boxes/1", vars, suite.handlers.DeleteBox)
+	assert.Equal(suite.T(), http.StatusNoContent, rr.Code)
+	rr = suite.serve(owner, "GET", "/api/v1/boxes/1", vars, suite.handlers.GetBox)
+	assert.Equal(suite.T(), http.StatusNotFound, rr.Code)
+	suite.assertLedgerConsistent()
+}
@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"github.com/your-username/coffee-cups-system/internal/services"
+)
+
+// TestUndoCoffee tests that undoing voids the latest log within the window
+func (suite *IntegrationTestSuite) TestUndoCoffee() {
+	anna := suite.newUser("Anna")
+	box, err := suite.services.Box.CreateBox("Undo Box", 10, models.NewMoney(1000, "EUR"), anna.ID, suite.team.ID)
+	suite.Require().NoError(err)
+
+	first, err := suite.services.Coffee.LogCoffee(anna.ID, box.ID)
+	suite.Require().NoError(err)
+	second, err := suite.services.Coffee.LogCoffee(anna.ID, box.ID)
+	suite.Require().NoError(err)
+
+	_, err = suite.services.Coffee.UndoLastCoffee(anna.ID, 0)
+	assert.ErrorIs(suite.T(), err, services.ErrNothingToUndo)
+
+	undone, err := suite.services.Coffee.UndoLastCoffee(anna.ID, time.Minute)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), second.ID, undone.ID)
+	assert.True(suite.T(), undone.IsVoided())
+	assert.Equal(suite.T(), anna.ID, *undone.VoidedBy)
+	assert.Equal(suite.T(), services.UndoReason, undone.VoidReason)
+
+	used, err := box.GetUsedCups(suite.db.DB)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 1, used)
+
+	logs, err := suite.services.Coffee.GetUserCoffeeLogs(anna.ID, 0)
+	suite.Require().NoError(err)
+	suite.Require().Len(logs, 1)
+	assert.Equal(suite.T(), first.ID, logs[0].ID)
+
+	// The older log stays, since only the most recent one can be undone
+	_, err = suite.services.Coffee.UndoLastCoffee(anna.ID, time.Minute)
+	assert.ErrorIs(suite.T(), err, services.ErrNothingToUndo)
+	used, err = box.GetUsedCups(suite.db.DB)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 1, used)
+}
+
+// TestVoidCoffeeLog tests that voided logs are kept but no longer cost anything
+func (suite *IntegrationTestSuite) TestVoidCoffeeLog() {
+	owner := suite.newUser("Owner")
+	anna := suite.newUser("Anna")
+	admin := suite.newUser("Admin")
+	suite.Require().NoError(suite.services.User.SetRole(admin.ID, models.RoleAdmin))
+	admin.Role = models.RoleAdmin
+
+	box, err := suite.services.Box.CreateBox("Void Box", 2, models.NewMoney(1000, "EUR"), owner.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	for i := 0; i < 2; i++ {
+		_, err := suite.services.Coffee.LogCoffee(anna.ID, box.ID)
+		suite.Require().NoError(err)
+	}
+
+	vars := map[string]string{"id": "2"}
+	body := map[string]string{"reason": "logged twice by mistake"}
+	rr := suite.serveJSON(anna, "POST", "/api/v1/coffee-logs/2/void", vars, body, suite.handlers.RequireAdmin(suite.handlers.VoidCoffeeLog))
+	assert.Equal(suite.T(), http.StatusForbidden, rr.Code)
+
+	rr = suite.serveJSON(admin, "POST", "/api/v1/coffee-logs/2/void", vars, nil, suite.handlers.VoidCoffeeLog)
+	assert.Equal(suite.T(), http.StatusBadRequest, rr.Code)
+
+	rr = suite.serveJSON(admin, "POST", "/api/v1/coffee-logs/2/void", vars, body, suite.handlers.VoidCoffeeLog)
+	suite.Require().Equal(http.StatusOK, rr.Code)
+
+	rr = suite.serveJSON(admin, "POST", "/api/v1/coffee-logs/2/void", vars, body, suite.handlers.VoidCoffeeLog)
+	assert.Equal(suite.T(), http.StatusConflict, rr.Code)
+
+	// Anna only pays for the cup she kept; the freed cup goes back to the box
+	debt, err := suite.services.Payment.CalculateUserDebt(anna.ID, box.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), int64(500), debt.MinorUnits)
+
+	_, err = suite.services.Coffee.LogCoffee(owner.ID, box.ID)
+	suite.Require().NoError(err)
+
+	suite.closeBox(box.ID)
+	_, err = suite.services.Coffee.VoidCoffeeLog(1, admin.ID, "too late")
+	assert.ErrorIs(suite.T(), err, services.ErrCoffeeLogSettled)
+}
+
+// TestStockEvents tests the events published as a box runs low and empty
+func (suite *IntegrationTestSuite) TestStockEvents() {
+	var events []services.Event
+	suite.services.Events.Subscribe(func(event services.Event) {
+		events = append(events, event)
+	})
+
+	owner := suite.newUser("Owner")
+	body := map[string]interface{}{
+		"name":                "Stock Box",
+		"total_cups":          3,
+		"price":               map[string]interface{}{"minor_units": 900, "currency": "EUR"},
+		"low_stock_threshold": 3,
+	}
+	rr := suite.serveJSON(owner, "POST", "/api/v1/teams/1/boxes", nil, body, suite.handlers.CreateBox)
+	assert.Equal(suite.T(), http.StatusUnprocessableEntity, rr.Code)
+	body["low_stock_threshold"] = 1
+	rr = suite.serveJSON(owner, "POST", "/api/v1/teams/1/boxes", nil, body, suite.handlers.CreateBox)
+	suite.Require().Equal(http.StatusCreated, rr.Code)
+
+	for i := 0; i < 3; i++ {
+		_, err := suite.services.Coffee.LogCoffee(owner.ID, 1)
+		suite.Require().NoError(err)
+	}
+	suite.Require().Len(events, 2)
+	low, ok := events[0].(services.BoxLowStockEvent)
+	suite.Require().True(ok)
+	assert.Equal(suite.T(), 1, low.Remaining)
+	assert.Equal(suite.T(), "Owner", low.Box.Creator.FirstName)
+	empty, ok := events[1].(services.BoxEmptyEvent)
+	suite.Require().True(ok)
+	assert.Equal(suite.T(), "Stock Box", empty.Box.Name)
+
+	// Taking the voided cups again doesn't warn about low stock twice
+	for _, logID := range []uint{2, 3} {
+		_, err := suite.services.Coffee.VoidCoffeeLog(logID, owner.ID, "wrong box")
+		suite.Require().NoError(err)
+	}
+	_, err := suite.services.Coffee.LogCoffee(owner.ID, 1)
+	suite.Require().NoError(err)
+	assert.Len(suite.T(), events, 2)
+
+	// A new threshold is warned about again
+	_, err = suite.services.Box.SetLowStockThreshold(1, 2)
+	suite.Require().NoError(err)
+	_, err = suite.services.Box.SetLowStockThreshold(1, 1)
+	suite.Require().NoError(err)
+	_, err = suite.services.Coffee.VoidCoffeeLog(4, owner.ID, "wrong box")
+	suite.Require().NoError(err)
+	_, err = suite.services.Coffee.LogCoffee(owner.ID, 1)
+	suite.Require().NoError(err)
+	suite.Require().Len(events, 3)
+	assert.IsType(suite.T(), services.BoxLowStockEvent{}, events[2])
+
+	_, err = suite.services.Box.SetLowStockThreshold(1, -1)
+	assert.ErrorIs(suite.T(), err, services.ErrInvalidLowStockThreshold)
+
+	small, err := suite.services.Box.CreateBox("Small Box", 2, models.NewMoney(200, "EUR"), owner.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 1, small.LowStockThreshold)
+}
@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/your-username/coffee-cups-system/internal/config"
+	"github.com/your-username/coffee-cups-system/internal/database"
+)
+
+// openTestDatabase opens an empty database for a test. When the configured
+// Postgres database can't be reached the test is skipped.
+func openTestDatabase(t *testing.T) *database.Database {
+	cfg, err := testDatabaseConfig()
+	require.NoError(t, err)
+
+	db, err := database.New(cfg)
+	if err != nil && cfg.Driver == database.DriverPostgres {
+		t.Skipf("Postgres test database is unavailable: %v", err)
+	}
+	require.NoError(t, err)
+	if cfg.Driver == database.DriverPostgres {
+		// Start from an empty schema, as with a new in-memory database
+		require.NoError(t, db.Exec("DROP SCHEMA public CASCADE; CREATE SCHEMA public;").Error)
+	}
+	return db
+}
+
+// testDatabaseConfig selects the database the integration suite runs on.
+// By default every test gets an in-memory SQLite database. With
+// TEST_DATABASE_DRIVER=postgres the suite uses the Postgres database given by
+// TEST_DATABASE_HOST, TEST_DATABASE_PORT, TEST_DATABASE_USER,
+// TEST_DATABASE_PASSWORD and TEST_DATABASE_DBNAME, which is wiped before
+// every test.
+func testDatabaseConfig() (config.DatabaseConfig, error) {
+	driver := os.Getenv("TEST_DATABASE_DRIVER")
+	switch driver {
+	case "", database.DriverSQLite:
+		return config.DatabaseConfig{Driver: database.DriverSQLite, Path: ":memory:"}, nil
+	case database.DriverPostgres:
+	default:
+		return config.DatabaseConfig{}, fmt.Errorf("unsupported TEST_DATABASE_DRIVER %q", driver)
+	}
+
+	port := 5432
+	if value := os.Getenv("TEST_DATABASE_PORT"); value != "" {
+		var err error
+		if port, err = strconv.Atoi(value); err != nil {
+			return config.DatabaseConfig{}, fmt.Errorf("invalid TEST_DATABASE_PORT %q: %w", value, err)
+		}
+	}
+	return config.DatabaseConfig{
+		Driver:   database.DriverPostgres,
+		Host:     envOr("TEST_DATABASE_HOST", "localhost"),
+		Port:     port,
+		User:     envOr("TEST_DATABASE_USER", "coffee_user"),
+		Password: envOr("TEST_DATABASE_PASSWORD", "coffee_password"),
+		DBName:   envOr("TEST_DATABASE_DBNAME", "coffee_cups_test"),
+		SSLMode:  "disable",
+	}, nil
+}
+
+// envOr returns the environment variable key, or fallback when it is unset
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/your-username/coffee-cups-system/internal/handlers"
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"github.com/your-username/coffee-cups-system/internal/services"
+)
+
+// TestBoxErrors tests that LogCoffee tells missing, exhausted and closed boxes apart
+func (suite *IntegrationTestSuite) TestBoxErrors() {
+	anna := suite.newUser("Anna")
+	box, err := suite.services.Box.CreateBox("Tiny Box", 1, models.NewMoney(300, "EUR"), anna.ID, suite.team.ID)
+	suite.Require().NoError(err)
+
+	_, err = suite.services.Coffee.LogCoffee(anna.ID, 42)
+	assert.ErrorIs(suite.T(), err, services.ErrBoxNotFound)
+
+	_, err = suite.services.Coffee.LogCoffee(anna.ID, box.ID)
+	suite.Require().NoError(err)
+	_, err = suite.services.Coffee.LogCoffee(anna.ID, box.ID)
+	assert.ErrorIs(suite.T(), err, services.ErrBoxExhausted)
+
+	suite.closeBox(box.ID)
+	_, err = suite.services.Coffee.LogCoffee(anna.ID, box.ID)
+	assert.ErrorIs(suite.T(), err, services.ErrBoxInactive)
+
+	_, err = suite.services.Box.GetBoxByID(42)
+	assert.ErrorIs(suite.T(), err, services.ErrBoxNotFound)
+}
+
+// TestErrorResponses tests the status and JSON body of failed requests
+func (suite *IntegrationTestSuite) TestErrorResponses() {
+	anna := suite.newUser("Anna")
+	box, err := suite.services.Box.CreateBox("Tiny Box", 1, models.NewMoney(300, "EUR"), anna.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	_, err = suite.services.Coffee.LogCoffee(anna.ID, box.ID)
+	suite.Require().NoError(err)
+
+	cases := []struct {
+		body   interface{}
+		status int
+		code   string
+	}{
+		{"not an object", http.StatusBadRequest, handlers.CodeInvalidRequest},
+		{map[string]uint{"box_id": 42}, http.StatusNotFound, services.ErrBoxNotFound.Code},
+		{map[string]uint{"box_id": box.ID}, http.StatusConflict, services.ErrBoxExhausted.Code},
+	}
+	for _, c := range cases {
+		rr := suite.serveJSON(anna, "POST", "/api/v1/coffee-logs", nil, c.body, suite.handlers.LogCoffee)
+		assert.Equal(suite.T(), c.status, rr.Code)
+		assert.Equal(suite.T(), c.code, suite.decodeError(rr).Error)
+	}
+
+	rr := suite.serve(anna, "GET", "/api/v1/boxes/42", map[string]string{"id": "42"}, suite.handlers.GetBox)
+	assert.Equal(suite.T(), http.StatusNotFound, rr.Code)
+	failure := suite.decodeError(rr)
+	assert.Equal(suite.T(), services.ErrBoxNotFound.Code, failure.Error)
+	assert.Equal(suite.T(), services.ErrBoxNotFound.Message, failure.Message)
+}
+
+// decodeError reads the JSON error body of a response
+func (suite *IntegrationTestSuite) decodeError(rr *httptest.ResponseRecorder) handlers.ErrorResponse {
+	assert.Equal(suite.T(), "application/json", rr.Header().Get("Content-Type"))
+	var body handlers.ErrorResponse
+	suite.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &body))
+	return body
+}
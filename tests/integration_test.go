@@ -5,13 +5,15 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
-	"github.com/your-username/coffee-cups-system/internal/config"
 	"github.com/your-username/coffee-cups-system/internal/database"
 	"github.com/your-username/coffee-cups-system/internal/handlers"
+	"github.com/your-username/coffee-cups-system/internal/models"
 	"github.com/your-username/coffee-cups-system/internal/services"
 )
 
@@ -21,30 +23,38 @@ type IntegrationTestSuite struct {
 	db       *database.Database
 	services *services.Services
 	handlers *handlers.Handlers
+	team     *models.Team
+	nextID   int64
 }
 
-// SetupSuite sets up the test suite
-func (suite *IntegrationTestSuite) SetupSuite() {
-	// Use test database configuration
-	cfg := config.DatabaseConfig{
-		Host:     "localhost",
-		Port:     5432,
-		User:     "test_user",
-		Password: "test_password",
-		DBName:   "test_coffee_cups",
-		SSLMode:  "disable",
-	}
-
-	db, err := database.New(cfg)
-	assert.NoError(suite.T(), err)
+// SetupTest gives every test a fresh, fully migrated database
+func (suite *IntegrationTestSuite) SetupTest() {
+	db := openTestDatabase(suite.T())
 	suite.db = db
 
+	migrator, err := db.Migrator()
+	suite.Require().NoError(err)
+	_, err = migrator.Up()
+	suite.Require().NoError(err)
+
 	suite.services = services.NewServices(suite.db.DB, nil)
 	suite.handlers = handlers.New(suite.services, nil)
+
+	suite.team, err = suite.services.Team.CreateTeam("Test Team")
+	suite.Require().NoError(err)
+}
+
+// newUser creates a member of the test team with a unique Telegram ID
+func (suite *IntegrationTestSuite) newUser(firstName string) *models.User {
+	suite.nextID++
+	user, err := suite.services.User.CreateOrUpdateUser(suite.nextID, "", firstName, "Tester")
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.services.Team.AddMember(suite.team.ID, user.ID))
+	return user
 }
 
-// TearDownSuite cleans up after tests
-func (suite *IntegrationTestSuite) TearDownSuite() {
+// TearDownTest drops the test database
+func (suite *IntegrationTestSuite) TearDownTest() {
 	if suite.db != nil {
 		suite.db.Close()
 	}
@@ -62,23 +72,169 @@ func (suite *IntegrationTestSuite) TestCoffeeLogging() {
 	// and verifying the log is created correctly
 }
 
+// TestConcurrentCoffeeLogging tests that parallel logs never exceed box capacity.
+// SQLite serializes everything through one connection, so only Postgres
+// shows whether the box row lock holds.
+func (suite *IntegrationTestSuite) TestConcurrentCoffeeLogging() {
+	if suite.db.Dialector.Name() != database.DriverPostgres {
+		suite.T().Skip("row locking needs Postgres; set TEST_DATABASE_DRIVER=postgres and TEST_DATABASE_* to run this test")
+	}
+	const totalCups = 5
+	const attempts = 20
+
+	user := suite.newUser("Racer")
+
+	box, err := suite.services.Box.CreateBox("Race Box", totalCups, models.NewMoney(500, "EUR"), user.ID, suite.team.ID)
+	suite.Require().NoError(err)
+
+	var wg sync.WaitGroup
+	var succeeded int32
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := suite.services.Coffee.LogCoffee(user.ID, box.ID); err == nil {
+				atomic.AddInt32(&succeeded, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(suite.T(), int32(totalCups), succeeded)
+
+	used, err := box.GetUsedCups(suite.db.DB)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), totalCups, used)
+}
+
+// TestCloseBox tests that closing a box creates payments that add up to its price
+func (suite *IntegrationTestSuite) TestCloseBox() {
+	owner := suite.newUser("Owner")
+	anna := suite.newUser("Anna")
+	boris := suite.newUser("Boris")
+
+	box, err := suite.services.Box.CreateBox("Settled Box", 3, models.NewMoney(1000, "EUR"), owner.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	for _, user := range []*models.User{owner, anna, boris} {
+		_, err := suite.services.Coffee.LogCoffee(user.ID, box.ID)
+		suite.Require().NoError(err)
+	}
+
+	_, err = suite.services.Box.CloseBox(box.ID)
+	assert.ErrorIs(suite.T(), err, services.ErrInvalidBoxTransition)
+	settlement := suite.closeBox(box.ID)
+	suite.Require().Len(settlement.Payments, 2)
+	assert.False(suite.T(), settlement.Box.IsActive)
+	assert.True(suite.T(), settlement.Box.IsClosed())
+
+	// The owner keeps the extra cent as the lowest user ID, so 3.34 + 3.33 + 3.33
+	assert.Equal(suite.T(), anna.ID, settlement.Payments[0].UserID)
+	assert.Equal(suite.T(), int64(333), settlement.Payments[0].Amount.MinorUnits)
+	assert.Equal(suite.T(), boris.ID, settlement.Payments[1].UserID)
+	assert.Equal(suite.T(), int64(333), settlement.Payments[1].Amount.MinorUnits)
+
+	// Closing again is a no-op
+	again, err := suite.services.Box.CloseBox(box.ID)
+	suite.Require().NoError(err)
+	assert.Len(suite.T(), again.Payments, 2)
+
+	_, err = suite.services.Coffee.LogCoffee(anna.ID, box.ID)
+	assert.Error(suite.T(), err)
+}
+
+// TestSettlementPlan tests netting payments into a minimal set of transfers
+func (suite *IntegrationTestSuite) TestSettlementPlan() {
+	anna := suite.newUser("Anna")
+	boris := suite.newUser("Boris")
+	chen := suite.newUser("Chen")
+
+	// Anna owes Boris 5.00, Boris owes Chen 3.00, Chen owes Anna 1.00
+	suite.createPayment(anna, boris, 500)
+	suite.createPayment(boris, chen, 300)
+	suite.createPayment(chen, anna, 100)
+
+	plan, err := suite.services.Settlement.GetPlan(suite.team.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(plan.Transfers, 2)
+	assert.Len(suite.T(), plan.PaymentIDs, 3)
+
+	assert.Equal(suite.T(), "Anna", plan.Transfers[0].FromName)
+	assert.Equal(suite.T(), "Boris", plan.Transfers[0].ToName)
+	assert.Equal(suite.T(), int64(200), plan.Transfers[0].Amount.MinorUnits)
+	assert.Equal(suite.T(), "Anna", plan.Transfers[1].FromName)
+	assert.Equal(suite.T(), "Chen", plan.Transfers[1].ToName)
+	assert.Equal(suite.T(), int64(200), plan.Transfers[1].Amount.MinorUnits)
+
+	_, err = suite.services.Settlement.AcceptPlan(suite.team.ID, "stale", anna.ID)
+	assert.ErrorIs(suite.T(), err, services.ErrSettlementPlanChanged)
+
+	settlement, err := suite.services.Settlement.AcceptPlan(suite.team.ID, plan.Token, anna.ID)
+	suite.Require().NoError(err)
+
+	payments, err := suite.services.Payment.GetUserPayments(anna.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(payments, 1)
+	assert.True(suite.T(), payments[0].IsPaid)
+	assert.Equal(suite.T(), settlement.ID, *payments[0].SettlementID)
+
+	empty, err := suite.services.Settlement.GetPlan(suite.team.ID)
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), empty.Transfers)
+	suite.assertLedgerConsistent()
+}
+
+// closeBox finishes a box and closes it
+func (suite *IntegrationTestSuite) closeBox(boxID uint) *services.BoxSettlement {
+	_, err := suite.services.Box.FinishBox(boxID)
+	suite.Require().NoError(err)
+	settlement, err := suite.services.Box.CloseBox(boxID)
+	suite.Require().NoError(err)
+	return settlement
+}
+
+// createPayment records that debtor owes creditor for a box creditor bought
+func (suite *IntegrationTestSuite) createPayment(debtor, creditor *models.User, minorUnits int64) {
+	box, err := suite.services.Box.CreateBox("Shared Box", 10, models.NewMoney(1000, "EUR"), creditor.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	_, err = suite.services.Payment.CreatePayment(debtor.ID, box.ID, models.NewMoney(minorUnits, "EUR"))
+	suite.Require().NoError(err)
+}
+
+// TestMigrationStatus tests that every migration has been applied
+func (suite *IntegrationTestSuite) TestMigrationStatus() {
+	migrator, err := suite.db.Migrator()
+	suite.Require().NoError(err)
+
+	pending, err := migrator.Pending()
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), pending)
+}
+
 // TestBoxCreation tests box creation functionality
 func (suite *IntegrationTestSuite) TestBoxCreation() {
+	user := suite.newUser("Owner")
+
 	reqBody := map[string]interface{}{
 		"name":       "Test Coffee Box",
 		"total_cups": 20,
-		"price":      15.99,
-		"created_by": 1,
+		"price":      map[string]interface{}{"minor_units": 1599, "currency": "EUR"},
 	}
 
 	jsonBody, _ := json.Marshal(reqBody)
-	req := httptest.NewRequest("POST", "/api/v1/boxes", bytes.NewBuffer(jsonBody))
+	req := httptest.NewRequest("POST", "/api/v1/teams/1/boxes", bytes.NewBuffer(jsonBody))
 	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(handlers.ContextWithTeam(handlers.ContextWithUser(req.Context(), user), suite.team))
 
 	rr := httptest.NewRecorder()
 	suite.handlers.CreateBox(rr, req)
 
 	assert.Equal(suite.T(), http.StatusCreated, rr.Code)
+
+	// The creator comes from the authenticated user, not the body
+	var box models.Box
+	suite.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &box))
+	assert.Equal(suite.T(), user.ID, box.CreatedBy)
+	assert.Equal(suite.T(), suite.team.ID, box.TeamID)
 }
 
 // Run the test suite
@@ -0,0 +1,172 @@
+package tests
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"github.com/your-username/coffee-cups-system/internal/services"
+)
+
+// TestLedger tests that every money movement is posted and the ledger checks out
+func (suite *IntegrationTestSuite) TestLedger() {
+	owner := suite.newUser("Owner")
+	anna := suite.newUser("Anna")
+	boris := suite.newUser("Boris")
+
+	// Three cups for 10.00 cost 3.34 each until the box is closed
+	box, err := suite.services.Box.CreateBox("Ledger Box", 3, models.NewMoney(1000, "EUR"), owner.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	for _, user := range []*models.User{anna, boris, owner} {
+		_, err := suite.services.Coffee.LogCoffee(user.ID, box.ID)
+		suite.Require().NoError(err)
+	}
+	_, err = suite.services.Coffee.UndoLastCoffee(boris.ID, time.Minute)
+	suite.Require().NoError(err)
+	_, err = suite.services.Coffee.LogCoffee(anna.ID, box.ID)
+	suite.Require().NoError(err)
+	suite.assertLedgerConsistent()
+
+	settlement := suite.closeBox(box.ID)
+	suite.Require().Len(settlement.Payments, 1)
+	_, err = suite.services.Payment.MarkPaymentAsPaid(settlement.Payments[0].ID)
+	suite.Require().NoError(err)
+
+	// A payment asked for by hand, then written off
+	other, err := suite.services.Box.CreateBox("Other Box", 10, models.NewMoney(500, "EUR"), anna.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	charge, err := suite.services.Payment.CreatePayment(boris.ID, other.ID, models.NewMoney(150, "EUR"))
+	suite.Require().NoError(err)
+	suite.assertLedgerConsistent()
+	_, err = suite.services.Payment.CancelPayment(charge.ID)
+	suite.Require().NoError(err)
+	suite.assertLedgerConsistent()
+
+	balances := suite.ledgerBalances(nil)
+	assert.Equal(suite.T(), models.NewMoney(0, "EUR"), balances["box:1"])
+	assert.Equal(suite.T(), models.NewMoney(0, "EUR"), balances["user:2"])
+	assert.Equal(suite.T(), models.NewMoney(0, "EUR"), balances["user:3"])
+	assert.Equal(suite.T(), models.NewMoney(-500, "EUR"), balances["purchaser:2"])
+
+	// Before the box was closed Anna owed for her two cups
+	var closing models.LedgerTransaction
+	suite.Require().NoError(suite.db.DB.Where("kind = ?", models.LedgerBoxClose).First(&closing).Error)
+	before := closing.CreatedAt.Add(-time.Nanosecond)
+	balances = suite.ledgerBalances(&before)
+	assert.Equal(suite.T(), models.NewMoney(668, "EUR"), balances["user:2"])
+	assert.Equal(suite.T(), models.NewMoney(-2, "EUR"), balances["box:1"])
+}
+
+// TestLedgerPrepaid tests the ledger of a prepaid team's wallets and boxes
+func (suite *IntegrationTestSuite) TestLedgerPrepaid() {
+	owner := suite.newUser("Owner")
+	anna := suite.newUser("Anna")
+	_, err := suite.services.Team.SetBillingMode(suite.team.ID, models.BillingPrepaid)
+	suite.Require().NoError(err)
+
+	_, err = suite.services.Wallet.TopUp(suite.team.ID, anna.ID, models.NewMoney(1000, "EUR"), "", owner.ID)
+	suite.Require().NoError(err)
+	box, err := suite.services.Box.CreateBox("Prepaid Box", 4, models.NewMoney(800, "EUR"), owner.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	for i := 0; i < 2; i++ {
+		_, err := suite.services.Coffee.LogCoffee(anna.ID, box.ID)
+		suite.Require().NoError(err)
+	}
+	_, err = suite.services.Coffee.UndoLastCoffee(anna.ID, time.Minute)
+	suite.Require().NoError(err)
+	suite.closeBox(box.ID)
+	suite.assertLedgerConsistent()
+
+	balances := suite.ledgerBalances(nil)
+	assert.Equal(suite.T(), models.NewMoney(1000, "EUR"), balances["team_cash:1"])
+	assert.Equal(suite.T(), models.NewMoney(-800, "EUR"), balances["wallet:1:2"])
+	assert.Equal(suite.T(), models.NewMoney(-200, "EUR"), balances["purchaser:1"])
+	assert.Equal(suite.T(), models.NewMoney(0, "EUR"), balances["box:1"])
+}
+
+// TestLedgerModeSwitch tests that boxes keep the billing mode they were used
+// in when their team switches modes
+func (suite *IntegrationTestSuite) TestLedgerModeSwitch() {
+	owner := suite.newUser("Owner")
+	anna := suite.newUser("Anna")
+	closed, err := suite.services.Box.CreateBox("Closed Box", 2, models.NewMoney(400, "EUR"), owner.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	finished, err := suite.services.Box.CreateBox("Finished Box", 5, models.NewMoney(500, "EUR"), owner.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	for _, log := range []struct{ user, box uint }{{anna.ID, closed.ID}, {owner.ID, closed.ID}, {anna.ID, finished.ID}} {
+		_, err := suite.services.Coffee.LogCoffee(log.user, log.box)
+		suite.Require().NoError(err)
+	}
+	suite.closeBox(closed.ID)
+	_, err = suite.services.Box.FinishBox(finished.ID)
+	suite.Require().NoError(err)
+
+	// A finished box isn't settled yet, so its team can't switch
+	_, err = suite.services.Team.SetBillingMode(suite.team.ID, models.BillingPrepaid)
+	assert.ErrorIs(suite.T(), err, services.ErrBillingModeInUse)
+	_, err = suite.services.Box.CloseBox(finished.ID)
+	suite.Require().NoError(err)
+	_, err = suite.services.Team.SetBillingMode(suite.team.ID, models.BillingPrepaid)
+	suite.Require().NoError(err)
+
+	// The closed boxes are still owed through their payments
+	suite.assertLedgerConsistent()
+	balance, err := suite.services.Balance.GetUserBalance(anna.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), []models.Money{models.NewMoney(300, "EUR")}, balance.Outstanding)
+	assert.False(suite.T(), balance.Boxes[0].IsPrepaid)
+
+	// New boxes are prepaid
+	box, err := suite.services.Box.CreateBox("Prepaid Box", 2, models.NewMoney(400, "EUR"), owner.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	assert.True(suite.T(), box.IsPrepaid())
+}
+
+// TestLedgerCheck tests that the check finds tampering and opening balances restore the ledger
+func (suite *IntegrationTestSuite) TestLedgerCheck() {
+	owner := suite.newUser("Owner")
+	anna := suite.newUser("Anna")
+	box, err := suite.services.Box.CreateBox("Check Box", 10, models.NewMoney(1000, "EUR"), owner.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	_, err = suite.services.Coffee.LogCoffee(anna.ID, box.ID)
+	suite.Require().NoError(err)
+
+	_, err = suite.services.Ledger.Open()
+	assert.ErrorIs(suite.T(), err, services.ErrLedgerNotEmpty)
+
+	suite.Require().NoError(suite.db.DB.Model(&models.LedgerPosting{}).Where("id = 1").
+		Update("amount_minor_units", 900).Error)
+	check, err := suite.services.Ledger.Check()
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), []string{
+		"transaction 1 does not balance: off by -1.00 EUR",
+		"account box:1 holds 8.00 EUR, expected 9.00 EUR",
+	}, check.Problems)
+
+	// A database from before the ledger gets its balances in one opening transaction
+	suite.Require().NoError(suite.db.DB.Exec("DELETE FROM ledger_postings").Error)
+	suite.Require().NoError(suite.db.DB.Exec("DELETE FROM ledger_transactions").Error)
+	opening, err := suite.services.Ledger.Open()
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), models.LedgerOpening, opening.Kind)
+	assert.Len(suite.T(), opening.Postings, 3)
+	suite.assertLedgerConsistent()
+}
+
+// assertLedgerConsistent checks the ledger against the records it is posted from
+func (suite *IntegrationTestSuite) assertLedgerConsistent() {
+	check, err := suite.services.Ledger.Check()
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), check.Problems)
+}
+
+// ledgerBalances returns the EUR balance of every ledger account by code
+func (suite *IntegrationTestSuite) ledgerBalances(asOf *time.Time) map[string]models.Money {
+	balances, err := suite.services.Ledger.Balances(asOf)
+	suite.Require().NoError(err)
+	byCode := make(map[string]models.Money, len(balances))
+	for _, balance := range balances {
+		byCode[balance.Code] = balance.Balance
+	}
+	return byCode
+}
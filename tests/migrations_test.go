@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-username/coffee-cups-system/internal/database"
+)
+
+// TestCreateMigration tests that new migrations get the next version number
+func TestCreateMigration(t *testing.T) {
+	dir := t.TempDir()
+	dialectDir := filepath.Join(dir, "postgres")
+	require.NoError(t, os.Mkdir(dialectDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dialectDir, "0001_initial.up.sql"), []byte("SELECT 1;"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dialectDir, "0001_initial.down.sql"), []byte("SELECT 1;"), 0o644))
+
+	files, err := database.CreateMigration(dir, "Add Box Index")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		filepath.Join(dialectDir, "0002_add_box_index.up.sql"),
+		filepath.Join(dialectDir, "0002_add_box_index.down.sql"),
+	}, files)
+	for _, file := range files {
+		assert.FileExists(t, file)
+	}
+}
+
+// TestCreateMigrationRequiresName tests that a name must be given
+func TestCreateMigrationRequiresName(t *testing.T) {
+	_, err := database.CreateMigration(t.TempDir(), "  ")
+	assert.Error(t, err)
+}
+
+// TestMigrationsRoundTrip tests that every migration can be rolled back and reapplied
+func TestMigrationsRoundTrip(t *testing.T) {
+	db := openTestDatabase(t)
+	defer db.Close()
+
+	migrator, err := db.Migrator()
+	require.NoError(t, err)
+
+	applied, err := migrator.Up()
+	require.NoError(t, err)
+	require.NotEmpty(t, applied)
+
+	rolledBack, err := migrator.Down(len(applied))
+	require.NoError(t, err)
+	assert.Len(t, rolledBack, len(applied))
+
+	pending, err := migrator.Pending()
+	require.NoError(t, err)
+	assert.Len(t, pending, len(applied))
+
+	_, err = migrator.Up()
+	require.NoError(t, err)
+	statuses, err := migrator.Status()
+	require.NoError(t, err)
+	for _, status := range statuses {
+		assert.NotNil(t, status.AppliedAt, status.Name)
+	}
+}
+
+// TestMigrationsUpPartialFailure tests that Up reports the migrations it
+// applied before one failed
+func TestMigrationsUpPartialFailure(t *testing.T) {
+	db := openTestDatabase(t)
+	defer db.Close()
+
+	migrator, err := db.Migrator()
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.NoError(t, err)
+	rolledBack, err := migrator.Down(2)
+	require.NoError(t, err)
+	require.Len(t, rolledBack, 2)
+
+	// The last migration adds this column, so applying it again fails
+	require.NoError(t, db.DB.Exec("ALTER TABLE boxes ADD COLUMN billing_mode TEXT").Error)
+	applied, err := migrator.Up()
+	assert.Error(t, err)
+	require.Len(t, applied, 1)
+	assert.Equal(t, rolledBack[1].Version, applied[0].Version)
+
+	pending, err := migrator.Pending()
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, rolledBack[0].Version, pending[0].Version)
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-username/coffee-cups-system/internal/models"
+)
+
+// TestMoneyAllocateSumsToTotal tests that shares always add back up to the price
+func TestMoneyAllocateSumsToTotal(t *testing.T) {
+	price := models.NewMoney(1599, "EUR")
+
+	// Seven people sharing a 20 cup box
+	weights := []int64{5, 4, 3, 3, 2, 2, 1}
+	shares := price.Allocate(weights)
+
+	var sum int64
+	for _, share := range shares {
+		sum += share.MinorUnits
+		assert.Equal(t, "EUR", share.Currency)
+	}
+	assert.Equal(t, price.MinorUnits, sum)
+}
+
+// TestMoneyAllocateIsDeterministic tests the remainder allocation rule
+func TestMoneyAllocateIsDeterministic(t *testing.T) {
+	shares := models.NewMoney(100, "EUR").Allocate([]int64{1, 1, 1})
+
+	// The leftover cent goes to the lowest index on a tie
+	assert.Equal(t, int64(34), shares[0].MinorUnits)
+	assert.Equal(t, int64(33), shares[1].MinorUnits)
+	assert.Equal(t, int64(33), shares[2].MinorUnits)
+}
+
+// TestMoneyAllocateZeroWeights tests allocation without any weight
+func TestMoneyAllocateZeroWeights(t *testing.T) {
+	shares := models.NewMoney(1599, "EUR").Allocate([]int64{0, 0})
+
+	assert.Equal(t, int64(0), shares[0].MinorUnits)
+	assert.Equal(t, int64(0), shares[1].MinorUnits)
+}
+
+// TestParseMoney tests parsing decimal strings into minor units
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"15.99", 1599},
+		{"15,99", 1599},
+		{"15", 1500},
+		{"0.5", 50},
+		{"-2.10", -210},
+	}
+
+	for _, tt := range tests {
+		money, err := models.ParseMoney(tt.input, "eur")
+		require.NoError(t, err, tt.input)
+		assert.Equal(t, tt.expected, money.MinorUnits, tt.input)
+		assert.Equal(t, "EUR", money.Currency)
+	}
+
+	for _, input := range []string{"1.999", "abc", "", "1.-5", "--1", "1.+5", "+1", "1.", ".5", "- 1", "92233720368547758.07"} {
+		_, err := models.ParseMoney(input, "EUR")
+		assert.Error(t, err, input)
+	}
+	largest, err := models.ParseMoney("92233720368547757.99", "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, int64(9223372036854775799), largest.MinorUnits)
+}
+
+// TestMoneyCurrencyMismatch tests that amounts in different currencies don't add up
+func TestMoneyCurrencyMismatch(t *testing.T) {
+	eur, usd := models.NewMoney(100, "EUR"), models.NewMoney(100, "USD")
+	assert.Equal(t, models.NewMoney(200, "EUR"), eur.Add(eur))
+	assert.Panics(t, func() { eur.Add(usd) })
+	assert.Panics(t, func() { eur.Sub(usd) })
+}
+
+// TestMoneyString tests formatting of amounts
+func TestMoneyString(t *testing.T) {
+	assert.Equal(t, "15.99 EUR", models.NewMoney(1599, "EUR").String())
+	assert.Equal(t, "-0.05 EUR", models.NewMoney(-5, "EUR").String())
+}
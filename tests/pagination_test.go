@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"github.com/your-username/coffee-cups-system/internal/services"
+)
+
+// TestPageWalk tests that following next cursors visits every row once,
+// also when rows share the sort value
+func (suite *IntegrationTestSuite) TestPageWalk() {
+	anna := suite.newUser("Anna")
+	box, err := suite.services.Box.CreateBox("Busy Box", 20, models.NewMoney(1000, "EUR"), anna.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	for i := 0; i < 5; i++ {
+		_, err := suite.services.Coffee.LogCoffee(anna.ID, box.ID)
+		suite.Require().NoError(err)
+	}
+	// All but the first log share an earlier time, so ties are broken by ID
+	sameTime := time.Now().Add(-time.Hour)
+	suite.Require().NoError(suite.db.DB.Model(&models.CoffeeLog{}).Where("id > ?", 1).Update("logged_at", sameTime).Error)
+
+	var ids []uint
+	page := services.Page{Limit: 2}
+	for pages := 0; pages < 5; pages++ {
+		logs, next, err := suite.services.Coffee.ListCoffeeLogs(services.CoffeeLogFilter{TeamID: suite.team.ID, Page: page})
+		suite.Require().NoError(err)
+		for _, l := range logs {
+			ids = append(ids, l.ID)
+		}
+		if next == "" {
+			break
+		}
+		page.Cursor = next
+	}
+	assert.Equal(suite.T(), []uint{1, 5, 4, 3, 2}, ids)
+
+	var names []string
+	page = services.Page{Limit: 1, Sort: "-first_name"}
+	suite.newUser("Boris")
+	for pages := 0; pages < 5; pages++ {
+		users, next, err := suite.services.User.ListTeammates(anna.ID, services.UserFilter{Page: page})
+		suite.Require().NoError(err)
+		for _, u := range users {
+			names = append(names, u.FirstName)
+		}
+		if next == "" {
+			break
+		}
+		page.Cursor = next
+	}
+	assert.Equal(suite.T(), []string{"Boris", "Anna"}, names)
+}
+
+// TestListLinkHeader tests that list responses point at the next page
+func (suite *IntegrationTestSuite) TestListLinkHeader() {
+	owner := suite.newUser("Owner")
+	for i := 0; i < 3; i++ {
+		suite.createPayment(owner, owner, 100)
+	}
+
+	target := "/api/v1/teams/1/payments?limit=2&sort=amount"
+	var listed []models.Payment
+	for pages := 0; pages < 2; pages++ {
+		rr := suite.serve(owner, "GET", target, nil, suite.handlers.GetPayments)
+		suite.Require().Equal(http.StatusOK, rr.Code)
+		var payments []models.Payment
+		suite.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &payments))
+		listed = append(listed, payments...)
+
+		link := rr.Header().Get("Link")
+		if pages == 1 {
+			assert.Empty(suite.T(), link)
+			break
+		}
+		cursor := rr.Header().Get("X-Next-Cursor")
+		suite.Require().NotEmpty(cursor)
+		suite.Require().True(strings.HasSuffix(link, `>; rel="next"`), link)
+		target = strings.TrimSuffix(strings.TrimPrefix(link, "<"), `>; rel="next"`)
+		assert.Contains(suite.T(), target, "cursor="+cursor)
+		assert.Contains(suite.T(), target, "sort=amount")
+	}
+	suite.Require().Len(listed, 3)
+	assert.Equal(suite.T(), []uint{1, 2, 3}, []uint{listed[0].ID, listed[1].ID, listed[2].ID})
+}
+
+// TestListParameters tests how list handlers reject bad parameters
+func (suite *IntegrationTestSuite) TestListParameters() {
+	owner := suite.newUser("Owner")
+	for i := 0; i < 2; i++ {
+		suite.createPayment(owner, owner, 100)
+	}
+	rr := suite.serve(owner, "GET", "/api/v1/teams/1/payments?limit=1", nil, suite.handlers.GetPayments)
+	suite.Require().Equal(http.StatusOK, rr.Code)
+	cursor := rr.Header().Get("X-Next-Cursor")
+	suite.Require().NotEmpty(cursor)
+
+	cases := []struct {
+		query  string
+		status int
+		field  string
+	}{
+		{"limit=many", http.StatusBadRequest, ""},
+		{"from=yesterday", http.StatusBadRequest, ""},
+		{"limit=500", http.StatusUnprocessableEntity, "limit"},
+		{"sort=price", http.StatusUnprocessableEntity, "sort"},
+		{"cursor=garbage", http.StatusUnprocessableEntity, "cursor"},
+		{"sort=amount&cursor=" + cursor, http.StatusUnprocessableEntity, "cursor"},
+		{"from=2026-03-02&to=2026-03-01", http.StatusUnprocessableEntity, "to"},
+	}
+	for _, c := range cases {
+		rr := suite.serve(owner, "GET", "/api/v1/teams/1/payments?"+c.query, nil, suite.handlers.GetPayments)
+		suite.Require().Equal(c.status, rr.Code, c.query)
+		if c.field != "" {
+			failure := suite.decodeError(rr)
+			suite.Require().Len(failure.Details, 1, c.query)
+			assert.Equal(suite.T(), c.field, failure.Details[0].Field, c.query)
+		}
+	}
+}
+
+// TestListFilters tests the box, user and date filters of the list handlers
+func (suite *IntegrationTestSuite) TestListFilters() {
+	anna := suite.newUser("Anna")
+	boris := suite.newUser("Boris")
+	price := models.NewMoney(1000, "EUR")
+	annaBox, err := suite.services.Box.CreateBox("Anna's Box", 10, price, anna.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	borisBox, err := suite.services.Box.CreateBox("Boris's Box", 10, price, boris.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	_, err = suite.services.Coffee.LogCoffee(anna.ID, annaBox.ID)
+	suite.Require().NoError(err)
+	_, err = suite.services.Coffee.LogCoffee(boris.ID, borisBox.ID)
+	suite.Require().NoError(err)
+	_, err = suite.services.Box.FinishBox(borisBox.ID)
+	suite.Require().NoError(err)
+
+	var boxes []models.Box
+	suite.getList(anna, "/api/v1/teams/1/boxes?active=false", suite.handlers.GetBoxes, &boxes)
+	suite.Require().Len(boxes, 1)
+	assert.Equal(suite.T(), borisBox.ID, boxes[0].ID)
+	suite.getList(anna, "/api/v1/teams/1/boxes?created_by=2", suite.handlers.GetBoxes, &boxes)
+	assert.Empty(suite.T(), boxes)
+
+	var logs []models.CoffeeLog
+	suite.getList(anna, "/api/v1/teams/1/coffee-logs?box_id=2", suite.handlers.GetCoffeeLogs, &logs)
+	suite.Require().Len(logs, 1)
+	assert.Equal(suite.T(), boris.ID, logs[0].UserID)
+	suite.getList(anna, "/api/v1/teams/1/coffee-logs?to=2000-01-01", suite.handlers.GetCoffeeLogs, &logs)
+	assert.Empty(suite.T(), logs)
+
+	var users []models.User
+	suite.getList(anna, "/api/v1/users?sort=-id", suite.handlers.GetUsers, &users)
+	suite.Require().Len(users, 2)
+	assert.Equal(suite.T(), boris.ID, users[0].ID)
+	suite.getList(anna, "/api/v1/users?active=false", suite.handlers.GetUsers, &users)
+	assert.Empty(suite.T(), users)
+}
+
+// getList calls a list handler and decodes its JSON array into items
+func (suite *IntegrationTestSuite) getList(user *models.User, target string, handler http.HandlerFunc, items interface{}) {
+	rr := suite.serve(user, "GET", target, nil, handler)
+	suite.Require().Equal(http.StatusOK, rr.Code, target)
+	suite.Require().NoError(json.Unmarshal(rr.Body.Bytes(), items))
+}
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/your-username/coffee-cups-system/internal/handlers"
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"github.com/your-username/coffee-cups-system/internal/services"
+)
+
+// TestPaymentLifecycle tests paying, cancelling and filtering payments
+func (suite *IntegrationTestSuite) TestPaymentLifecycle() {
+	owner := suite.newUser("Owner")
+	anna := suite.newUser("Anna")
+	boris := suite.newUser("Boris")
+	suite.createPayment(anna, owner, 250)
+	suite.createPayment(boris, owner, 400)
+
+	pending, next, err := suite.services.Payment.ListPayments(services.PaymentFilter{Status: models.PaymentStatusPending})
+	suite.Require().NoError(err)
+	suite.Require().Len(pending, 2)
+	assert.Empty(suite.T(), next)
+
+	annaPayment := pending[1]
+	borisPayment := pending[0]
+	suite.Require().Equal(anna.ID, annaPayment.UserID)
+
+	paid, err := suite.services.Payment.MarkPaymentAsPaid(annaPayment.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), models.PaymentStatusPaid, paid.Status)
+	assert.NotNil(suite.T(), paid.PaidAt)
+
+	cancelled, err := suite.services.Payment.CancelPayment(borisPayment.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), models.PaymentStatusCancelled, cancelled.Status)
+
+	// Paid and cancelled are final
+	_, err = suite.services.Payment.CancelPayment(annaPayment.ID)
+	assert.ErrorIs(suite.T(), err, services.ErrPaymentNotPending)
+	_, err = suite.services.Payment.MarkPaymentAsPaid(borisPayment.ID)
+	assert.ErrorIs(suite.T(), err, services.ErrPaymentNotPending)
+
+	_, err = suite.services.Payment.GetPaymentByID(9999)
+	assert.ErrorIs(suite.T(), err, services.ErrPaymentNotFound)
+
+	// Cancelled payments are not part of any settlement
+	plan, err := suite.services.Settlement.GetPlan(suite.team.ID)
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), plan.PaymentIDs)
+
+	var listed []models.Payment
+	rr := suite.serve(owner, "GET", "/api/v1/teams/1/payments?status=cancelled&user_id="+strconv.FormatUint(uint64(boris.ID), 10), nil, suite.handlers.GetPayments)
+	suite.Require().Equal(http.StatusOK, rr.Code)
+	suite.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &listed))
+	suite.Require().Len(listed, 1)
+	assert.Equal(suite.T(), borisPayment.ID, listed[0].ID)
+
+	rr = suite.serve(owner, "GET", "/api/v1/teams/1/payments?status=bogus", nil, suite.handlers.GetPayments)
+	assert.Equal(suite.T(), http.StatusUnprocessableEntity, rr.Code)
+}
+
+// TestMarkPaymentAsPaidPermissions tests who may mark a payment as paid
+func (suite *IntegrationTestSuite) TestMarkPaymentAsPaidPermissions() {
+	owner := suite.newUser("Owner")
+	anna := suite.newUser("Anna")
+	stranger := suite.newUser("Stranger")
+	suite.createPayment(anna, owner, 250)
+
+	vars := map[string]string{"id": "1"}
+	rr := suite.serve(stranger, "POST", "/api/v1/payments/1/pay", vars, suite.handlers.MarkPaymentAsPaid)
+	assert.Equal(suite.T(), http.StatusForbidden, rr.Code)
+
+	rr = suite.serve(anna, "POST", "/api/v1/payments/1/pay", vars, suite.handlers.MarkPaymentAsPaid)
+	assert.Equal(suite.T(), http.StatusOK, rr.Code)
+
+	rr = suite.serve(owner, "POST", "/api/v1/payments/1/cancel", vars, suite.handlers.CancelPayment)
+	assert.Equal(suite.T(), http.StatusConflict, rr.Code)
+}
+
+// serve calls a handler as the given user
+func (suite *IntegrationTestSuite) serve(user *models.User, method, target string, vars map[string]string, handler http.HandlerFunc) *httptest.ResponseRecorder {
+	return suite.serveJSON(user, method, target, vars, nil, handler)
+}
+
+// serveJSON calls a handler as the given user within the test team with body encoded as JSON
+func (suite *IntegrationTestSuite) serveJSON(user *models.User, method, target string, vars map[string]string, body interface{}, handler http.HandlerFunc) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		suite.Require().NoError(err)
+		reader = bytes.NewReader(encoded)
+	}
+
+	req := httptest.NewRequest(method, target, reader)
+	if vars != nil {
+		req = mux.SetURLVars(req, vars)
+	}
+	req = req.WithContext(handlers.ContextWithTeam(handlers.ContextWithUser(req.Context(), user), suite.team))
+
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+	return rr
+}
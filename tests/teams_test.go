@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/your-username/coffee-cups-system/internal/handlers"
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"github.com/your-username/coffee-cups-system/internal/services"
+)
+
+// TestTeamLinking tests linking group chats to teams and team-scoped boxes
+func (suite *IntegrationTestSuite) TestTeamLinking() {
+	owner := suite.newUser("Owner")
+
+	team, err := suite.services.Team.LinkChat(-100, "Floor 3")
+	suite.Require().NoError(err)
+	renamed, err := suite.services.Team.LinkChat(-100, "Third Floor")
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), team.ID, renamed.ID)
+
+	found, err := suite.services.Team.GetTeamByChatID(-100)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "Third Floor", found.Name)
+	_, err = suite.services.Team.GetTeamByChatID(-200)
+	assert.ErrorIs(suite.T(), err, services.ErrTeamNotFound)
+
+	box, err := suite.services.Box.CreateBox("Moved Box", 10, models.NewMoney(1000, "EUR"), owner.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	_, err = suite.services.Box.CreateBox("Staying Box", 10, models.NewMoney(1000, "EUR"), owner.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.services.Box.AssignTeam(box.ID, team.ID))
+
+	boxes, err := suite.services.Box.GetActiveTeamBoxes(team.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(boxes, 1)
+	assert.Equal(suite.T(), box.ID, boxes[0].ID)
+}
+
+// TestTeamIsolation tests that teams cannot see or use each other's boxes and payments
+func (suite *IntegrationTestSuite) TestTeamIsolation() {
+	owner := suite.newUser("Owner")
+	anna := suite.newUser("Anna")
+	floor2, err := suite.services.Team.CreateTeam("Floor 2")
+	suite.Require().NoError(err)
+	outsider, err := suite.services.User.CreateOrUpdateUser(999, "", "Outsider", "Tester")
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.services.Team.AddMember(floor2.ID, outsider.ID))
+	suite.Require().NoError(suite.services.Team.AddMember(floor2.ID, outsider.ID))
+
+	box, err := suite.services.Box.CreateBox("Team Box", 10, models.NewMoney(1000, "EUR"), owner.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	_, err = suite.services.Coffee.LogCoffee(outsider.ID, box.ID)
+	assert.ErrorIs(suite.T(), err, services.ErrNotTeamMember)
+	suite.createPayment(anna, owner, 250)
+
+	members, err := suite.services.Team.GetMembers(floor2.ID)
+	suite.Require().NoError(err)
+	assert.Len(suite.T(), members, 1)
+	boxes, err := suite.services.Box.GetActiveUserBoxes(outsider.ID)
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), boxes)
+	teammates, err := suite.services.User.GetTeammates(outsider.ID)
+	suite.Require().NoError(err)
+	assert.Len(suite.T(), teammates, 1)
+
+	plan, err := suite.services.Settlement.GetPlan(floor2.ID)
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), plan.PaymentIDs)
+	plan, err = suite.services.Settlement.GetPlan(suite.team.ID)
+	suite.Require().NoError(err)
+	assert.Len(suite.T(), plan.PaymentIDs, 1)
+
+	listBoxes := suite.handlers.RequireTeamMember(http.HandlerFunc(suite.handlers.GetBoxes))
+	req := mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/teams/1/boxes", nil), map[string]string{"team_id": "1"})
+	rr := httptest.NewRecorder()
+	listBoxes.ServeHTTP(rr, req.WithContext(handlers.ContextWithUser(req.Context(), outsider)))
+	assert.Equal(suite.T(), http.StatusForbidden, rr.Code)
+	rr = httptest.NewRecorder()
+	listBoxes.ServeHTTP(rr, req.WithContext(handlers.ContextWithUser(req.Context(), anna)))
+	assert.Equal(suite.T(), http.StatusOK, rr.Code)
+
+	rr = suite.serve(outsider, "GET", "/api/v1/boxes/1", map[string]string{"id": "1"}, suite.handlers.GetBox)
+	assert.Equal(suite.T(), http.StatusNotFound, rr.Code)
+	rr = suite.serve(outsider, "GET", "/api/v1/payments/1", map[string]string{"id": "1"}, suite.handlers.GetPayment)
+	assert.Equal(suite.T(), http.StatusNotFound, rr.Code)
+	rr = suite.serveJSON(outsider, "POST", "/api/v1/coffee-logs", nil, map[string]uint{"box_id": box.ID}, suite.handlers.LogCoffee)
+	assert.Equal(suite.T(), http.StatusNotFound, rr.Code)
+	assert.Equal(suite.T(), services.ErrBoxNotFound.Code, suite.decodeError(rr).Error)
+
+	annaID := fmt.Sprint(anna.TelegramID)
+	rr = suite.serve(outsider, "GET", "/api/v1/users/"+annaID, map[string]string{"id": annaID}, suite.handlers.GetUser)
+	assert.Equal(suite.T(), http.StatusNotFound, rr.Code)
+	assert.Equal(suite.T(), services.ErrUserNotFound.Code, suite.decodeError(rr).Error)
+	rr = suite.serve(owner, "GET", "/api/v1/users/"+annaID, map[string]string{"id": annaID}, suite.handlers.GetUser)
+	assert.Equal(suite.T(), http.StatusOK, rr.Code)
+	rr = suite.serve(outsider, "GET", "/api/v1/users/999", map[string]string{"id": "999"}, suite.handlers.GetUser)
+	assert.Equal(suite.T(), http.StatusOK, rr.Code)
+}
+
+// TestEventsPublished tests that closing boxes and accepting plans publish events once
+func (suite *IntegrationTestSuite) TestEventsPublished() {
+	var events []services.Event
+	suite.services.Events.Subscribe(func(event services.Event) {
+		events = append(events, event)
+	})
+
+	owner := suite.newUser("Owner")
+	anna := suite.newUser("Anna")
+	box, err := suite.services.Box.CreateBox("Event Box", 2, models.NewMoney(1000, "EUR"), owner.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	_, err = suite.services.Coffee.LogCoffee(anna.ID, box.ID)
+	suite.Require().NoError(err)
+
+	suite.closeBox(box.ID)
+	_, err = suite.services.Box.CloseBox(box.ID)
+	suite.Require().NoError(err)
+	// Anna took the second to last cup, which is the low-stock threshold of a 2-cup box
+	suite.Require().Len(events, 2)
+	assert.IsType(suite.T(), services.BoxLowStockEvent{}, events[0])
+	closed, ok := events[1].(services.BoxClosedEvent)
+	suite.Require().True(ok)
+	assert.Len(suite.T(), closed.Settlement.Payments, 1)
+
+	plan, err := suite.services.Settlement.GetPlan(suite.team.ID)
+	suite.Require().NoError(err)
+	_, err = suite.services.Settlement.AcceptPlan(suite.team.ID, plan.Token, owner.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(events, 3)
+	accepted, ok := events[2].(services.SettlementAcceptedEvent)
+	suite.Require().True(ok)
+	assert.Equal(suite.T(), "Anna", accepted.Plan.Transfers[0].FromName)
+}
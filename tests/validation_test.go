@@ -0,0 +1,166 @@
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"github.com/your-username/coffee-cups-system/internal/services"
+)
+
+// TestValidationRules tests each rule on a valid and an invalid value
+func TestValidationRules(t *testing.T) {
+	eur := func(minorUnits int64) models.Money { return models.NewMoney(minorUnits, "EUR") }
+	cases := []struct {
+		name  string
+		rule  services.Rule
+		field string
+	}{
+		{"required", services.Required("name", "  "), "name"},
+		{"max length", services.MaxLength("name", strings.Repeat("é", 4), 3), "name"},
+		{"below range", services.Between("total_cups", 0, 1, 10), "total_cups"},
+		{"above range", services.Between("total_cups", 11, 1, 10), "total_cups"},
+		{"missing id", services.ID("box_id", 0), "box_id"},
+		{"one of", services.OneOf("billing_mode", "monthly", "postpaid", "prepaid"), "billing_mode"},
+		{"zero amount", services.PositiveAmount("amount", eur(0)), "amount.minor_units"},
+		{"negative price", services.NonNegativeAmount("price", eur(-1)), "price.minor_units"},
+		{"bad currency", services.NonNegativeAmount("price", models.Money{MinorUnits: 100, Currency: "EURO"}), "price.currency"},
+	}
+	for _, c := range cases {
+		failure := c.rule()
+		if assert.NotNil(t, failure, c.name) {
+			assert.Equal(t, c.field, failure.Field, c.name)
+		}
+	}
+
+	valid := []services.Rule{
+		services.Required("name", "Office"),
+		services.MaxLength("name", strings.Repeat("é", 3), 3),
+		services.Between("total_cups", 10, 1, 10),
+		services.ID("box_id", 1),
+		services.OneOf("billing_mode", "prepaid", "postpaid", "prepaid"),
+		services.PositiveAmount("amount", eur(1)),
+		services.NonNegativeAmount("price", models.Money{MinorUnits: 0}),
+	}
+	assert.NoError(t, services.Validate(valid...))
+}
+
+// TestValidateCollectsFields tests that every failing field is reported at once
+func TestValidateCollectsFields(t *testing.T) {
+	err := services.Validate(services.BoxRules("", 0, models.NewMoney(-5, "EUR"))...)
+	require.ErrorIs(t, err, services.ErrValidation)
+
+	var domainErr *services.Error
+	require.True(t, errors.As(err, &domainErr))
+	assert.Equal(t, []services.FieldError{
+		{Field: "name", Message: "is required"},
+		{Field: "total_cups", Message: "must be between 1 and 10000"},
+		{Field: "price.minor_units", Message: "must not be negative"},
+	}, domainErr.Fields)
+}
+
+// TestServiceValidation tests that services reject invalid input without the handlers
+func (suite *IntegrationTestSuite) TestServiceValidation() {
+	owner := suite.newUser("Owner")
+	anna := suite.newUser("Anna")
+	price := models.NewMoney(1000, "EUR")
+
+	_, err := suite.services.Box.CreateBox("Empty", 0, price, owner.ID, suite.team.ID)
+	assert.ErrorIs(suite.T(), err, services.ErrValidation)
+	_, err = suite.services.Box.CreateBox("Ghost", 10, price, 99, suite.team.ID)
+	suite.assertFields(err, "created_by")
+	_, err = suite.services.Box.CreateBox("Homeless", 10, price, owner.ID, 99)
+	suite.assertFields(err, "team_id")
+
+	_, err = suite.services.Team.CreateTeam(" ")
+	suite.assertFields(err, "name")
+	suite.assertFields(suite.services.Team.AddMember(suite.team.ID, 99), "user_id")
+
+	box, err := suite.services.Box.CreateBox("Box", 10, price, owner.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	_, err = suite.services.Payment.CreatePayment(anna.ID, box.ID, models.NewMoney(0, "EUR"))
+	suite.assertFields(err, "amount.minor_units")
+	_, err = suite.services.Payment.CreatePayment(anna.ID, box.ID, models.NewMoney(500, "USD"))
+	suite.assertFields(err, "amount.currency")
+	outsider, err := suite.services.User.CreateOrUpdateUser(99, "outsider", "Outsider", "")
+	suite.Require().NoError(err)
+	_, err = suite.services.Payment.CreatePayment(outsider.ID, box.ID, models.NewMoney(500, "EUR"))
+	assert.ErrorIs(suite.T(), err, services.ErrMemberRequired)
+
+	coffeeLog, err := suite.services.Coffee.LogCoffee(anna.ID, box.ID)
+	suite.Require().NoError(err)
+	_, err = suite.services.Coffee.VoidCoffeeLog(coffeeLog.ID, owner.ID, "")
+	suite.assertFields(err, "reason")
+	_, err = suite.services.Settlement.AcceptPlan(suite.team.ID, "", owner.ID)
+	suite.assertFields(err, "token")
+	_, err = suite.services.Wallet.TopUp(suite.team.ID, anna.ID, models.NewMoney(100, "EUR"), strings.Repeat("x", 501), owner.ID)
+	suite.assertFields(err, "note")
+	suite.assertLedgerConsistent()
+}
+
+// TestRequestValidation tests the field details returned for invalid request bodies
+func (suite *IntegrationTestSuite) TestRequestValidation() {
+	admin := suite.newUser("Admin")
+	suite.Require().NoError(suite.services.User.SetRole(admin.ID, models.RoleAdmin))
+	admin.Role = models.RoleAdmin
+	vars := map[string]string{"id": "1"}
+
+	cases := []struct {
+		name    string
+		target  string
+		handler http.HandlerFunc
+		body    map[string]interface{}
+		fields  []string
+	}{
+		{"team", "/api/v1/teams", suite.handlers.CreateTeam,
+			map[string]interface{}{"name": strings.Repeat("x", 101)}, []string{"name"}},
+		{"member", "/api/v1/teams/1/members", suite.handlers.AddTeamMember,
+			map[string]interface{}{}, []string{"user_id"}},
+		{"box", "/api/v1/teams/1/boxes", suite.handlers.CreateBox,
+			map[string]interface{}{"name": "", "total_cups": -3, "price": map[string]interface{}{"minor_units": -1}},
+			[]string{"name", "total_cups", "price.minor_units"}},
+		{"low stock", "/api/v1/teams/1/boxes", suite.handlers.CreateBox,
+			map[string]interface{}{"name": "Box", "total_cups": 5, "low_stock_threshold": 5}, []string{"low_stock_threshold"}},
+		{"coffee", "/api/v1/coffee-logs", suite.handlers.LogCoffee,
+			map[string]interface{}{}, []string{"box_id"}},
+		{"void", "/api/v1/coffee-logs/1/void", suite.handlers.VoidCoffeeLog,
+			map[string]interface{}{"reason": " "}, []string{"reason"}},
+		{"payment", "/api/v1/payments", suite.handlers.CreatePayment,
+			map[string]interface{}{"box_id": 1, "amount": map[string]interface{}{"minor_units": 100, "currency": "12"}},
+			[]string{"user_id", "amount.currency"}},
+		{"settlement", "/api/v1/teams/1/settlements/plan/accept", suite.handlers.AcceptSettlementPlan,
+			map[string]interface{}{}, []string{"token"}},
+		{"billing mode", "/api/v1/teams/1/billing-mode", suite.handlers.SetTeamBillingMode,
+			map[string]interface{}{"billing_mode": "monthly"}, []string{"billing_mode"}},
+		{"top-up", "/api/v1/teams/1/wallet/top-ups", suite.handlers.TopUpWallet,
+			map[string]interface{}{"user_id": admin.ID, "amount": map[string]interface{}{"minor_units": -100}},
+			[]string{"amount.minor_units"}},
+	}
+	for _, c := range cases {
+		rr := suite.serveJSON(admin, "POST", c.target, vars, c.body, c.handler)
+		suite.Require().Equal(http.StatusUnprocessableEntity, rr.Code, c.name)
+		failure := suite.decodeError(rr)
+		assert.Equal(suite.T(), services.ErrValidation.Code, failure.Error, c.name)
+		fields := make([]string, 0, len(failure.Details))
+		for _, detail := range failure.Details {
+			fields = append(fields, detail.Field)
+		}
+		assert.Equal(suite.T(), c.fields, fields, c.name)
+	}
+}
+
+// assertFields checks that err is a validation error about exactly the given fields
+func (suite *IntegrationTestSuite) assertFields(err error, fields ...string) {
+	var domainErr *services.Error
+	suite.Require().True(errors.As(err, &domainErr), "expected a validation error, got %v", err)
+	suite.Require().Equal(services.KindValidation, domainErr.Kind)
+	got := make([]string, 0, len(domainErr.Fields))
+	for _, field := range domainErr.Fields {
+		got = append(got, field.Field)
+	}
+	assert.Equal(suite.T(), fields, got)
+}
@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/your-username/coffee-cups-system/internal/models"
+	"github.com/your-username/coffee-cups-system/internal/services"
+)
+
+// TestPrepaidWallet tests that cups of a prepaid team are paid from wallets
+func (suite *IntegrationTestSuite) TestPrepaidWallet() {
+	owner := suite.newUser("Owner")
+	anna := suite.newUser("Anna")
+	_, err := suite.services.Team.SetBillingMode(suite.team.ID, "monthly")
+	assert.ErrorIs(suite.T(), err, services.ErrInvalidBillingMode)
+	team, err := suite.services.Team.SetBillingMode(suite.team.ID, models.BillingPrepaid)
+	suite.Require().NoError(err)
+	assert.True(suite.T(), team.IsPrepaid())
+
+	box, err := suite.services.Box.CreateBox("Prepaid Box", 4, models.NewMoney(800, "EUR"), owner.ID, suite.team.ID)
+	suite.Require().NoError(err)
+
+	// An empty wallet doesn't cover a cup
+	_, err = suite.services.Coffee.LogCoffee(anna.ID, box.ID)
+	assert.ErrorIs(suite.T(), err, services.ErrInsufficientFunds)
+	used, err := box.GetUsedCups(suite.db.DB)
+	suite.Require().NoError(err)
+	assert.Zero(suite.T(), used)
+
+	_, err = suite.services.Wallet.TopUp(suite.team.ID, anna.ID, models.NewMoney(0, "EUR"), "", owner.ID)
+	assert.ErrorIs(suite.T(), err, services.ErrInvalidTopUp)
+	_, err = suite.services.Wallet.TopUp(suite.team.ID, anna.ID, models.NewMoney(500, "EUR"), "cash", owner.ID)
+	suite.Require().NoError(err)
+
+	// Each cup costs 2.00; the third one is refused
+	for i := 0; i < 2; i++ {
+		_, err := suite.services.Coffee.LogCoffee(anna.ID, box.ID)
+		suite.Require().NoError(err)
+	}
+	_, err = suite.services.Coffee.LogCoffee(anna.ID, box.ID)
+	assert.ErrorIs(suite.T(), err, services.ErrInsufficientFunds)
+	balance, err := suite.services.Wallet.GetBalance(suite.team.ID, anna.ID, "EUR")
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), models.NewMoney(100, "EUR"), balance)
+
+	// Undoing a cup refunds it
+	_, err = suite.services.Coffee.UndoLastCoffee(anna.ID, time.Minute)
+	suite.Require().NoError(err)
+	wallet, err := suite.services.Wallet.GetWallet(suite.team.ID, anna.ID, 0)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), []models.Money{models.NewMoney(300, "EUR")}, wallet.Balance)
+	suite.Require().Len(wallet.Entries, 4)
+	assert.Equal(suite.T(), models.WalletRefund, wallet.Entries[0].Kind)
+	assert.Equal(suite.T(), models.WalletTopUp, wallet.Entries[3].Kind)
+
+	// The mode can't change while the box is in use
+	_, err = suite.services.Team.SetBillingMode(suite.team.ID, models.BillingPostpaid)
+	assert.ErrorIs(suite.T(), err, services.ErrBillingModeInUse)
+
+	// Closing the box creates no payments, and nobody owes anything
+	settlement := suite.closeBox(box.ID)
+	assert.Empty(suite.T(), settlement.Payments)
+	userBalance, err := suite.services.Balance.GetUserBalance(anna.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), []models.Money{models.NewMoney(0, "EUR")}, userBalance.Outstanding)
+	assert.Equal(suite.T(), models.NewMoney(200, "EUR"), userBalance.Boxes[0].Paid)
+}
+
+// TestPrepaidRounding tests that wallets pay exactly the box price when it
+// doesn't divide evenly between the cups
+func (suite *IntegrationTestSuite) TestPrepaidRounding() {
+	owner := suite.newUser("Owner")
+	anna := suite.newUser("Anna")
+	_, err := suite.services.Team.SetBillingMode(suite.team.ID, models.BillingPrepaid)
+	suite.Require().NoError(err)
+	_, err = suite.services.Wallet.TopUp(suite.team.ID, anna.ID, models.NewMoney(1500, "EUR"), "cash", owner.ID)
+	suite.Require().NoError(err)
+
+	// 10.00 over 3 cups is 3.34, 3.33 and 3.33, also when a cup is voided and taken again
+	box, err := suite.services.Box.CreateBox("Odd Box", 3, models.NewMoney(1000, "EUR"), owner.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	first, err := suite.services.Coffee.LogCoffee(anna.ID, box.ID)
+	suite.Require().NoError(err)
+	for i := 0; i < 2; i++ {
+		_, err := suite.services.Coffee.LogCoffee(anna.ID, box.ID)
+		suite.Require().NoError(err)
+	}
+	_, err = suite.services.Coffee.VoidCoffeeLog(first.ID, owner.ID, "wrong box")
+	suite.Require().NoError(err)
+	_, err = suite.services.Coffee.LogCoffee(anna.ID, box.ID)
+	suite.Require().NoError(err)
+
+	wallet, err := suite.services.Wallet.GetBalance(suite.team.ID, anna.ID, "EUR")
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), models.NewMoney(500, "EUR"), wallet)
+	balance, err := suite.services.Balance.GetUserBalance(anna.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(balance.Boxes, 1)
+	assert.Equal(suite.T(), models.NewMoney(1000, "EUR"), balance.Boxes[0].Paid)
+	suite.assertLedgerConsistent()
+
+	suite.closeBox(box.ID)
+	assert.Equal(suite.T(), models.NewMoney(-1000, "EUR"), suite.ledgerBalances(nil)["purchaser:"+fmt.Sprint(owner.ID)])
+	suite.assertLedgerConsistent()
+}
+
+// TestWalletAPI tests the billing mode, wallet and top-up endpoints
+func (suite *IntegrationTestSuite) TestWalletAPI() {
+	admin := suite.newUser("Admin")
+	suite.Require().NoError(suite.services.User.SetRole(admin.ID, models.RoleAdmin))
+	admin.Role = models.RoleAdmin
+	anna := suite.newUser("Anna")
+	outsider, err := suite.services.User.CreateOrUpdateUser(99, "outsider", "Outsider", "")
+	suite.Require().NoError(err)
+
+	rr := suite.serveJSON(admin, "PUT", "/api/v1/teams/1/billing-mode", nil, map[string]string{"billing_mode": "monthly"}, suite.handlers.SetTeamBillingMode)
+	assert.Equal(suite.T(), http.StatusUnprocessableEntity, rr.Code)
+	rr = suite.serveJSON(admin, "PUT", "/api/v1/teams/1/billing-mode", nil, map[string]string{"billing_mode": "prepaid"}, suite.handlers.SetTeamBillingMode)
+	suite.Require().Equal(http.StatusOK, rr.Code)
+
+	topUp := map[string]interface{}{"user_id": anna.ID, "amount": map[string]interface{}{"minor_units": 2000, "currency": "EUR"}, "note": "cash"}
+	rr = suite.serveJSON(anna, "POST", "/api/v1/teams/1/wallet/top-ups", nil, topUp, suite.handlers.RequireAdmin(suite.handlers.TopUpWallet))
+	assert.Equal(suite.T(), http.StatusForbidden, rr.Code)
+	rr = suite.serveJSON(admin, "POST", "/api/v1/teams/1/wallet/top-ups", nil, topUp, suite.handlers.TopUpWallet)
+	suite.Require().Equal(http.StatusCreated, rr.Code)
+	topUp["user_id"] = outsider.ID
+	rr = suite.serveJSON(admin, "POST", "/api/v1/teams/1/wallet/top-ups", nil, topUp, suite.handlers.TopUpWallet)
+	assert.Equal(suite.T(), http.StatusUnprocessableEntity, rr.Code)
+
+	// Cups the wallet doesn't cover are refused with 402
+	box, err := suite.services.Box.CreateBox("Big Box", 2, models.NewMoney(5000, "EUR"), admin.ID, suite.team.ID)
+	suite.Require().NoError(err)
+	rr = suite.serveJSON(anna, "POST", "/api/v1/coffee-logs", nil, map[string]uint{"box_id": box.ID}, suite.handlers.LogCoffee)
+	assert.Equal(suite.T(), http.StatusPaymentRequired, rr.Code)
+
+	rr = suite.serve(anna, "GET", "/api/v1/teams/1/wallet", nil, suite.handlers.GetWallet)
+	suite.Require().Equal(http.StatusOK, rr.Code)
+	var wallet services.Wallet
+	suite.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &wallet))
+	assert.Equal(suite.T(), []models.Money{models.NewMoney(2000, "EUR")}, wallet.Balance)
+	suite.Require().Len(wallet.Entries, 1)
+	assert.Equal(suite.T(), "cash", wallet.Entries[0].Note)
+
+	// Only admins see other users' wallets
+	rr = suite.serve(anna, "GET", "/api/v1/teams/1/wallet?user_id=1", nil, suite.handlers.GetWallet)
+	assert.Equal(suite.T(), http.StatusForbidden, rr.Code)
+	rr = suite.serve(admin, "GET", "/api/v1/teams/1/wallet?user_id=2", nil, suite.handlers.GetWallet)
+	assert.Equal(suite.T(), http.StatusOK, rr.Code)
+}